@@ -0,0 +1,24 @@
+package notifications
+
+import (
+	"context"
+	hotelsDomain "hotels-api/domain/hotels"
+	"log"
+)
+
+// Notificador que solo escribe las notificaciones en el log
+// Sirve mientras no haya un servicio de mails, cualquier otro notificador tiene que implementar los mismos metodos
+type Log struct{}
+
+func NewLog() Log {
+	return Log{}
+}
+
+// Avisa a un usuario de la lista de espera que se libero una habitacion para sus fechas
+func (notifier Log) NotifyWaitlistOffer(ctx context.Context, entry hotelsDomain.WaitlistEntry) error {
+	log.Printf("waitlist offer for user %s: %d room(s) in hotel %s from %s to %s, held until %s",
+		entry.UserID, entry.RoomCount, entry.HotelID,
+		entry.CheckIn.Format("2006-01-02"), entry.CheckOut.Format("2006-01-02"),
+		entry.OfferExpiresAt.Format("2006-01-02 15:04:05"))
+	return nil
+}
@@ -0,0 +1,34 @@
+package notifications
+
+import (
+	"context"
+	hotelsDomain "hotels-api/domain/hotels"
+	"sync"
+)
+
+// Notificador que guarda las notificaciones en memoria, para las pruebas
+type Mock struct {
+	mutex  *sync.Mutex
+	offers *[]hotelsDomain.WaitlistEntry
+}
+
+func NewMock() Mock {
+	return Mock{
+		mutex:  &sync.Mutex{},
+		offers: &[]hotelsDomain.WaitlistEntry{},
+	}
+}
+
+func (mock Mock) NotifyWaitlistOffer(ctx context.Context, entry hotelsDomain.WaitlistEntry) error {
+	mock.mutex.Lock()
+	defer mock.mutex.Unlock()
+	*mock.offers = append(*mock.offers, entry)
+	return nil
+}
+
+// Devuelve las ofertas notificadas, en el orden en que se enviaron
+func (mock Mock) Offers() []hotelsDomain.WaitlistEntry {
+	mock.mutex.Lock()
+	defer mock.mutex.Unlock()
+	return append([]hotelsDomain.WaitlistEntry(nil), *mock.offers...)
+}
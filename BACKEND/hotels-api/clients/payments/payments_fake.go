@@ -0,0 +1,150 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	hotelsDomain "hotels-api/domain/hotels"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Tokens que el procesador falso rechaza, cualquier otro se aprueba
+const (
+	DeclinedToken          = "tok_declined"
+	InsufficientFundsToken = "tok_insufficient_funds"
+)
+
+// Procesador de pagos en memoria, para las pruebas y para correr la API localmente
+// Cualquier otro procesador tiene que implementar los mismos metodos
+// Los webhooks se firman con el HMAC-SHA256 del body usando el secreto, en hexadecimal
+type Fake struct {
+	mutex          *sync.Mutex
+	secret         string
+	authorizations map[string]*authorization
+	results        map[string]hotelsDomain.PaymentResult // Resultados por operacion y clave de idempotencia
+}
+
+// Estado de una autorizacion en el procesador
+type authorization struct {
+	amount   float64
+	captured float64
+	refunded float64
+	voided   bool
+}
+
+func NewFake(secret string) Fake {
+	return Fake{
+		mutex:          &sync.Mutex{},
+		secret:         secret,
+		authorizations: make(map[string]*authorization),
+		results:        make(map[string]hotelsDomain.PaymentResult),
+	}
+}
+
+// Reserva el monto en el medio de pago, sin cobrarlo
+func (fake Fake) Authorize(ctx context.Context, request hotelsDomain.PaymentRequest) (hotelsDomain.PaymentResult, error) {
+	if request.Amount <= 0 {
+		return hotelsDomain.PaymentResult{}, fmt.Errorf("invalid amount %.2f", request.Amount)
+	}
+	return fake.idempotent("authorize", request.IdempotencyKey, func() (hotelsDomain.PaymentResult, error) {
+		switch request.Token {
+		case DeclinedToken:
+			return declined("card declined"), nil
+		case InsufficientFundsToken:
+			return declined("insufficient funds"), nil
+		}
+		id := "auth_" + uuid.New().String()
+		fake.authorizations[id] = &authorization{amount: request.Amount}
+		return hotelsDomain.PaymentResult{TransactionID: id, Approved: true, Amount: request.Amount}, nil
+	})
+}
+
+// Cobra una autorizacion, una sola vez y hasta el monto autorizado
+func (fake Fake) Capture(ctx context.Context, authorizationID string, amount float64, idempotencyKey string) (hotelsDomain.PaymentResult, error) {
+	return fake.idempotent("capture", idempotencyKey, func() (hotelsDomain.PaymentResult, error) {
+		auth, ok := fake.authorizations[authorizationID]
+		if !ok {
+			return hotelsDomain.PaymentResult{}, fmt.Errorf("authorization %s not found", authorizationID)
+		}
+		switch {
+		case auth.voided:
+			return declined("authorization was voided"), nil
+		case auth.captured > 0:
+			return declined("authorization was already captured"), nil
+		case amount <= 0 || amount > auth.amount:
+			return declined("amount exceeds the authorized amount"), nil
+		}
+		auth.captured = amount
+		return hotelsDomain.PaymentResult{TransactionID: "cap_" + uuid.New().String(), Approved: true, Amount: amount}, nil
+	})
+}
+
+// Devuelve parte o todo lo cobrado de una autorizacion
+func (fake Fake) Refund(ctx context.Context, authorizationID string, amount float64, idempotencyKey string) (hotelsDomain.PaymentResult, error) {
+	return fake.idempotent("refund", idempotencyKey, func() (hotelsDomain.PaymentResult, error) {
+		auth, ok := fake.authorizations[authorizationID]
+		if !ok {
+			return hotelsDomain.PaymentResult{}, fmt.Errorf("authorization %s not found", authorizationID)
+		}
+		if amount <= 0 || amount > auth.captured-auth.refunded {
+			return declined("amount exceeds the captured amount"), nil
+		}
+		auth.refunded += amount
+		return hotelsDomain.PaymentResult{TransactionID: "ref_" + uuid.New().String(), Approved: true, Amount: amount}, nil
+	})
+}
+
+// Libera una autorizacion que todavia no se cobro
+func (fake Fake) Void(ctx context.Context, authorizationID string, idempotencyKey string) (hotelsDomain.PaymentResult, error) {
+	return fake.idempotent("void", idempotencyKey, func() (hotelsDomain.PaymentResult, error) {
+		auth, ok := fake.authorizations[authorizationID]
+		if !ok {
+			return hotelsDomain.PaymentResult{}, fmt.Errorf("authorization %s not found", authorizationID)
+		}
+		if auth.voided || auth.captured > 0 {
+			return declined("authorization can't be voided"), nil
+		}
+		auth.voided = true
+		return hotelsDomain.PaymentResult{TransactionID: "void_" + uuid.New().String(), Approved: true, Amount: auth.amount}, nil
+	})
+}
+
+// Indica si la firma de un webhook corresponde al body
+func (fake Fake) VerifyWebhook(payload []byte, signature string) bool {
+	return hmac.Equal([]byte(fake.Sign(payload)), []byte(signature))
+}
+
+// Firma un body como lo haria el procesador al mandar un webhook
+func (fake Fake) Sign(payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(fake.secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Ejecuta una operacion una sola vez por clave de idempotencia, las repeticiones devuelven el primer resultado
+// Sin clave la operacion se ejecuta siempre
+func (fake Fake) idempotent(operation, key string, run func() (hotelsDomain.PaymentResult, error)) (hotelsDomain.PaymentResult, error) {
+	fake.mutex.Lock()
+	defer fake.mutex.Unlock()
+	if key != "" {
+		if result, ok := fake.results[operation+":"+key]; ok {
+			return result, nil
+		}
+	}
+	result, err := run()
+	if err != nil {
+		return hotelsDomain.PaymentResult{}, err
+	}
+	if key != "" {
+		fake.results[operation+":"+key] = result
+	}
+	return result, nil
+}
+
+func declined(reason string) hotelsDomain.PaymentResult {
+	return hotelsDomain.PaymentResult{Approved: false, DeclineReason: reason}
+}
@@ -0,0 +1,64 @@
+package payments
+
+import (
+	"context"
+	hotelsDomain "hotels-api/domain/hotels"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeAuthorizeCaptureRefund(t *testing.T) {
+	ctx := context.Background()
+	fake := NewFake("secret")
+
+	auth, err := fake.Authorize(ctx, hotelsDomain.PaymentRequest{Amount: 300, Currency: "USD", Token: "tok_visa", IdempotencyKey: "k1"})
+	assert.NoError(t, err)
+	assert.True(t, auth.Approved)
+
+	// La misma clave devuelve la misma autorizacion
+	again, err := fake.Authorize(ctx, hotelsDomain.PaymentRequest{Amount: 300, Currency: "USD", Token: "tok_visa", IdempotencyKey: "k1"})
+	assert.NoError(t, err)
+	assert.Equal(t, auth.TransactionID, again.TransactionID)
+
+	capture, err := fake.Capture(ctx, auth.TransactionID, 400, "")
+	assert.NoError(t, err)
+	assert.False(t, capture.Approved)
+
+	capture, err = fake.Capture(ctx, auth.TransactionID, 250, "")
+	assert.NoError(t, err)
+	assert.True(t, capture.Approved)
+
+	refund, err := fake.Refund(ctx, auth.TransactionID, 100, "")
+	assert.NoError(t, err)
+	assert.True(t, refund.Approved)
+	refund, err = fake.Refund(ctx, auth.TransactionID, 200, "")
+	assert.NoError(t, err)
+	assert.False(t, refund.Approved)
+
+	// Lo cobrado ya no se puede anular
+	void, err := fake.Void(ctx, auth.TransactionID, "")
+	assert.NoError(t, err)
+	assert.False(t, void.Approved)
+}
+
+func TestFakeDeclines(t *testing.T) {
+	fake := NewFake("secret")
+
+	result, err := fake.Authorize(context.Background(), hotelsDomain.PaymentRequest{Amount: 100, Token: InsufficientFundsToken})
+	assert.NoError(t, err)
+	assert.False(t, result.Approved)
+	assert.Equal(t, "insufficient funds", result.DeclineReason)
+
+	_, err = fake.Capture(context.Background(), "auth_unknown", 100, "")
+	assert.Error(t, err)
+}
+
+func TestFakeVerifyWebhook(t *testing.T) {
+	fake := NewFake("secret")
+	payload := []byte(`{"id":"cap_1","type":"payment.captured"}`)
+
+	assert.True(t, fake.VerifyWebhook(payload, fake.Sign(payload)))
+	assert.False(t, fake.VerifyWebhook(payload, NewFake("other").Sign(payload)))
+	assert.False(t, fake.VerifyWebhook([]byte(`{}`), fake.Sign(payload)))
+}
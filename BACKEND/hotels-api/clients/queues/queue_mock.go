@@ -11,3 +11,11 @@ func NewMock() Mock {
 func (Mock) Publish(hotelNew hotels.HotelNew) error {
 	return nil
 }
+
+func (Mock) PublishReservation(reservationNew hotels.ReservationNew) error {
+	return nil
+}
+
+func (Mock) StartConsumer(handler func(hotels.HotelNew)) error {
+	return nil
+}
@@ -15,12 +15,16 @@ type RabbitConfig struct {
 	Username  string
 	Password  string
 	QueueName string
+	// Exchange fanout opcional. Si esta, los mensajes se publican en el exchange y la cola queda enlazada a el,
+	// asi cada replica de hotels-api puede enlazar su propia cola y recibir tambien los eventos
+	Exchange string
 }
 
 type Rabbit struct {
 	connection *amqp.Connection
 	channel    *amqp.Channel
 	queue      amqp.Queue
+	exchange   string
 }
 
 // Funcion que crea una nueva instancia de Rabbit
@@ -28,32 +32,58 @@ func NewRabbit(config RabbitConfig) Rabbit {
 	//Crea la conexion a RabbitMQ
 	connection, err := amqp.Dial(fmt.Sprintf("amqp://%s:%s@%s:%s/", config.Username, config.Password, config.Host, config.Port))
 	if err != nil {
-		log.Fatalf("error getting Rabbit connection: %w", err)
+		log.Fatalf("error getting Rabbit connection: %v", err)
 	}
 	channel, err := connection.Channel()
 	if err != nil {
-		log.Fatalf("error creating Rabbit channel: %w", err)
+		log.Fatalf("error creating Rabbit channel: %v", err)
 	}
 	//Crea la cola en RabbitMQ
 	queue, err := channel.QueueDeclare(config.QueueName, false, false, false, false, nil)
+	if err != nil {
+		log.Fatalf("error declaring Rabbit queue: %v", err)
+	}
+	if config.Exchange != "" {
+		//Crea el exchange y le enlaza la cola, para que siga recibiendo todos los mensajes
+		if err := channel.ExchangeDeclare(config.Exchange, amqp.ExchangeFanout, false, false, false, false, nil); err != nil {
+			log.Fatalf("error declaring Rabbit exchange: %v", err)
+		}
+		if err := channel.QueueBind(queue.Name, "", config.Exchange, false, nil); err != nil {
+			log.Fatalf("error binding Rabbit queue: %v", err)
+		}
+	}
 	return Rabbit{
 		connection: connection,
 		channel:    channel,
 		queue:      queue,
+		exchange:   config.Exchange,
 	}
 }
 
 // Funcion que publica un mensaje de hotel en RabbitMQ
 func (queue Rabbit) Publish(hotelNew hotels.HotelNew) error {
+	return queue.publish(hotelNew)
+}
+
+// Funcion que publica un mensaje de reserva en RabbitMQ
+func (queue Rabbit) PublishReservation(reservationNew hotels.ReservationNew) error {
+	return queue.publish(reservationNew)
+}
+
+func (queue Rabbit) publish(message interface{}) error {
 	//Codifica el mensaje a JSON
-	bytes, err := json.Marshal(hotelNew)
+	bytes, err := json.Marshal(message)
 	if err != nil {
-		return fmt.Errorf("error marshaling Rabbit hotelNew: %w", err)
+		return fmt.Errorf("error marshaling Rabbit message: %w", err)
+	}
+	//Publica el mensaje en RabbitMQ, en el exchange si hay uno o directo en la cola si no
+	exchange, key := queue.exchange, ""
+	if exchange == "" {
+		key = queue.queue.Name
 	}
-	//Publica el mensaje en RabbitMQ
 	if err := queue.channel.Publish(
-		"",
-		queue.queue.Name,
+		exchange,
+		key,
 		false,
 		false,
 		amqp.Publishing{
@@ -65,6 +95,39 @@ func (queue Rabbit) Publish(hotelNew hotels.HotelNew) error {
 	return nil
 }
 
+// Funcion que consume los mensajes de hotel del exchange con una cola propia de esta instancia
+// La cola la nombra RabbitMQ, es exclusiva y se borra al cerrar la conexion, asi cada replica recibe todos los eventos
+// sin quitarselos a search-api ni a las demas replicas
+func (queue Rabbit) StartConsumer(handler func(hotels.HotelNew)) error {
+	if queue.exchange == "" {
+		return fmt.Errorf("consuming hotel news requires an exchange")
+	}
+	own, err := queue.channel.QueueDeclare("", false, true, true, false, nil)
+	if err != nil {
+		return fmt.Errorf("error declaring consumer queue: %w", err)
+	}
+	if err := queue.channel.QueueBind(own.Name, "", queue.exchange, false, nil); err != nil {
+		return fmt.Errorf("error binding consumer queue: %w", err)
+	}
+	messages, err := queue.channel.Consume(own.Name, "", true, true, false, false, nil)
+	if err != nil {
+		return fmt.Errorf("error registering consumer: %w", err)
+	}
+
+	go func() {
+		for msg := range messages {
+			var hotelNew hotels.HotelNew
+			if err := json.Unmarshal(msg.Body, &hotelNew); err != nil {
+				log.Printf("error unmarshaling message: %v", err)
+				continue
+			}
+			handler(hotelNew)
+		}
+	}()
+
+	return nil
+}
+
 // Funcion que cierra la conexion a RabbitMQ
 func (queue Rabbit) Close() {
 	if err := queue.channel.Close(); err != nil {
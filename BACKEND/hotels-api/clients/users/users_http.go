@@ -0,0 +1,62 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type HTTPConfig struct {
+	Host    string
+	Port    string
+	Timeout time.Duration // Tiempo maximo de cada consulta a users-api
+}
+
+// Cliente de users-api, se usa para verificar que los usuarios de las reservas existen
+type HTTP struct {
+	client  *http.Client
+	baseURL func(userID string) string
+}
+
+const (
+	defaultTimeout = 5 * time.Second
+)
+
+func NewHTTP(config HTTPConfig) HTTP {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return HTTP{
+		client: &http.Client{Timeout: timeout},
+		baseURL: func(userID string) string {
+			return fmt.Sprintf("http://%s:%s/users/%s", config.Host, config.Port, url.PathEscape(userID))
+		},
+	}
+}
+
+// Funcion que consulta si un usuario existe en users-api
+// Devuelve false solo si users-api responde que no lo encuentra (404), y error ante cualquier otra respuesta
+func (client HTTP) UserExists(ctx context.Context, userID string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, client.baseURL(userID), nil)
+	if err != nil {
+		return false, fmt.Errorf("error creating request for user (%s): %w", userID, err)
+	}
+
+	resp, err := client.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("error fetching user (%s): %w", userID, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to fetch user (%s): received status code %d", userID, resp.StatusCode)
+	}
+}
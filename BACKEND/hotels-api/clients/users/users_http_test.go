@@ -0,0 +1,53 @@
+package users
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserExists(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.EscapedPath() {
+		case "/users/1":
+			w.WriteHeader(http.StatusOK)
+		case "/users/abc":
+			w.WriteHeader(http.StatusBadRequest)
+		case "/users/..%2Fadmin":
+			w.WriteHeader(http.StatusOK)
+		case "/users/500":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	address, err := url.Parse(server.URL)
+	assert.NoError(t, err)
+	client := NewHTTP(HTTPConfig{Host: address.Hostname(), Port: address.Port()})
+
+	exists, err := client.UserExists(context.Background(), "1")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = client.UserExists(context.Background(), "2")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	// Un ID invalido no significa que el usuario no exista
+	_, err = client.UserExists(context.Background(), "abc")
+	assert.Error(t, err)
+
+	// El ID se escapa y no puede cambiar la ruta de la consulta
+	exists, err = client.UserExists(context.Background(), "../admin")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	_, err = client.UserExists(context.Background(), "500")
+	assert.Error(t, err)
+}
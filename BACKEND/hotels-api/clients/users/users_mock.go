@@ -0,0 +1,19 @@
+package users
+
+import "context"
+
+type Mock struct {
+	users map[string]bool
+}
+
+func NewMock(userIDs ...string) Mock {
+	users := make(map[string]bool)
+	for _, id := range userIDs {
+		users[id] = true
+	}
+	return Mock{users: users}
+}
+
+func (mock Mock) UserExists(ctx context.Context, userID string) (bool, error) {
+	return mock.users[userID], nil
+}
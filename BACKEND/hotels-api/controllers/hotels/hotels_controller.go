@@ -2,10 +2,15 @@ package hotels
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	hotelsDAO "hotels-api/dao/hotels"
 	hotelsDomain "hotels-api/domain/hotels"
+	"hotels-api/utils"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -13,15 +18,30 @@ import (
 // Estas son las funciones que se encargan de interactuar con el servicio, se encargan de recibir las peticiones y enviar las respuestas (Vienen del service)
 type Service interface {
 	GetHotelByID(ctx context.Context, id string) (hotelsDomain.Hotel, error)
+	CacheMetrics(ctx context.Context) (hotelsDomain.CacheMetrics, error)
 	Create(ctx context.Context, hotel hotelsDomain.Hotel) (string, error)
 	Update(ctx context.Context, hotel hotelsDomain.Hotel) error
 	Delete(ctx context.Context, id string) error
 	CreateReservation(ctx context.Context, reservation hotelsDomain.Reservation) (string, error)
-	CancelReservation(ctx context.Context, id string) error
-	GetReservationsByHotelID(ctx context.Context, hotelID string) ([]hotelsDomain.Reservation, error)
-	GetReservationsByUserID(ctx context.Context, userID string) ([]hotelsDomain.Reservation, error)
-	GetReservationsByUserAndHotelID(ctx context.Context, userID, hotelID string) ([]hotelsDomain.Reservation, error)
+	GetReservationByID(ctx context.Context, id string, userID string) (hotelsDomain.Reservation, error)
+	CancelReservation(ctx context.Context, id string, userID string) (hotelsDomain.Cancellation, error)
+	CancelReservationRoom(ctx context.Context, id string, roomID string, userID string) (hotelsDomain.Cancellation, error)
+	PreviewCancellation(ctx context.Context, id string, userID string) (hotelsDomain.Cancellation, error)
+	ModifyReservation(ctx context.Context, id string, userID string, change hotelsDomain.ReservationChange) (hotelsDomain.Reservation, error)
+	GetReservationsByHotelID(ctx context.Context, hotelID string, requesterID string) ([]hotelsDomain.Reservation, error)
+	GetReservationsByUserID(ctx context.Context, userID string, requesterID string) ([]hotelsDomain.Reservation, error)
+	GetReservationsByUserAndHotelID(ctx context.Context, hotelID, userID string, requesterID string) ([]hotelsDomain.Reservation, error)
 	GetAvailability(ctx context.Context, hotelIDs []string, checkIn, checkOut string) (map[string]bool, error)
+	Quote(ctx context.Context, hotelID string, checkIn, checkOut time.Time, guests int) (hotelsDomain.Quote, error)
+	Calendar(ctx context.Context, hotelID string, from, to time.Time) (hotelsDomain.Calendar, error)
+	JoinWaitlist(ctx context.Context, entry hotelsDomain.WaitlistEntry) (string, error)
+	CreateHold(ctx context.Context, hold hotelsDomain.Hold) (hotelsDomain.Hold, error)
+	ConfirmHold(ctx context.Context, id string, userID string, details hotelsDomain.Reservation) (string, error)
+	ReleaseHold(ctx context.Context, id string, userID string) error
+	GetPayment(ctx context.Context, reservationID string, userID string) (hotelsDomain.Payment, error)
+	CapturePayment(ctx context.Context, reservationID string, userID string, amount float64, idempotencyKey string) (hotelsDomain.Payment, error)
+	RefundPayment(ctx context.Context, reservationID string, userID string, amount float64, idempotencyKey string) (hotelsDomain.Payment, error)
+	HandlePaymentWebhook(ctx context.Context, payload []byte, signature string) error
 }
 
 type Controller struct {
@@ -52,6 +72,19 @@ func (controller Controller) GetHotelByID(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, hotel)
 }
 
+// Funcion para ver los contadores de la cache (GET)
+func (controller Controller) CacheMetrics(ctx *gin.Context) {
+	metrics, err := controller.service.CacheMetrics(ctx.Request.Context())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("error getting cache metrics: %s", err.Error()),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, metrics)
+}
+
 // Funcion para crear un hotel (POST)
 func (controller Controller) Create(ctx *gin.Context) {
 	// Le da formato al hotel que viene en el body de la peticiona un DAO
@@ -65,6 +98,14 @@ func (controller Controller) Create(ctx *gin.Context) {
 
 	// Crea el hotel
 	id, err := controller.service.Create(ctx.Request.Context(), hotel)
+	var validationErr hotelsDomain.ValidationError
+	if errors.As(err, &validationErr) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", validationErr.Error()),
+			"field": validationErr.Field,
+		})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("error creating hotel: %s", err.Error()),
@@ -96,7 +137,16 @@ func (controller Controller) Update(ctx *gin.Context) {
 	hotel.ID = id
 
 	// Actualiza el hotel
-	if err := controller.service.Update(ctx.Request.Context(), hotel); err != nil {
+	err := controller.service.Update(ctx.Request.Context(), hotel)
+	var validationErr hotelsDomain.ValidationError
+	if errors.As(err, &validationErr) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", validationErr.Error()),
+			"field": validationErr.Field,
+		})
+		return
+	}
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("error updating hotel: %s", err.Error()),
 		})
@@ -139,8 +189,33 @@ func (controller Controller) CreateReservation(ctx *gin.Context) {
 		return
 	}
 
+	// El usuario de la reserva es el del token, lo deja el middleware de autenticacion
+	reservation.UserID = ctx.GetString(utils.UserIDKey)
+
+	// Con la misma clave de idempotencia un reintento devuelve la reserva ya creada
+	reservation.IdempotencyKey = strings.TrimSpace(ctx.GetHeader("Idempotency-Key"))
+
 	// Crea la reserva
 	id, err := controller.service.CreateReservation(ctx.Request.Context(), reservation)
+	var validationErr hotelsDomain.ValidationError
+	if errors.As(err, &validationErr) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", validationErr.Error()),
+			"field": validationErr.Field,
+		})
+		return
+	}
+	var unprocessableErr hotelsDomain.UnprocessableError
+	if errors.As(err, &unprocessableErr) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": fmt.Sprintf("error creating reservation: %s", unprocessableErr.Error()),
+			"field": unprocessableErr.Field,
+		})
+		return
+	}
+	if respondPaymentError(ctx, "error creating reservation", err) {
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("error creating reservation: %s", err.Error()),
@@ -154,30 +229,176 @@ func (controller Controller) CreateReservation(ctx *gin.Context) {
 	})
 }
 
+// Funcion para obtener una reserva por su ID (GET), solo para su dueño o los administradores del hotel
+func (controller Controller) GetReservationByID(ctx *gin.Context) {
+	// Valida el ID de la reserva que viene en la URL
+	id := strings.TrimSpace(ctx.Param("id"))
+
+	// Obtiene la reserva, el usuario lo deja el middleware de autenticacion
+	reservation, err := controller.service.GetReservationByID(ctx.Request.Context(), id, ctx.GetString(utils.UserIDKey))
+	if errors.Is(err, hotelsDAO.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": fmt.Sprintf("error getting reservation: %s", err.Error()),
+		})
+		return
+	}
+	if respondForbiddenError(ctx, "error getting reservation", err) {
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("error getting reservation: %s", err.Error()),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, reservation)
+}
+
 func (controller Controller) CancelReservation(ctx *gin.Context) {
 	// Valida el ID de la reserva que viene en la URL
 	id := strings.TrimSpace(ctx.Param("id"))
 
 	// Cancela la reserva
-	if err := controller.service.CancelReservation(ctx.Request.Context(), id); err != nil {
+	cancellation, err := controller.service.CancelReservation(ctx.Request.Context(), id, ctx.GetString(utils.UserIDKey))
+	if respondCancellationError(ctx, "error canceling reservation", err) {
+		return
+	}
+
+	// Devuelve el ID de la reserva cancelada con la penalidad y el reembolso
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":      id,
+		"cancellation": cancellation,
+	})
+}
+
+// Funcion para cambiar las fechas o el tipo de habitacion de una reserva (PATCH)
+func (controller Controller) ModifyReservation(ctx *gin.Context) {
+	// Valida el ID de la reserva que viene en la URL
+	id := strings.TrimSpace(ctx.Param("id"))
+
+	// Le da formato al cambio que viene en el body de la peticion
+	var change hotelsDomain.ReservationChange
+	if err := ctx.ShouldBindJSON(&change); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", err.Error()),
+		})
+		return
+	}
+
+	// Modifica la reserva
+	reservation, err := controller.service.ModifyReservation(ctx.Request.Context(), id, ctx.GetString(utils.UserIDKey), change)
+	if errors.Is(err, hotelsDAO.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": fmt.Sprintf("error modifying reservation: %s", err.Error()),
+		})
+		return
+	}
+	if respondForbiddenError(ctx, "error modifying reservation", err) {
+		return
+	}
+	var validationErr hotelsDomain.ValidationError
+	if errors.As(err, &validationErr) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", validationErr.Error()),
+			"field": validationErr.Field,
+		})
+		return
+	}
+	var unprocessableErr hotelsDomain.UnprocessableError
+	if errors.As(err, &unprocessableErr) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": fmt.Sprintf("error modifying reservation: %s", unprocessableErr.Error()),
+			"field": unprocessableErr.Field,
+		})
+		return
+	}
+	if respondPaymentError(ctx, "error modifying reservation", err) {
+		return
+	}
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error": fmt.Sprintf("error canceling reservation: %s", err.Error()),
+			"error": fmt.Sprintf("error modifying reservation: %s", err.Error()),
 		})
 		return
 	}
 
-	// Devuelve el ID de la reserva cancelada
+	// Devuelve la reserva con las fechas, las habitaciones y el precio nuevos
+	ctx.JSON(http.StatusOK, reservation)
+}
+
+// Funcion para cancelar una sola habitacion de una reserva (DELETE)
+// Si era la ultima habitacion activa se cancela la reserva entera
+func (controller Controller) CancelReservationRoom(ctx *gin.Context) {
+	// Valida los IDs de la reserva y de la habitacion que vienen en la URL
+	id := strings.TrimSpace(ctx.Param("id"))
+	roomID := strings.TrimSpace(ctx.Param("room_id"))
+
+	// Cancela la habitacion
+	cancellation, err := controller.service.CancelReservationRoom(ctx.Request.Context(), id, roomID, ctx.GetString(utils.UserIDKey))
+	if respondCancellationError(ctx, "error canceling reservation room", err) {
+		return
+	}
+
+	// Devuelve el ID de la habitacion cancelada con la penalidad y el reembolso
 	ctx.JSON(http.StatusOK, gin.H{
-		"message": id,
+		"message":      roomID,
+		"cancellation": cancellation,
 	})
 }
 
+// Funcion para ver la penalidad y el reembolso de cancelar una reserva ahora, sin cancelarla (GET)
+func (controller Controller) PreviewCancellation(ctx *gin.Context) {
+	// Valida el ID de la reserva que viene en la URL
+	id := strings.TrimSpace(ctx.Param("id"))
+
+	cancellation, err := controller.service.PreviewCancellation(ctx.Request.Context(), id, ctx.GetString(utils.UserIDKey))
+	if respondCancellationError(ctx, "error previewing cancellation", err) {
+		return
+	}
+
+	ctx.JSON(http.StatusOK, cancellation)
+}
+
+// Funcion que responde los errores de las cancelaciones: 404 si la reserva o la habitacion no existen, 403 si no es
+// del usuario, 422 si ya estaba cancelada y los errores de pago al ajustar el pago. Devuelve true si respondio
+func respondCancellationError(ctx *gin.Context, message string, err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, hotelsDAO.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": fmt.Sprintf("%s: %s", message, err.Error()),
+		})
+		return true
+	}
+	if respondForbiddenError(ctx, message, err) {
+		return true
+	}
+	var unprocessableErr hotelsDomain.UnprocessableError
+	if errors.As(err, &unprocessableErr) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": fmt.Sprintf("%s: %s", message, unprocessableErr.Error()),
+			"field": unprocessableErr.Field,
+		})
+		return true
+	}
+	if respondPaymentError(ctx, message, err) {
+		return true
+	}
+	ctx.JSON(http.StatusInternalServerError, gin.H{
+		"error": fmt.Sprintf("%s: %s", message, err.Error()),
+	})
+	return true
+}
+
+// Funcion para listar las reservas de un hotel (GET), los que no lo administran solo ven las suyas
 func (controller Controller) GetReservationsByHotelID(ctx *gin.Context) {
 	// Valida el ID del hotel que viene en la URL
 	hotelID := strings.TrimSpace(ctx.Param("hotel_id"))
 
-	// Obtiene las reservas por ID de hotel
-	reservations, err := controller.service.GetReservationsByHotelID(ctx.Request.Context(), hotelID)
+	// Obtiene las reservas por ID de hotel, el usuario lo deja el middleware de autenticacion
+	reservations, err := controller.service.GetReservationsByHotelID(ctx.Request.Context(), hotelID, ctx.GetString(utils.UserIDKey))
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{
 			"error": fmt.Sprintf("error getting reservations: %s", err.Error()),
@@ -189,12 +410,16 @@ func (controller Controller) GetReservationsByHotelID(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, reservations)
 }
 
+// Funcion para listar las reservas de un usuario (GET), solo para ese mismo usuario
 func (controller Controller) GetReservationsByUserID(ctx *gin.Context) {
 	// Valida el ID del usuario que viene en la URL
 	userID := strings.TrimSpace(ctx.Param("user_id"))
 
-	// Obtiene las reservas por ID de usuario
-	reservations, err := controller.service.GetReservationsByUserID(ctx.Request.Context(), userID)
+	// Obtiene las reservas por ID de usuario, el usuario que las pide lo deja el middleware de autenticacion
+	reservations, err := controller.service.GetReservationsByUserID(ctx.Request.Context(), userID, ctx.GetString(utils.UserIDKey))
+	if respondForbiddenError(ctx, "error getting reservations", err) {
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{
 			"error": fmt.Sprintf("error getting reservations: %s", err.Error()),
@@ -206,13 +431,18 @@ func (controller Controller) GetReservationsByUserID(ctx *gin.Context) {
 	ctx.JSON(http.StatusOK, reservations)
 }
 
+// Funcion para listar las reservas de un usuario en un hotel (GET), solo para ese usuario o los administradores
+// del hotel
 func (controller Controller) GetReservationsByUserAndHotelID(ctx *gin.Context) {
 	// Valida el ID del usuario que viene en la URL
 	userID := strings.TrimSpace(ctx.Param("user_id"))
 	hotelID := strings.TrimSpace(ctx.Param("hotel_id"))
 
-	// Obtiene las reservas por ID de usuario y hotel
-	reservations, err := controller.service.GetReservationsByUserAndHotelID(ctx.Request.Context(), hotelID, userID)
+	// Obtiene las reservas por ID de usuario y hotel, el usuario que las pide lo deja el middleware de autenticacion
+	reservations, err := controller.service.GetReservationsByUserAndHotelID(ctx.Request.Context(), hotelID, userID, ctx.GetString(utils.UserIDKey))
+	if respondForbiddenError(ctx, "error getting reservations", err) {
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{
 			"error": fmt.Sprintf("error getting reservations: %s", err.Error()),
@@ -250,3 +480,477 @@ func (controller Controller) GetAvailability(ctx *gin.Context) {
 	// Devuelve la disponibilidad de los hoteles
 	ctx.JSON(http.StatusOK, availability)
 }
+
+// Funcion para cotizar una estadia en un hotel (GET)
+// Recibe check_in y check_out en formato 2006-01-02 y opcionalmente guests (1 por defecto)
+func (controller Controller) Quote(ctx *gin.Context) {
+	// Valida el ID del hotel que viene en la URL
+	hotelID := strings.TrimSpace(ctx.Param("hotel_id"))
+
+	// Valida las fechas y la cantidad de huespedes
+	checkIn, err := time.Parse("2006-01-02", ctx.Query("check_in"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid check_in: %s", err.Error()),
+		})
+		return
+	}
+	checkOut, err := time.Parse("2006-01-02", ctx.Query("check_out"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid check_out: %s", err.Error()),
+		})
+		return
+	}
+	if !checkOut.After(checkIn) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: check_out must be after check_in",
+		})
+		return
+	}
+	guests := 1
+	if value := ctx.Query("guests"); value != "" {
+		guests, err = strconv.Atoi(value)
+		if err != nil || guests <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "invalid request: guests must be a positive number",
+			})
+			return
+		}
+	}
+
+	// Cotiza la estadia
+	quote, err := controller.service.Quote(ctx.Request.Context(), hotelID, checkIn, checkOut, guests)
+	var validationErr hotelsDomain.ValidationError
+	if errors.As(err, &validationErr) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", validationErr.Error()),
+			"field": validationErr.Field,
+		})
+		return
+	}
+	if errors.Is(err, hotelsDAO.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": fmt.Sprintf("error quoting stay: %s", err.Error()),
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("error quoting stay: %s", err.Error()),
+		})
+		return
+	}
+
+	// Devuelve la cotizacion con el detalle por noche
+	ctx.JSON(http.StatusOK, quote)
+}
+
+// Funcion para obtener el calendario de disponibilidad de un hotel (GET)
+// Recibe from y to en formato 2006-01-02 y devuelve una entrada por cada noche de [from, to)
+func (controller Controller) Calendar(ctx *gin.Context) {
+	// Valida el ID del hotel que viene en la URL
+	hotelID := strings.TrimSpace(ctx.Param("hotel_id"))
+
+	// Valida las fechas
+	from, err := time.Parse("2006-01-02", ctx.Query("from"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid from: %s", err.Error()),
+		})
+		return
+	}
+	to, err := time.Parse("2006-01-02", ctx.Query("to"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid to: %s", err.Error()),
+		})
+		return
+	}
+
+	// Obtiene el calendario
+	calendar, err := controller.service.Calendar(ctx.Request.Context(), hotelID, from, to)
+	var validationErr hotelsDomain.ValidationError
+	if errors.As(err, &validationErr) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", validationErr.Error()),
+			"field": validationErr.Field,
+		})
+		return
+	}
+	if errors.Is(err, hotelsDAO.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": fmt.Sprintf("error getting calendar: %s", err.Error()),
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("error getting calendar: %s", err.Error()),
+		})
+		return
+	}
+
+	// Devuelve el calendario con el detalle por noche
+	ctx.JSON(http.StatusOK, calendar)
+}
+
+// Funcion para anotarse en la lista de espera de un hotel sin lugar (POST)
+// Cuando se libere lugar para esas fechas se le avisa al usuario y se le guarda la habitacion un tiempo
+func (controller Controller) JoinWaitlist(ctx *gin.Context) {
+	// Le da formato a la entrada que viene en el body de la peticion
+	var entry hotelsDomain.WaitlistEntry
+	if err := ctx.ShouldBindJSON(&entry); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", err.Error()),
+		})
+		return
+	}
+	entry.HotelID = strings.TrimSpace(ctx.Param("hotel_id"))
+	entry.UserID = ctx.GetString(utils.UserIDKey)
+
+	// Anota al usuario en la lista de espera
+	id, err := controller.service.JoinWaitlist(ctx.Request.Context(), entry)
+	var validationErr hotelsDomain.ValidationError
+	if errors.As(err, &validationErr) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", validationErr.Error()),
+			"field": validationErr.Field,
+		})
+		return
+	}
+	var unprocessableErr hotelsDomain.UnprocessableError
+	if errors.As(err, &unprocessableErr) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": fmt.Sprintf("error joining waitlist: %s", unprocessableErr.Error()),
+			"field": unprocessableErr.Field,
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("error joining waitlist: %s", err.Error()),
+		})
+		return
+	}
+
+	// Devuelve el ID de la entrada creada
+	ctx.JSON(http.StatusCreated, gin.H{
+		"id": id,
+	})
+}
+
+// Funcion para retener habitaciones mientras el usuario paga (POST)
+// La retencion vence sola si no se confirma a tiempo
+func (controller Controller) CreateHold(ctx *gin.Context) {
+	// Le da formato a la retencion que viene en el body de la peticion
+	var hold hotelsDomain.Hold
+	if err := ctx.ShouldBindJSON(&hold); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", err.Error()),
+		})
+		return
+	}
+	hold.UserID = ctx.GetString(utils.UserIDKey)
+
+	// Crea la retencion
+	hold, err := controller.service.CreateHold(ctx.Request.Context(), hold)
+	var validationErr hotelsDomain.ValidationError
+	if errors.As(err, &validationErr) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", validationErr.Error()),
+			"field": validationErr.Field,
+		})
+		return
+	}
+	var unprocessableErr hotelsDomain.UnprocessableError
+	if errors.As(err, &unprocessableErr) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": fmt.Sprintf("error creating hold: %s", unprocessableErr.Error()),
+			"field": unprocessableErr.Field,
+		})
+		return
+	}
+	if respondPaymentError(ctx, "error creating hold", err) {
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("error creating hold: %s", err.Error()),
+		})
+		return
+	}
+
+	// Devuelve la retencion con su ID y su vencimiento
+	ctx.JSON(http.StatusCreated, hold)
+}
+
+// Funcion para convertir una retencion en una reserva (POST)
+// El body puede traer los datos de contacto de la reserva
+func (controller Controller) ConfirmHold(ctx *gin.Context) {
+	// Valida el ID de la retencion que viene en la URL
+	id := strings.TrimSpace(ctx.Param("id"))
+
+	// Los datos de contacto son opcionales
+	var details hotelsDomain.Reservation
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&details); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("invalid request: %s", err.Error()),
+			})
+			return
+		}
+	}
+
+	details.IdempotencyKey = strings.TrimSpace(ctx.GetHeader("Idempotency-Key"))
+
+	// Confirma la retencion
+	reservationID, err := controller.service.ConfirmHold(ctx.Request.Context(), id, ctx.GetString(utils.UserIDKey), details)
+	if errors.Is(err, hotelsDAO.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": fmt.Sprintf("error confirming hold: %s", err.Error()),
+		})
+		return
+	}
+	var validationErr hotelsDomain.ValidationError
+	if errors.As(err, &validationErr) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", validationErr.Error()),
+			"field": validationErr.Field,
+		})
+		return
+	}
+	var unprocessableErr hotelsDomain.UnprocessableError
+	if errors.As(err, &unprocessableErr) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": fmt.Sprintf("error confirming hold: %s", unprocessableErr.Error()),
+			"field": unprocessableErr.Field,
+		})
+		return
+	}
+	if respondPaymentError(ctx, "error confirming hold", err) {
+		return
+	}
+	if respondForbiddenError(ctx, "error confirming hold", err) {
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("error confirming hold: %s", err.Error()),
+		})
+		return
+	}
+
+	// Devuelve el ID de la reserva creada
+	ctx.JSON(http.StatusCreated, gin.H{
+		"id": reservationID,
+	})
+}
+
+// Funcion para liberar una retencion antes de que venza (DELETE)
+func (controller Controller) ReleaseHold(ctx *gin.Context) {
+	// Valida el ID de la retencion que viene en la URL
+	id := strings.TrimSpace(ctx.Param("id"))
+
+	// Libera la retencion
+	err := controller.service.ReleaseHold(ctx.Request.Context(), id, ctx.GetString(utils.UserIDKey))
+	if errors.Is(err, hotelsDAO.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": fmt.Sprintf("error releasing hold: %s", err.Error()),
+		})
+		return
+	}
+	if respondForbiddenError(ctx, "error releasing hold", err) {
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("error releasing hold: %s", err.Error()),
+		})
+		return
+	}
+
+	// Devuelve el ID de la retencion liberada
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": id,
+	})
+}
+
+// Funcion para obtener el pago de una reserva (GET)
+func (controller Controller) GetPayment(ctx *gin.Context) {
+	// Valida el ID de la reserva que viene en la URL
+	id := strings.TrimSpace(ctx.Param("id"))
+
+	payment, err := controller.service.GetPayment(ctx.Request.Context(), id, ctx.GetString(utils.UserIDKey))
+	if errors.Is(err, hotelsDAO.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": fmt.Sprintf("error getting payment: %s", err.Error()),
+		})
+		return
+	}
+	if respondForbiddenError(ctx, "error getting payment", err) {
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("error getting payment: %s", err.Error()),
+		})
+		return
+	}
+
+	// Devuelve el pago con sus eventos
+	ctx.JSON(http.StatusOK, payment)
+}
+
+// Funcion para cobrar el pago autorizado de una reserva (POST)
+// El body puede traer el monto a cobrar, si no se cobra el precio de la reserva
+func (controller Controller) CapturePayment(ctx *gin.Context) {
+	controller.changePayment(ctx, "error capturing payment", controller.service.CapturePayment)
+}
+
+// Funcion para devolver parte o todo lo cobrado de una reserva (POST)
+// El body puede traer el monto a devolver, si no se devuelve todo lo cobrado
+func (controller Controller) RefundPayment(ctx *gin.Context) {
+	controller.changePayment(ctx, "error refunding payment", controller.service.RefundPayment)
+}
+
+// Funcion comun de los cobros y las devoluciones, que reciben el mismo body y devuelven los mismos errores
+func (controller Controller) changePayment(ctx *gin.Context, message string, change func(ctx context.Context, reservationID string, userID string, amount float64, idempotencyKey string) (hotelsDomain.Payment, error)) {
+	// Valida el ID de la reserva que viene en la URL
+	id := strings.TrimSpace(ctx.Param("id"))
+
+	// El monto es opcional
+	var request struct {
+		Amount float64 `json:"amount"`
+	}
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": fmt.Sprintf("invalid request: %s", err.Error()),
+			})
+			return
+		}
+	}
+
+	payment, err := change(ctx.Request.Context(), id, ctx.GetString(utils.UserIDKey), request.Amount, strings.TrimSpace(ctx.GetHeader("Idempotency-Key")))
+	if errors.Is(err, hotelsDAO.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": fmt.Sprintf("%s: %s", message, err.Error()),
+		})
+		return
+	}
+	if respondForbiddenError(ctx, message, err) {
+		return
+	}
+	var validationErr hotelsDomain.ValidationError
+	if errors.As(err, &validationErr) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", validationErr.Error()),
+			"field": validationErr.Field,
+		})
+		return
+	}
+	var unprocessableErr hotelsDomain.UnprocessableError
+	if errors.As(err, &unprocessableErr) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": fmt.Sprintf("%s: %s", message, unprocessableErr.Error()),
+			"field": unprocessableErr.Field,
+		})
+		return
+	}
+	if respondPaymentError(ctx, message, err) {
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("%s: %s", message, err.Error()),
+		})
+		return
+	}
+
+	// Devuelve el pago actualizado
+	ctx.JSON(http.StatusOK, payment)
+}
+
+// Funcion que recibe los avisos del procesador de pagos (POST)
+// El body se firma con el secreto compartido y la firma viene en el header X-Payment-Signature
+func (controller Controller) PaymentWebhook(ctx *gin.Context) {
+	// La firma es del body tal cual llega, asi que se lee sin decodificar
+	payload, err := ctx.GetRawData()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", err.Error()),
+		})
+		return
+	}
+
+	err = controller.service.HandlePaymentWebhook(ctx.Request.Context(), payload, ctx.GetHeader("X-Payment-Signature"))
+	var validationErr hotelsDomain.ValidationError
+	if errors.As(err, &validationErr) {
+		status := http.StatusBadRequest
+		if validationErr.Field == "signature" {
+			status = http.StatusUnauthorized
+		}
+		ctx.JSON(status, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", validationErr.Error()),
+			"field": validationErr.Field,
+		})
+		return
+	}
+	if errors.Is(err, hotelsDAO.ErrNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"error": fmt.Sprintf("error processing payment webhook: %s", err.Error()),
+		})
+		return
+	}
+	var unprocessableErr hotelsDomain.UnprocessableError
+	if errors.As(err, &unprocessableErr) {
+		ctx.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error": fmt.Sprintf("error processing payment webhook: %s", unprocessableErr.Error()),
+			"field": unprocessableErr.Field,
+		})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("error processing payment webhook: %s", err.Error()),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "processed",
+	})
+}
+
+// Funcion que responde los errores de pago: 402 si el procesador rechazo el pago y 409 si la misma clave de
+// idempotencia se esta procesando. Devuelve true si respondio
+func respondPaymentError(ctx *gin.Context, message string, err error) bool {
+	var paymentErr hotelsDomain.PaymentError
+	if errors.As(err, &paymentErr) {
+		ctx.JSON(http.StatusPaymentRequired, gin.H{
+			"error": fmt.Sprintf("%s: %s", message, paymentErr.Error()),
+		})
+		return true
+	}
+	var conflictErr hotelsDomain.ConflictError
+	if errors.As(err, &conflictErr) {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"error": fmt.Sprintf("%s: %s", message, conflictErr.Error()),
+		})
+		return true
+	}
+	return false
+}
+
+// Funcion que responde 403 cuando el usuario no tiene permiso sobre la reserva. Devuelve true si respondio
+func respondForbiddenError(ctx *gin.Context, message string, err error) bool {
+	var forbiddenErr hotelsDomain.ForbiddenError
+	if !errors.As(err, &forbiddenErr) {
+		return false
+	}
+	ctx.JSON(http.StatusForbidden, gin.H{
+		"error": fmt.Sprintf("%s: %s", message, forbiddenErr.Error()),
+	})
+	return true
+}
@@ -0,0 +1,18 @@
+package hotels
+
+import "time"
+
+// Calendario de disponibilidad ya calculado, tal como se guarda en la cache
+type Calendar struct {
+	HotelID string
+	From    time.Time
+	To      time.Time
+	Nights  []CalendarNight
+}
+
+type CalendarNight struct {
+	Date      time.Time
+	RoomsLeft int
+	Price     float64
+	Bookable  bool
+}
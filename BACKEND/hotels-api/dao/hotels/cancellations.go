@@ -0,0 +1,26 @@
+package hotels
+
+import "time"
+
+// Politica de cancelacion de un hotel
+// Name es una de las politicas predefinidas (flexible, moderate, non_refundable) o custom, que usa Rules
+type CancellationPolicy struct {
+	Name  string             `bson:"name"`
+	Rules []CancellationRule `bson:"rules,omitempty"`
+}
+
+// Regla de una politica: si se cancela al menos HoursBeforeCheckIn horas antes del check-in se devuelve RefundPercent
+type CancellationRule struct {
+	HoursBeforeCheckIn int     `bson:"hours_before_check_in"`
+	RefundPercent      float64 `bson:"refund_percent"`
+}
+
+// Resultado de cancelar una reserva o una habitacion: de Amount se cobra Penalty y se devuelve Refund
+type Cancellation struct {
+	Policy        string    `bson:"policy"`
+	RefundPercent float64   `bson:"refund_percent"`
+	Amount        float64   `bson:"amount"`
+	Penalty       float64   `bson:"penalty"`
+	Refund        float64   `bson:"refund"`
+	CancelledAt   time.Time `bson:"cancelled_at"`
+}
@@ -0,0 +1,31 @@
+package hotels
+
+import "time"
+
+// Retencion temporal de habitaciones mientras el usuario termina de pagar
+// Mientras no vence ocupa sus habitaciones igual que una reserva
+type Hold struct {
+	ID        string            `bson:"_id,omitempty"`
+	HotelID   string            `bson:"hotel_id"`
+	UserID    string            `bson:"user_id"`
+	CheckIn   time.Time         `bson:"check_in"`
+	CheckOut  time.Time         `bson:"check_out"`
+	Guests    int               `bson:"guests"`
+	RoomCount int               `bson:"room_count"`
+	Rooms     []ReservationRoom `bson:"rooms"`
+	CreatedAt time.Time         `bson:"created_at"`
+	ExpiresAt time.Time         `bson:"expires_at"`
+}
+
+// Una retencion ocupa sus habitaciones igual que una reserva
+func (hold Hold) AsReservation() Reservation {
+	return Reservation{
+		HotelID:   hold.HotelID,
+		UserID:    hold.UserID,
+		CheckIn:   hold.CheckIn,
+		CheckOut:  hold.CheckOut,
+		Guests:    hold.Guests,
+		RoomCount: hold.RoomCount,
+		Rooms:     hold.Rooms,
+	}
+}
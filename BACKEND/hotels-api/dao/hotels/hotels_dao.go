@@ -1,6 +1,12 @@
 package hotels
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// ErrNotFound lo devuelven los repositorios cuando el documento buscado no existe
+var ErrNotFound = errors.New("not found")
 
 type Hotel struct {
 	ID        string   `bson:"_id,omitempty"`
@@ -19,6 +25,77 @@ type Hotel struct {
 	CheckOutTime time.Time `bson:"check_out_time"`
 	Amenities []string `bson:"amenities"`
 	Images    []string `bson:"images"`
+	Version   int64    `bson:"version"`
+	UpdatedAt time.Time `bson:"updated_at"`
+	Location           *GeoPoint           `bson:"location,omitempty"`
+	Pricing            *Pricing            `bson:"pricing,omitempty"`
+	MaxGuestsPerRoom   int                 `bson:"max_guests_per_room"`           // Capacidad de cada habitacion, 0 usa el valor por defecto
+	RoomTypes          []RoomType          `bson:"room_types,omitempty"`          // Sin tipos, todas las habitaciones son del tipo DefaultRoomType
+	CancellationPolicy *CancellationPolicy `bson:"cancellation_policy,omitempty"` // Sin politica se usa la flexible
+	ManagerIDs         []string            `bson:"manager_ids,omitempty"`         // Usuarios que administran el hotel y pueden ver y cambiar sus reservas
+}
+
+// Tipo de habitacion usado por los hoteles que no definen tipos y por las reservas anteriores a los tipos
+const DefaultRoomType = "standard"
+
+// Tipo de habitacion del hotel
+type RoomType struct {
+	Code          string  `bson:"code"`
+	Name          string  `bson:"name"`
+	Rooms         int     `bson:"rooms"`           // Cantidad de habitaciones de este tipo
+	MaxGuests     int     `bson:"max_guests"`      // 0 usa MaxGuestsPerRoom del hotel
+	PricePerNight float64 `bson:"price_per_night"` // 0 usa PricePerNight del hotel
+}
+
+// Reglas de precio dinamico del hotel. PricePerNight es la tarifa base y estas reglas la modifican por noche
+type Pricing struct {
+	WeekendRate         float64              `bson:"weekend_rate"`         // Tarifa de viernes y sabado a la noche, 0 usa la base
+	Seasons             []Season             `bson:"seasons"`              // Calendario de temporadas, gana la primera que contiene la noche
+	StayDiscounts       []StayDiscount       `bson:"stay_discounts"`       // Descuentos por cantidad de noches, se aplica el mayor que corresponda
+	OccupancySurcharges []OccupancySurcharge `bson:"occupancy_surcharges"` // Recargos segun la ocupacion de cada noche
+	IncludedGuests      int                  `bson:"included_guests"`      // Huespedes incluidos en la tarifa, 0 no cobra extra
+	ExtraGuestFee       float64              `bson:"extra_guest_fee"`      // Cargo por noche por cada huesped de mas
+}
+
+type Season struct {
+	Name        string    `bson:"name"`
+	From        time.Time `bson:"from"` // Primera noche de la temporada
+	To          time.Time `bson:"to"`   // Ultima noche de la temporada (inclusive)
+	Rate        float64   `bson:"rate"`
+	WeekendRate float64   `bson:"weekend_rate"`
+}
+
+type StayDiscount struct {
+	MinNights int     `bson:"min_nights"`
+	Percent   float64 `bson:"percent"`
+}
+
+type OccupancySurcharge struct {
+	MinOccupancy float64 `bson:"min_occupancy"` // Fraccion de habitaciones ocupadas, de 0 a 1
+	Percent      float64 `bson:"percent"`
+}
+
+// Punto GeoJSON, que es lo que indexa el indice 2dsphere de MongoDB
+// Ojo: GeoJSON guarda las coordenadas como [longitud, latitud]
+type GeoPoint struct {
+	Type        string    `bson:"type"`
+	Coordinates []float64 `bson:"coordinates"`
+}
+
+// Crea un punto a partir de latitud y longitud, o nil si no hay coordenadas
+func NewGeoPoint(latitude, longitude float64) *GeoPoint {
+	if latitude == 0 && longitude == 0 {
+		return nil
+	}
+	return &GeoPoint{Type: "Point", Coordinates: []float64{longitude, latitude}}
+}
+
+// Devuelve la latitud y longitud del punto (0, 0 si no tiene coordenadas)
+func (point *GeoPoint) LatLon() (float64, float64) {
+	if point == nil || len(point.Coordinates) != 2 {
+		return 0, 0
+	}
+	return point.Coordinates[1], point.Coordinates[0]
 }
 
 type Reservation struct {
@@ -28,4 +105,43 @@ type Reservation struct {
 	UserID   string    `bson:"user_id"`
 	CheckIn  time.Time `bson:"check_in"`
 	CheckOut time.Time `bson:"check_out"`
+	Guests          int               `bson:"guests"`     // Huespedes de las habitaciones no canceladas
+	RoomCount       int               `bson:"room_count"` // Habitaciones no canceladas
+	Rooms           []ReservationRoom `bson:"rooms"`
+	TotalPrice      float64           `bson:"total_price"` // Precio cotizado al momento de reservar
+	Currency        string            `bson:"currency"`
+	SpecialRequests string            `bson:"special_requests"`
+	ContactName     string            `bson:"contact_name"`
+	ContactEmail    string            `bson:"contact_email"`
+	ContactPhone    string            `bson:"contact_phone"`
+	Status          string            `bson:"status"`
+	Cancellation    *Cancellation     `bson:"cancellation,omitempty"` // Penalidad y reembolso, solo si se cancelo
+	CreatedAt       time.Time         `bson:"created_at"`
+	Version         int64             `bson:"version"` // Se incrementa en cada cambio, para no pisar cambios concurrentes
+}
+
+// Estados de una reserva. Las canceladas se guardan con su cancelacion pero no ocupan habitaciones
+const (
+	ReservationConfirmed = "confirmed" // Se autorizo el pago de la reserva
+	ReservationCancelled = "cancelled"
+)
+
+// Habitacion de una reserva. Cada una se puede cancelar por separado
+type ReservationRoom struct {
+	ID          string     `bson:"id"`
+	RoomType    string     `bson:"room_type"`
+	Guests      int        `bson:"guests"`
+	Price       float64    `bson:"price"`
+	Cancelled   bool       `bson:"cancelled"`
+	CancelledAt *time.Time `bson:"cancelled_at,omitempty"`
+	Penalty     float64    `bson:"penalty"` // Lo que se cobra de la habitacion si se cancelo
+}
+
+// Contadores de la cache de hoteles y reservas
+type CacheMetrics struct {
+	Hits         int64
+	Misses       int64
+	NotFoundHits int64 // Aciertos que respondieron que el hotel no existe, sin ir a la base principal
+	Evictions    int64 // Entradas que la cache saco por falta de lugar
+	Items        int64
 }
@@ -0,0 +1,66 @@
+package hotels
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrConflict lo devuelven los repositorios cuando el documento cambio desde que se leyo
+var ErrConflict = errors.New("conflict")
+
+// Estados de un pago. Un pago se crea autorizado, y de ahi se cobra o se anula
+const (
+	PaymentAuthorized        = "authorized"
+	PaymentCaptured          = "captured"
+	PaymentPartiallyRefunded = "partially_refunded"
+	PaymentRefunded          = "refunded"
+	PaymentVoided            = "voided"
+)
+
+// Tipos de eventos de un pago, tanto los de las operaciones propias como los que avisa el procesador de pagos
+const (
+	PaymentEventAuthorized = "payment.authorized"
+	PaymentEventCaptured   = "payment.captured"
+	PaymentEventRefunded   = "payment.refunded"
+	PaymentEventVoided     = "payment.voided"
+)
+
+// Pago de una reserva. Hay uno solo por reserva, con los montos acumulados y los eventos que se le aplicaron
+type Payment struct {
+	ID               string         `bson:"_id,omitempty"`
+	ReservationID    string         `bson:"reservation_id"`
+	AuthorizationID  string         `bson:"authorization_id"` // ID de la autorizacion en el procesador de pagos
+	Status           string         `bson:"status"`
+	Currency         string         `bson:"currency"`
+	AuthorizedAmount float64        `bson:"authorized_amount"`
+	CapturedAmount   float64        `bson:"captured_amount"`
+	RefundedAmount   float64        `bson:"refunded_amount"`
+	Events           []PaymentEvent `bson:"events"`
+	Version          int64          `bson:"version"` // Se incrementa en cada cambio, para no pisar cambios concurrentes
+	CreatedAt        time.Time      `bson:"created_at"`
+	UpdatedAt        time.Time      `bson:"updated_at"`
+}
+
+// Evento de un pago. El ID es el de la transaccion en el procesador de pagos, asi un evento repetido no se aplica dos veces
+type PaymentEvent struct {
+	ID              string    `bson:"id"`
+	Type            string    `bson:"type"`
+	AuthorizationID string    `bson:"authorization_id"`
+	Amount          float64   `bson:"amount"`
+	CreatedAt       time.Time `bson:"created_at"`
+}
+
+// Clave de idempotencia de una peticion. Mientras Done es false la peticion se esta procesando
+// El ID junta el usuario, la operacion (Scope) y la clave que manda el cliente, asi dos usuarios pueden usar la misma
+// PayloadHash es el hash de los datos de la peticion, para no devolver el resultado de otra con la misma clave
+// Result es el ID de lo que creo o modifico la peticion, para devolverlo si se repite
+type IdempotencyKey struct {
+	ID          string    `bson:"_id"`
+	Key         string    `bson:"key"`
+	UserID      string    `bson:"user_id"`
+	Scope       string    `bson:"scope"`
+	PayloadHash string    `bson:"payload_hash"`
+	Result      string    `bson:"result"`
+	Done        bool      `bson:"done"`
+	CreatedAt   time.Time `bson:"created_at"`
+}
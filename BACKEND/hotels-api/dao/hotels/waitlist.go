@@ -0,0 +1,46 @@
+package hotels
+
+import "time"
+
+// Estados de una entrada de la lista de espera
+const (
+	WaitlistWaiting = "waiting" // Esperando que se libere lugar
+	WaitlistOffered = "offered" // Se le ofrecio una habitacion que se le guarda hasta OfferExpiresAt
+	WaitlistExpired = "expired" // No reservo antes de que venciera la oferta
+	WaitlistBooked  = "booked"  // Reservo con la oferta
+)
+
+type WaitlistEntry struct {
+	ID             string     `bson:"_id,omitempty"`
+	HotelID        string     `bson:"hotel_id"`
+	UserID         string     `bson:"user_id"`
+	CheckIn        time.Time  `bson:"check_in"`
+	CheckOut       time.Time  `bson:"check_out"`
+	RoomType       string     `bson:"room_type"`
+	RoomCount      int        `bson:"room_count"`
+	Status         string     `bson:"status"`
+	CreatedAt      time.Time  `bson:"created_at"`
+	OfferedAt      *time.Time `bson:"offered_at,omitempty"`
+	OfferExpiresAt *time.Time `bson:"offer_expires_at,omitempty"`
+}
+
+// Una oferta guarda sus habitaciones igual que una retencion, hasta que vence
+func (entry WaitlistEntry) AsHold() Hold {
+	rooms := make([]ReservationRoom, 0, entry.RoomCount)
+	for i := 0; i < entry.RoomCount; i++ {
+		rooms = append(rooms, ReservationRoom{RoomType: entry.RoomType})
+	}
+	hold := Hold{
+		ID:        entry.ID,
+		HotelID:   entry.HotelID,
+		UserID:    entry.UserID,
+		CheckIn:   entry.CheckIn,
+		CheckOut:  entry.CheckOut,
+		RoomCount: entry.RoomCount,
+		Rooms:     rooms,
+	}
+	if entry.OfferExpiresAt != nil {
+		hold.ExpiresAt = *entry.OfferExpiresAt
+	}
+	return hold
+}
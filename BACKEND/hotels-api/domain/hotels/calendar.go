@@ -0,0 +1,19 @@
+package hotels
+
+import "time"
+
+// Calendario de disponibilidad de un hotel, una entrada por noche de [from, to)
+type Calendar struct {
+	HotelID string          `json:"hotel_id"`
+	From    time.Time       `json:"from"`
+	To      time.Time       `json:"to"`
+	Nights  []CalendarNight `json:"nights"`
+}
+
+// Una noche del calendario. Price es la tarifa de esa noche para un huesped, sin descuentos por estadia
+type CalendarNight struct {
+	Date      time.Time `json:"date"`
+	RoomsLeft int       `json:"rooms_left"`
+	Price     float64   `json:"price"`
+	Bookable  bool      `json:"bookable"`
+}
@@ -0,0 +1,22 @@
+package hotels
+
+import "time"
+
+type CancellationPolicy struct {
+	Name  string             `json:"name"`
+	Rules []CancellationRule `json:"rules,omitempty"`
+}
+
+type CancellationRule struct {
+	HoursBeforeCheckIn int     `json:"hours_before_check_in"`
+	RefundPercent      float64 `json:"refund_percent"`
+}
+
+type Cancellation struct {
+	Policy        string    `json:"policy"`
+	RefundPercent float64   `json:"refund_percent"`
+	Amount        float64   `json:"amount"`
+	Penalty       float64   `json:"penalty"`
+	Refund        float64   `json:"refund"`
+	CancelledAt   time.Time `json:"cancelled_at"`
+}
@@ -0,0 +1,53 @@
+package hotels
+
+import "fmt"
+
+// ValidationError indica que un dato de la peticion no es valido, los controladores lo devuelven como 400
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (err ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", err.Field, err.Message)
+}
+
+// UnprocessableError indica que la peticion esta bien formada pero no se puede cumplir (por ejemplo, el hotel o el
+// usuario no existen o no hay capacidad), los controladores lo devuelven como 422
+type UnprocessableError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (err UnprocessableError) Error() string {
+	return fmt.Sprintf("%s %s", err.Field, err.Message)
+}
+
+// PaymentError indica que el procesador de pagos rechazo el pago, los controladores lo devuelven como 402
+type PaymentError struct {
+	Message string `json:"message"`
+}
+
+func (err PaymentError) Error() string {
+	return fmt.Sprintf("payment declined: %s", err.Message)
+}
+
+// ConflictError indica que la peticion choca con otra que todavia se esta procesando, por ejemplo una con la
+// misma clave de idempotencia, los controladores lo devuelven como 409
+type ConflictError struct {
+	Message string `json:"message"`
+}
+
+func (err ConflictError) Error() string {
+	return err.Message
+}
+
+// ForbiddenError indica que el usuario que hace la peticion no tiene permiso sobre el recurso, por ejemplo una reserva
+// que no es suya de un hotel que no administra, los controladores lo devuelven como 403
+type ForbiddenError struct {
+	Message string `json:"message"`
+}
+
+func (err ForbiddenError) Error() string {
+	return err.Message
+}
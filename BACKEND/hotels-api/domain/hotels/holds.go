@@ -0,0 +1,16 @@
+package hotels
+
+import "time"
+
+type Hold struct {
+	ID        string            `json:"id"`
+	HotelID   string            `json:"hotel_id"`
+	UserID    string            `json:"user_id"`
+	CheckIn   time.Time         `json:"check_in"`
+	CheckOut  time.Time         `json:"check_out"`
+	Guests    int               `json:"guests"`
+	RoomCount int               `json:"room_count"`
+	Rooms     []ReservationRoom `json:"rooms"`
+	CreatedAt time.Time         `json:"created_at"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
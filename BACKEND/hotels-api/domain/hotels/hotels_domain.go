@@ -19,9 +19,40 @@ type Hotel struct {
 	CheckOutTime time.Time `json:"check_out_time"`
 	Amenities []string `json:"amenities"`
 	Images []string `json:"images"`
+	Version int64 `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Latitude           float64             `json:"latitude"`
+	Longitude          float64             `json:"longitude"`
+	Pricing            *Pricing            `json:"pricing,omitempty"`
+	MaxGuestsPerRoom   int                 `json:"max_guests_per_room"`
+	RoomTypes          []RoomType          `json:"room_types,omitempty"`
+	CancellationPolicy *CancellationPolicy `json:"cancellation_policy,omitempty"`
+	ManagerIDs         []string            `json:"-"` // Se asignan directo en la base, las rutas de hoteles no tienen autenticacion
 }
 
 type HotelNew struct {
 	Operation string `json:"operation"`
 	HotelID   string `json:"hotel_id"`
+	Version   int64  `json:"version"`
+	// Solo en los eventos RESERVATIONS, la reserva y el usuario cuyas entradas de cache quedaron viejas
+	ReservationID string `json:"reservation_id,omitempty"`
+	UserID        string `json:"user_id,omitempty"`
+}
+
+type RoomType struct {
+	Code          string  `json:"code"`
+	Name          string  `json:"name"`
+	Rooms         int     `json:"rooms"`
+	MaxGuests     int     `json:"max_guests"`
+	PricePerNight float64 `json:"price_per_night"`
+}
+
+type CacheMetrics struct {
+	Hits         int64   `json:"hits"`
+	Misses       int64   `json:"misses"`
+	HitRatio     float64 `json:"hit_ratio"`
+	NotFoundHits int64   `json:"not_found_hits"`
+	Evictions    int64   `json:"evictions"`
+	Items        int64   `json:"items"`
+	Errors       int64   `json:"errors"` // Escrituras e invalidaciones de la cache que fallaron
 }
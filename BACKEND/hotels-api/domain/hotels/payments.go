@@ -0,0 +1,46 @@
+package hotels
+
+import "time"
+
+type Payment struct {
+	ID               string         `json:"id"`
+	ReservationID    string         `json:"reservation_id"`
+	AuthorizationID  string         `json:"authorization_id"`
+	Status           string         `json:"status"`
+	Currency         string         `json:"currency"`
+	AuthorizedAmount float64        `json:"authorized_amount"`
+	CapturedAmount   float64        `json:"captured_amount"`
+	RefundedAmount   float64        `json:"refunded_amount"`
+	Events           []PaymentEvent `json:"events"`
+	Version          int64          `json:"version"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+}
+
+// Evento de un pago, es tambien el formato de los webhooks del procesador de pagos
+type PaymentEvent struct {
+	ID              string    `json:"id"`
+	Type            string    `json:"type"`
+	AuthorizationID string    `json:"authorization_id"`
+	Amount          float64   `json:"amount"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// Pedido de autorizacion al procesador de pagos
+// Token es el medio de pago tokenizado por el cliente, los datos de la tarjeta nunca llegan a la API
+type PaymentRequest struct {
+	Reference      string  `json:"reference"`
+	Amount         float64 `json:"amount"`
+	Currency       string  `json:"currency"`
+	Token          string  `json:"token"`
+	IdempotencyKey string  `json:"idempotency_key"`
+}
+
+// Respuesta del procesador de pagos a una operacion
+// Un rechazo no es un error: Approved viene en false y DeclineReason dice por que
+type PaymentResult struct {
+	TransactionID string  `json:"transaction_id"`
+	Approved      bool    `json:"approved"`
+	DeclineReason string  `json:"decline_reason"`
+	Amount        float64 `json:"amount"`
+}
@@ -0,0 +1,52 @@
+package hotels
+
+import "time"
+
+type Pricing struct {
+	WeekendRate         float64              `json:"weekend_rate"`
+	Seasons             []Season             `json:"seasons"`
+	StayDiscounts       []StayDiscount       `json:"stay_discounts"`
+	OccupancySurcharges []OccupancySurcharge `json:"occupancy_surcharges"`
+	IncludedGuests      int                  `json:"included_guests"`
+	ExtraGuestFee       float64              `json:"extra_guest_fee"`
+}
+
+type Season struct {
+	Name        string    `json:"name"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	Rate        float64   `json:"rate"`
+	WeekendRate float64   `json:"weekend_rate"`
+}
+
+type StayDiscount struct {
+	MinNights int     `json:"min_nights"`
+	Percent   float64 `json:"percent"`
+}
+
+type OccupancySurcharge struct {
+	MinOccupancy float64 `json:"min_occupancy"`
+	Percent      float64 `json:"percent"`
+}
+
+// Cotizacion de una estadia, con el detalle de cada noche
+type Quote struct {
+	HotelID  string       `json:"hotel_id"`
+	CheckIn  time.Time    `json:"check_in"`
+	CheckOut time.Time    `json:"check_out"`
+	Guests   int          `json:"guests"`
+	Nights   []NightPrice `json:"nights"`
+	Subtotal float64      `json:"subtotal"`
+	Discount float64      `json:"discount"`
+	Total    float64      `json:"total"`
+}
+
+type NightPrice struct {
+	Date               time.Time `json:"date"`
+	Season             string    `json:"season,omitempty"`
+	Weekend            bool      `json:"weekend"`
+	Rate               float64   `json:"rate"`
+	OccupancySurcharge float64   `json:"occupancy_surcharge"`
+	ExtraGuestFee      float64   `json:"extra_guest_fee"`
+	Price              float64   `json:"price"`
+}
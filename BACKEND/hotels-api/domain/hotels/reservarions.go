@@ -3,15 +3,54 @@ package hotels
 import "time"
 
 type Reservation struct {
-	ID       string    `json:"id"`
-	HotelID  string    `json:"hotel_id"`
-	HotelName string   `json:"hotel_name"`
-	UserID   string    `json:"user_id"`
+	ID              string            `json:"id"`
+	HotelID         string            `json:"hotel_id"`
+	HotelName       string            `json:"hotel_name"`
+	UserID          string            `json:"user_id"`
+	CheckIn         time.Time         `json:"check_in"`
+	CheckOut        time.Time         `json:"check_out"`
+	Guests          int               `json:"guests"`
+	RoomCount       int               `json:"room_count"`
+	Rooms           []ReservationRoom `json:"rooms"`
+	TotalPrice      float64           `json:"total_price"`
+	Currency        string            `json:"currency"`
+	SpecialRequests string            `json:"special_requests"`
+	ContactName     string            `json:"contact_name"`
+	ContactEmail    string            `json:"contact_email"`
+	ContactPhone    string            `json:"contact_phone"`
+	Status          string            `json:"status"`
+	Cancellation    *Cancellation     `json:"cancellation,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+	PaymentToken    string            `json:"payment_token,omitempty"` // Medio de pago tokenizado, solo al reservar
+	IdempotencyKey  string            `json:"-"`                       // Viene en el header Idempotency-Key
+}
+
+type ReservationRoom struct {
+	ID          string     `json:"id"`
+	RoomType    string     `json:"room_type"`
+	Guests      int        `json:"guests"`
+	Price       float64    `json:"price"`
+	Cancelled   bool       `json:"cancelled"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+	Penalty     float64    `json:"penalty"`
+}
+
+// Cambio de una reserva: las fechas o el tipo de todas sus habitaciones activas. Lo que viene vacio no cambia
+type ReservationChange struct {
 	CheckIn  time.Time `json:"check_in"`
 	CheckOut time.Time `json:"check_out"`
+	RoomType string    `json:"room_type"`
 }
 
+// Evento de una reserva, se publica en la cola de reservas
 type ReservationNew struct {
-	Operation string `json:"operation"`
-	ReservationID   string `json:"reservation_id"`
-}
\ No newline at end of file
+	Operation        string    `json:"operation"`
+	ReservationID    string    `json:"reservation_id"`
+	HotelID          string    `json:"hotel_id"`
+	UserID           string    `json:"user_id"`
+	CheckIn          time.Time `json:"check_in"`
+	CheckOut         time.Time `json:"check_out"`
+	PreviousCheckIn  time.Time `json:"previous_check_in"`
+	PreviousCheckOut time.Time `json:"previous_check_out"`
+	TotalPrice       float64   `json:"total_price"`
+}
@@ -0,0 +1,17 @@
+package hotels
+
+import "time"
+
+type WaitlistEntry struct {
+	ID             string     `json:"id"`
+	HotelID        string     `json:"hotel_id"`
+	UserID         string     `json:"user_id"`
+	CheckIn        time.Time  `json:"check_in"`
+	CheckOut       time.Time  `json:"check_out"`
+	RoomType       string     `json:"room_type"`
+	RoomCount      int        `json:"room_count"`
+	Status         string     `json:"status"`
+	CreatedAt      time.Time  `json:"created_at"`
+	OfferedAt      *time.Time `json:"offered_at,omitempty"`
+	OfferExpiresAt *time.Time `json:"offer_expires_at,omitempty"`
+}
@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
+	"hotels-api/clients/notifications"
+	"hotels-api/clients/payments"
 	"hotels-api/clients/queues"
+	"hotels-api/clients/users"
 	controllers "hotels-api/controllers/hotels"
 	repositories "hotels-api/repositories/hotels"
 	services "hotels-api/services/hotels"
 	"log"
+	"os"
 	"time"
 
 	"hotels-api/utils"
@@ -16,11 +21,28 @@ import (
 func main() {
 	// Local cache
 	cacheRepository := repositories.NewCache(repositories.CacheConfig{
-		MaxSize:      100000,
-		ItemsToPrune: 100,
-		Duration:     30 * time.Second,
+		MaxSize:          100000,
+		ItemsToPrune:     100,
+		Duration:         30 * time.Second,
+		NotFoundDuration: 5 * time.Second,
+		Jitter:           0.1,
 	})
 
+	// Memcached
+	// Cache compartida entre las replicas, solo si se configura MEMCACHED_HOST
+	var sharedCache services.SharedCache
+	if host := os.Getenv("MEMCACHED_HOST"); host != "" {
+		port := os.Getenv("MEMCACHED_PORT")
+		if port == "" {
+			port = "11211"
+		}
+		sharedCache = repositories.NewMemcached(repositories.MemcachedConfig{
+			Host:     host,
+			Port:     port,
+			Duration: 5 * time.Minute,
+		})
+	}
+
 	// Mongo
 	mainRepository := repositories.NewMongo(repositories.MongoConfig{
 		Host:                    "mongo",
@@ -30,20 +52,65 @@ func main() {
 		Database:                "hotels-api",
 		Collection_hotels:       "hotels",
 		Collection_reservations: "reservations",
+		Collection_waitlist:     "waitlist",
+		Collection_holds:        "holds",
+		Collection_payments:     "payments",
+		Collection_idempotency:  "idempotency_keys",
+		Collection_locks:        "locks",
 	})
 
+	// Completa los campos nuevos de las reservas existentes
+	if err := mainRepository.MigrateReservations(context.Background()); err != nil {
+		log.Printf("error migrating reservations: %v", err)
+	}
+
 	// Rabbit
 	//Este es el que carga a la cola de rabbit
+	//Los eventos de hoteles pasan por un exchange fanout: la cola de search-api queda enlazada y cada replica
+	//de hotels-api enlaza la suya para borrar de su cache los hoteles, las reservas y las retenciones que cambiaron en otra
 	eventsQueue := queues.NewRabbit(queues.RabbitConfig{
 		Host:      "rabbitmq",
 		Port:      "5672",
 		Username:  "root",
 		Password:  "root",
 		QueueName: "hotels-news",
+		Exchange:  "hotels-events",
+	})
+
+	// Los eventos de reservas van a otra cola, search-api solo consume la de hoteles
+	reservationsQueue := queues.NewRabbit(queues.RabbitConfig{
+		Host:      "rabbitmq",
+		Port:      "5672",
+		Username:  "root",
+		Password:  "root",
+		QueueName: "reservations-news",
+	})
+
+	// Users API
+	// Para validar que los usuarios de las reservas existen
+	usersAPI := users.NewHTTP(users.HTTPConfig{
+		Host: "users-api",
+		Port: "8080",
 	})
 
+	// Notifications
+	// Por ahora las ofertas de la lista de espera solo se escriben en el log
+	notifier := notifications.NewLog()
+
+	// Payments
+	// Por ahora se usa el procesador en memoria, que aprueba cualquier token salvo los de rechazo
+	paymentGateway := payments.NewFake("hotels-api-webhook-secret")
+
 	// Services
-	service := services.NewService(mainRepository, cacheRepository, eventsQueue)
+	service := services.NewService(mainRepository, cacheRepository, sharedCache, eventsQueue, reservationsQueue, usersAPI, notifier, paymentGateway)
+
+	// Escucha los eventos de hoteles de todas las replicas para mantener la cache local al dia
+	if err := eventsQueue.StartConsumer(service.HandleHotelNew); err != nil {
+		log.Fatalf("error running consumer: %v", err)
+	}
+
+	// Libera las retenciones vencidas y vence las ofertas de la lista de espera que no se usaron
+	go service.RunSweeper(context.Background(), time.Minute)
 
 	// Controllers
 	controller := controllers.NewController(service)
@@ -55,17 +122,36 @@ func main() {
 	// Use CORS middleware
 	router.Use(utils.CorsMiddleware())
 
+	// Las rutas de una reserva en particular necesitan el token de users-api, para saber si es del usuario
+	// o de un administrador del hotel
+	auth := utils.AuthMiddleware("ThisIsAnExampleJWTKey!")
+
 	router.GET("/hotels/:hotel_id", controller.GetHotelByID)
+	router.GET("/hotels/cache/metrics", controller.CacheMetrics)
 	router.POST("/hotels", controller.Create)
 	router.PUT("/hotels/:hotel_id", controller.Update)
 	router.DELETE("/hotels/:hotel_id", controller.Delete)
-	router.POST("/hotels/reservations", controller.CreateReservation)
-	router.DELETE("/hotels/reservations/:id", controller.CancelReservation)
-	router.GET("/hotels/:hotel_id/reservations", controller.GetReservationsByHotelID)
-	router.GET("/users/:user_id/reservations", controller.GetReservationsByUserID)
-	router.GET("hotels/:hotel_id/users/:user_id/reservations", controller.GetReservationsByUserAndHotelID)
+	router.POST("/hotels/reservations", auth, controller.CreateReservation)
+	router.GET("/hotels/reservations/:id", auth, controller.GetReservationByID)
+	router.DELETE("/hotels/reservations/:id", auth, controller.CancelReservation)
+	router.PATCH("/hotels/reservations/:id", auth, controller.ModifyReservation)
+	router.POST("/hotels/reservations/holds", auth, controller.CreateHold)
+	router.POST("/hotels/reservations/holds/:id/confirm", auth, controller.ConfirmHold)
+	router.DELETE("/hotels/reservations/holds/:id", auth, controller.ReleaseHold)
+	router.DELETE("/hotels/reservations/:id/rooms/:room_id", auth, controller.CancelReservationRoom)
+	router.GET("/hotels/reservations/:id/cancellation", auth, controller.PreviewCancellation)
+	router.GET("/hotels/reservations/:id/payment", auth, controller.GetPayment)
+	router.POST("/hotels/reservations/:id/payment/capture", auth, controller.CapturePayment)
+	router.POST("/hotels/reservations/:id/payment/refund", auth, controller.RefundPayment)
+	router.POST("/hotels/payments/webhook", controller.PaymentWebhook)
+	router.GET("/hotels/:hotel_id/reservations", auth, controller.GetReservationsByHotelID)
+	router.GET("/users/:user_id/reservations", auth, controller.GetReservationsByUserID)
+	router.GET("hotels/:hotel_id/users/:user_id/reservations", auth, controller.GetReservationsByUserAndHotelID)
 	router.POST("/hotels/availability", controller.GetAvailability)
+	router.GET("/hotels/:hotel_id/quote", controller.Quote)
+	router.GET("/hotels/:hotel_id/calendar", controller.Calendar)
+	router.POST("/hotels/:hotel_id/waitlist", auth, controller.JoinWaitlist)
 	if err := router.Run(":8081"); err != nil {
-		log.Fatalf("error running application: %w", err)
+		log.Fatalf("error running application: %v", err)
 	}
 }
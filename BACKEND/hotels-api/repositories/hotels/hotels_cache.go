@@ -4,6 +4,9 @@ import (
 	"context"
 	"fmt"
 	hotelsDAO "hotels-api/dao/hotels"
+	"hotels-api/services/availability"
+	"math/rand"
+	"sync/atomic"
 	"time"
 
 	"github.com/karlseguin/ccache"
@@ -11,46 +14,151 @@ import (
 
 const (
 	keyFormat = "hotel:%s"
+	calendarKeyFormat              = "%s:%s"
+	reservationKeyFormat           = "reservation:%s"
+	hotelReservationsKeyFormat     = "reservations:hotel:%s"
+	userReservationsKeyFormat      = "reservations:user:%s"
+	userHotelReservationsKeyFormat = "reservations:hotel:%s:user:%s"
+	hotelHoldsKeyFormat            = "holds:hotel:%s"
 )
 
 type CacheConfig struct {
 	MaxSize      int64
 	ItemsToPrune uint32
 	Duration     time.Duration
+	NotFoundDuration time.Duration // Cuanto se recuerda que un hotel no existe, 0 no lo recuerda
+	Jitter           float64       // Fraccion de la duracion que se suma o resta al azar, para que las entradas no venzan juntas
 }
 
 type Cache struct {
 	client   *ccache.Cache
 	duration time.Duration
+	notFoundDuration time.Duration
+	jitter           float64
+	metrics          *cacheMetrics
+	// Los calendarios se guardan por hotel (clave primaria) y rango (clave secundaria),
+	// asi se pueden eliminar todos los de un hotel sin conocer los rangos
+	calendars *ccache.LayeredCache
 }
 
+// Contadores de la cache, se comparten entre las copias de Cache
+type cacheMetrics struct {
+	hits         atomic.Int64
+	misses       atomic.Int64
+	notFoundHits atomic.Int64
+	evictions    atomic.Int64
+}
+
+// Valor que se guarda en ccache. ccache avisa por OnDelete tanto de lo que desaloja como de lo que se borra o se
+// reemplaza, asi que las entradas que se borran desde este repositorio se marcan para no contarlas como desalojos
+type entry struct {
+	value   interface{}
+	deleted atomic.Bool
+}
+
+// Valor que se guarda en lugar de un hotel que no existe, para no volver a buscarlo en MongoDB
+type notFound struct{}
 
 //Crea una nueva instancia de Cache
 func NewCache(config CacheConfig) Cache {
+	metrics := &cacheMetrics{}
 	client := ccache.New(ccache.Configure().
+		MaxSize(config.MaxSize).
+		ItemsToPrune(config.ItemsToPrune).
+		OnDelete(func(item *ccache.Item) {
+			if cached, ok := item.Value().(*entry); ok && !cached.deleted.Load() {
+				metrics.evictions.Add(1)
+			}
+		}))
+	calendars := ccache.Layered(ccache.Configure().
 		MaxSize(config.MaxSize).
 		ItemsToPrune(config.ItemsToPrune))
 	return Cache{
 		client:   client,
 		duration: config.Duration,
+		notFoundDuration: config.NotFoundDuration,
+		jitter:           config.Jitter,
+		metrics:          metrics,
+		calendars:        calendars,
+	}
+}
+
+// Funcion que busca una entrada vigente y la cuenta como acierto o fallo para las metricas
+func (repository Cache) lookup(key string) (interface{}, error) {
+	item := repository.client.Get(key)
+	if item == nil {
+		repository.metrics.misses.Add(1)
+		return nil, fmt.Errorf("not found item with key %s", key)
+	}
+	if item.Expired() {
+		repository.metrics.misses.Add(1)
+		return nil, fmt.Errorf("item with key %s is expired", key)
+	}
+	repository.metrics.hits.Add(1)
+	return item.Value().(*entry).value, nil
+}
+
+// Funcion que guarda una entrada con la duracion con variacion
+// La anterior se borra antes, para que el reemplazo no cuente como desalojo
+func (repository Cache) set(key string, value interface{}, duration time.Duration) {
+	repository.delete(key)
+	repository.client.Set(key, &entry{value: value}, repository.ttl(duration))
+}
+
+// Funcion que borra una entrada marcandola antes, para que no cuente como desalojo
+func (repository Cache) delete(key string) {
+	if item := repository.client.Get(key); item != nil {
+		item.Value().(*entry).deleted.Store(true)
+	}
+	repository.client.Delete(key)
+}
+
+// Funcion que le suma o resta a la duracion hasta jitter de si misma al azar
+// Asi las entradas que se guardan juntas, por ejemplo al arrancar, no vencen todas al mismo tiempo
+func (repository Cache) ttl(duration time.Duration) time.Duration {
+	if repository.jitter <= 0 {
+		return duration
+	}
+	delta := float64(duration) * repository.jitter
+	return duration + time.Duration((rand.Float64()*2-1)*delta)
+}
+
+// Devuelve los contadores de la cache
+// ccache saca las entradas en otra goroutine, asi que los desalojos pueden tardar un momento en aparecer
+func (repository Cache) Metrics(ctx context.Context) (hotelsDAO.CacheMetrics, error) {
+	return hotelsDAO.CacheMetrics{
+		Hits:         repository.metrics.hits.Load(),
+		Misses:       repository.metrics.misses.Load(),
+		NotFoundHits: repository.metrics.notFoundHits.Load(),
+		Evictions:    repository.metrics.evictions.Load(),
+		Items:        int64(repository.client.ItemCount()),
+	}, nil
+}
+
+// Recuerda por un rato corto que un hotel no existe en la base principal
+func (repository Cache) SetHotelNotFound(ctx context.Context, id string) error {
+	if repository.notFoundDuration <= 0 {
+		return nil
 	}
+	repository.set(fmt.Sprintf(keyFormat, id), notFound{}, repository.notFoundDuration)
+	return nil
 }
 
 //Obtiene un hotel por su ID de la cache
 func (repository Cache) GetHotelByID(ctx context.Context, id string) (hotelsDAO.Hotel, error) {
 	//Crea la llave para buscar el hotel
 	key := fmt.Sprintf(keyFormat, id)
-	//Obtiene el item de la cache
-	item := repository.client.Get(key)
-	//Si no se encuentra el item, regresa un error
-	if item == nil {
-		return hotelsDAO.Hotel{}, fmt.Errorf("not found item with key %s", key)
+	//Obtiene el item de la cache, si no esta o esta expirado regresa un error
+	value, err := repository.lookup(key)
+	if err != nil {
+		return hotelsDAO.Hotel{}, err
 	}
-	//Si el item esta expirado, regresa un error
-	if item.Expired() {
-		return hotelsDAO.Hotel{}, fmt.Errorf("item with key %s is expired", key)
+	//Si se guardo que el hotel no existe, no hace falta buscarlo en la base principal
+	if _, ok := value.(notFound); ok {
+		repository.metrics.notFoundHits.Add(1)
+		return hotelsDAO.Hotel{}, fmt.Errorf("hotel with ID %s: %w", id, hotelsDAO.ErrNotFound)
 	}
-	hotelDAO, ok := item.Value().(hotelsDAO.Hotel)
+	hotelDAO, ok := value.(hotelsDAO.Hotel)
 	if !ok {
 		return hotelsDAO.Hotel{}, fmt.Errorf("error converting item with key %s", key)
 	}
@@ -63,25 +171,31 @@ func (repository Cache) GetHotelByID(ctx context.Context, id string) (hotelsDAO.
 func (repository Cache) Create(ctx context.Context, hotel hotelsDAO.Hotel) (string, error) {
 	key := fmt.Sprintf(keyFormat, hotel.ID)
 	//Guarda el hotel en la cache
-	repository.client.Set(key, hotel, repository.duration)
+	repository.set(key, hotel, repository.duration)
 	return hotel.ID, nil
 }
 
+// Guarda un hotel en la cache por ttl, cero usa la duracion de la configuracion
+func (repository Cache) SetHotel(ctx context.Context, hotel hotelsDAO.Hotel, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = repository.duration
+	}
+	repository.set(fmt.Sprintf(keyFormat, hotel.ID), hotel, ttl)
+	return nil
+}
+
 //Actualiza un hotel en la cache
 func (repository Cache) Update(ctx context.Context, hotel hotelsDAO.Hotel) error {
 	key := fmt.Sprintf(keyFormat, hotel.ID)
 
 	// Busca el item actual en la cache y regresa un error si no se encuentra o esta expirado
-	item := repository.client.Get(key)
-	if item == nil {
-		return fmt.Errorf("hotel with ID %s not found in cache", hotel.ID)
-	}
-	if item.Expired() {
-		return fmt.Errorf("item with key %s is expired", key)
+	value, err := repository.lookup(key)
+	if err != nil {
+		return fmt.Errorf("hotel with ID %s not found in cache: %w", hotel.ID, err)
 	}
 
 	// Convierte el item a un hotel
-	currentHotel, ok := item.Value().(hotelsDAO.Hotel)
+	currentHotel, ok := value.(hotelsDAO.Hotel)
 	if !ok {
 		return fmt.Errorf("error converting item with key %s", key)
 	}
@@ -132,9 +246,28 @@ func (repository Cache) Update(ctx context.Context, hotel hotelsDAO.Hotel) error
 	if len(hotel.Images) > 0 {
 		currentHotel.Images = hotel.Images
 	}
+	if hotel.Location != nil {
+		currentHotel.Location = hotel.Location
+	}
+	if hotel.Pricing != nil {
+		currentHotel.Pricing = hotel.Pricing
+	}
+	if hotel.MaxGuestsPerRoom != 0 {
+		currentHotel.MaxGuestsPerRoom = hotel.MaxGuestsPerRoom
+	}
+	if len(hotel.RoomTypes) > 0 {
+		currentHotel.RoomTypes = hotel.RoomTypes
+	}
+	if hotel.CancellationPolicy != nil {
+		currentHotel.CancellationPolicy = hotel.CancellationPolicy
+	}
+	if hotel.Version != 0 {
+		currentHotel.Version = hotel.Version
+		currentHotel.UpdatedAt = hotel.UpdatedAt
+	}
 
 	// Guarda el hotel actualizado en la cache y reinicia el tiempo de expiracion
-	repository.client.Set(key, currentHotel, repository.duration)
+	repository.set(key, currentHotel, repository.duration)
 
 	//Devuelve nil si no hay errores
 	return nil
@@ -144,36 +277,102 @@ func (repository Cache) Update(ctx context.Context, hotel hotelsDAO.Hotel) error
 func (repository Cache) Delete(ctx context.Context, id string) error {
 	key := fmt.Sprintf(keyFormat, id)
 	// Elimina el hotel de la cache
-	repository.client.Delete(key)
+	repository.delete(key)
 	return nil
 }
 
 
 // Crea una reserva en la cache
 func (repository Cache) CreateReservation(ctx context.Context, reservation hotelsDAO.Reservation) (string, error) {
-    key := fmt.Sprintf("reservation:%s", reservation.ID)
-    repository.client.Set(key, reservation, repository.duration)
+	key := fmt.Sprintf(reservationKeyFormat, reservation.ID)
+	repository.set(key, reservation, repository.duration)
     return reservation.ID, nil
 }
 
+// Guarda una reserva en la cache por ttl, cero usa la duracion de la configuracion
+func (repository Cache) SetReservation(ctx context.Context, reservation hotelsDAO.Reservation, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = repository.duration
+	}
+	repository.set(fmt.Sprintf(reservationKeyFormat, reservation.ID), reservation, ttl)
+	return nil
+}
+
+// Saca una reserva de la cache, la proxima lectura la trae de la base principal
+func (repository Cache) DeleteReservation(ctx context.Context, id string) error {
+	repository.delete(fmt.Sprintf(reservationKeyFormat, id))
+	return nil
+}
+
+// Reemplaza una reserva modificada en la cache
+func (repository Cache) UpdateReservation(ctx context.Context, reservation hotelsDAO.Reservation) error {
+	key := fmt.Sprintf(reservationKeyFormat, reservation.ID)
+	repository.set(key, reservation, repository.duration)
+	return nil
+}
+
 // Cancela una reserva en la cache
-func (repository Cache) CancelReservation(ctx context.Context, id string) error {
-    key := fmt.Sprintf("reservation:%s", id)
-    repository.client.Delete(key)
+// La reserva se saca de la cache, la cancelada se vuelve a leer de la base principal
+func (repository Cache) CancelReservation(ctx context.Context, id string, cancellation hotelsDAO.Cancellation) error {
+	key := fmt.Sprintf(reservationKeyFormat, id)
+	repository.delete(key)
     return nil
 }
 
+// Cancela una habitacion de una reserva en la cache
+// Si la reserva no esta en la cache no hay nada que actualizar
+func (repository Cache) CancelReservationRoom(ctx context.Context, id string, roomID string, penalty float64) (hotelsDAO.Reservation, error) {
+	key := fmt.Sprintf(reservationKeyFormat, id)
+	value, err := repository.lookup(key)
+	if err != nil {
+		return hotelsDAO.Reservation{}, nil
+	}
+	reservation, ok := value.(hotelsDAO.Reservation)
+	if !ok {
+		return hotelsDAO.Reservation{}, fmt.Errorf("error converting item with key %s", key)
+	}
+
+	// Copia las habitaciones para no modificar el valor que comparten otras lecturas de la cache
+	rooms := make([]hotelsDAO.ReservationRoom, len(reservation.Rooms))
+	copy(rooms, reservation.Rooms)
+	for i := range rooms {
+		if rooms[i].ID == roomID && !rooms[i].Cancelled {
+			now := time.Now().UTC()
+			rooms[i].Cancelled = true
+			rooms[i].CancelledAt = &now
+			rooms[i].Penalty = penalty
+			reservation.RoomCount--
+			reservation.Guests -= rooms[i].Guests
+			reservation.TotalPrice -= rooms[i].Price - penalty
+		}
+	}
+	reservation.Rooms = rooms
+	repository.set(key, reservation, repository.duration)
+	return reservation, nil
+}
+
+// Obtiene una reserva por su ID de la cache
+func (repository Cache) GetReservationByID(ctx context.Context, id string) (hotelsDAO.Reservation, error) {
+	key := fmt.Sprintf(reservationKeyFormat, id)
+	value, err := repository.lookup(key)
+	if err != nil {
+		return hotelsDAO.Reservation{}, err
+	}
+	reservation, ok := value.(hotelsDAO.Reservation)
+	if !ok {
+		return hotelsDAO.Reservation{}, fmt.Errorf("error converting item with key %s", key)
+	}
+	return reservation, nil
+}
+
 // Obtiene las reservas por ID de hotel y usuario de la cache
 func (repository Cache) GetReservationsByUserAndHotelID(ctx context.Context, hotelID string, userID string) ([]hotelsDAO.Reservation, error) {
-    key := fmt.Sprintf("reservations:hotel:%s:user:%s", hotelID, userID)
-    item := repository.client.Get(key)
-    if item == nil {
-        return nil, fmt.Errorf("not found item with key %s", key)
-    }
-    if item.Expired() {
-        return nil, fmt.Errorf("item with key %s is expired", key)
+	key := fmt.Sprintf(userHotelReservationsKeyFormat, hotelID, userID)
+	value, err := repository.lookup(key)
+	if err != nil {
+		return nil, err
     }
-    reservations, ok := item.Value().([]hotelsDAO.Reservation)
+	reservations, ok := value.([]hotelsDAO.Reservation)
     if !ok {
         return nil, fmt.Errorf("error converting item with key %s", key)
     }
@@ -182,15 +381,12 @@ func (repository Cache) GetReservationsByUserAndHotelID(ctx context.Context, hot
 
 // Obtiene las reservas por ID de hotel de la cache
 func (repository Cache) GetReservationsByHotelID(ctx context.Context, hotelID string) ([]hotelsDAO.Reservation, error) {
-	key := fmt.Sprintf("reservations:hotel:%s", hotelID)
-	item := repository.client.Get(key)
-	if item == nil {
-		return nil, fmt.Errorf("not found item with key %s", key)
+	key := fmt.Sprintf(hotelReservationsKeyFormat, hotelID)
+	value, err := repository.lookup(key)
+	if err != nil {
+		return nil, err
 	}
-	if item.Expired() {
-		return nil, fmt.Errorf("item with key %s is expired", key)
-	}
-	reservations, ok := item.Value().([]hotelsDAO.Reservation)
+	reservations, ok := value.([]hotelsDAO.Reservation)
 	if !ok {
 		return nil, fmt.Errorf("error converting item with key %s", key)
 	}
@@ -199,21 +395,59 @@ func (repository Cache) GetReservationsByHotelID(ctx context.Context, hotelID st
 
 // Obtiene las reservas por ID de usuario de la cache
 func (repository Cache) GetReservationsByUserID(ctx context.Context, userID string) ([]hotelsDAO.Reservation, error) {
-    key := fmt.Sprintf("reservations:user:%s", userID)
-    item := repository.client.Get(key)
-    if item == nil {
-        return nil, fmt.Errorf("not found item with key %s", key)
-    }
-    if item.Expired() {
-        return nil, fmt.Errorf("item with key %s is expired", key)
+	key := fmt.Sprintf(userReservationsKeyFormat, userID)
+	value, err := repository.lookup(key)
+	if err != nil {
+		return nil, err
     }
-    reservations, ok := item.Value().([]hotelsDAO.Reservation)
+	reservations, ok := value.([]hotelsDAO.Reservation)
     if !ok {
         return nil, fmt.Errorf("error converting item with key %s", key)
     }
     return reservations, nil
 }
 
+// Guarda en la cache las reservas de un hotel leidas de la base principal
+func (repository Cache) SetReservationsByHotelID(ctx context.Context, hotelID string, reservations []hotelsDAO.Reservation) error {
+	repository.set(fmt.Sprintf(hotelReservationsKeyFormat, hotelID), reservations, repository.duration)
+	return nil
+}
+
+// Guarda en la cache las reservas de un usuario leidas de la base principal
+func (repository Cache) SetReservationsByUserID(ctx context.Context, userID string, reservations []hotelsDAO.Reservation) error {
+	repository.set(fmt.Sprintf(userReservationsKeyFormat, userID), reservations, repository.duration)
+	return nil
+}
+
+// Guarda en la cache las reservas de un usuario en un hotel leidas de la base principal
+func (repository Cache) SetReservationsByUserAndHotelID(ctx context.Context, hotelID string, userID string, reservations []hotelsDAO.Reservation) error {
+	repository.set(fmt.Sprintf(userHotelReservationsKeyFormat, hotelID, userID), reservations, repository.duration)
+	return nil
+}
+
+// Elimina de la cache las listas de reservas en las que aparece una reserva del usuario en el hotel:
+// la del hotel, la del usuario y la del usuario en el hotel. Se llama cada vez que se crea o cambia una reserva
+func (repository Cache) InvalidateReservations(ctx context.Context, hotelID string, userID string) error {
+	repository.delete(fmt.Sprintf(hotelReservationsKeyFormat, hotelID))
+	repository.delete(fmt.Sprintf(userReservationsKeyFormat, userID))
+	repository.delete(fmt.Sprintf(userHotelReservationsKeyFormat, hotelID, userID))
+	return nil
+}
+
+// Guarda en la cache las retenciones vigentes de un hotel, que ocupan habitaciones para la disponibilidad
+// Incluye las ofertas de la lista de espera, que se guardan como retenciones que vencen con la oferta
+func (repository Cache) SetActiveHolds(ctx context.Context, hotelID string, holds []hotelsDAO.Hold) error {
+	repository.set(fmt.Sprintf(hotelHoldsKeyFormat, hotelID), holds, repository.duration)
+	return nil
+}
+
+// Elimina de la cache las retenciones de un hotel, se llama cada vez que se crea o se borra una y cada vez
+// que cambian las ofertas de la lista de espera
+func (repository Cache) InvalidateHolds(ctx context.Context, hotelID string) error {
+	repository.delete(fmt.Sprintf(hotelHoldsKeyFormat, hotelID))
+	return nil
+}
+
 // GetAvailability verifica la disponibilidad de múltiples hoteles en caché
 func (repository Cache) GetAvailability(ctx context.Context, hotelIDs []string, checkIn, checkOut string) (map[string]bool, error) {
     // Verificar si todos los hoteles están en la caché
@@ -247,9 +481,7 @@ func (repository Cache) GetAvailability(ctx context.Context, hotelIDs []string,
     for i := 0; i < len(hotelIDs); i++ {
         r := <-results
         if r.err != nil {
-            // En caché, podemos continuar incluso si hay error en un hotel
-            availability[r.hotelID] = false
-            continue
+			return nil, fmt.Errorf("error checking availability for hotel %s in cache: %w", r.hotelID, r.err)
         }
         availability[r.hotelID] = r.available
     }
@@ -276,38 +508,70 @@ func (repository Cache) IsHotelAvailable(ctx context.Context, hotelID, checkIn,
     }
 
     // Obtener reservas de caché
-    key := fmt.Sprintf("reservations:hotel:%s", hotelID)
-    item := repository.client.Get(key)
-    if item == nil || item.Expired() {
-        // Si no hay datos en caché, asumimos que no hay disponibilidad
-        // Esta es una decisión conservadora para evitar overboking
-        return false, nil
+	value, err := repository.lookup(fmt.Sprintf(hotelReservationsKeyFormat, hotelID))
+	if err != nil {
+		// Sin las reservas en cache no se puede responder, se consulta a MongoDB
+		return false, fmt.Errorf("reservations of hotel %s not found or expired in cache", hotelID)
     }
 
-    reservations, ok := item.Value().([]hotelsDAO.Reservation)
+	reservations, ok := value.([]hotelsDAO.Reservation)
     if !ok {
         return false, fmt.Errorf("error converting cached reservations")
     }
 
-    // Contar reservas por día usando un mapa
-    reservationsByDay := make(map[time.Time]int)
-    for _, reservation := range reservations {
-        // Solo considerar reservas que se solapan con el período solicitado
-        if !reservation.CheckOut.Before(checkInTime) && !reservation.CheckIn.After(checkOutTime) {
-            for date := reservation.CheckIn; !date.After(reservation.CheckOut); date = date.AddDate(0, 0, 1) {
-                if !date.Before(checkInTime) && !date.After(checkOutTime) {
-                    reservationsByDay[date]++
+	// Las retenciones y ofertas vigentes ocupan sus habitaciones igual que en MongoDB, sin ellas tampoco se puede responder
+	value, err = repository.lookup(fmt.Sprintf(hotelHoldsKeyFormat, hotelID))
+	if err != nil {
+		return false, fmt.Errorf("holds of hotel %s not found or expired in cache", hotelID)
                 }
+	holds, ok := value.([]hotelsDAO.Hold)
+	if !ok {
+		return false, fmt.Errorf("error converting cached holds")
             }
+	// Se copia la lista para no modificar la que comparten otras lecturas de la cache
+	occupied := append([]hotelsDAO.Reservation(nil), reservations...)
+	now := time.Now().UTC()
+	for _, hold := range holds {
+		if hold.ExpiresAt.After(now) {
+			occupied = append(occupied, hold.AsReservation())
         }
     }
 
-    // Verificar disponibilidad para cada día
-    for date := checkInTime; !date.After(checkOutTime); date = date.AddDate(0, 0, 1) {
-        if reservationsByDay[date] >= hotel.AvaiableRooms {
-            return false, nil
-        }
+	// Misma regla que MongoDB: tiene que quedar una habitacion de algun tipo libre todas las noches de [check_in, check_out)
+	return availability.IsAvailable(availability.RoomCapacity(hotel), occupied, checkInTime, checkOutTime), nil
     }
 
-    return true, nil
-}
\ No newline at end of file
+// Obtiene de la cache el calendario de un hotel para el rango [from, to)
+func (repository Cache) GetCalendar(ctx context.Context, hotelID string, from, to time.Time) (hotelsDAO.Calendar, error) {
+	key := fmt.Sprintf(calendarKeyFormat, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	item := repository.calendars.Get(hotelID, key)
+	if item == nil {
+		return hotelsDAO.Calendar{}, fmt.Errorf("not found calendar %s for hotel %s", key, hotelID)
+}
+	if item.Expired() {
+		return hotelsDAO.Calendar{}, fmt.Errorf("calendar %s for hotel %s is expired", key, hotelID)
+	}
+	calendar, ok := item.Value().(hotelsDAO.Calendar)
+	if !ok {
+		return hotelsDAO.Calendar{}, fmt.Errorf("error converting calendar %s for hotel %s", key, hotelID)
+	}
+	return calendar, nil
+}
+
+// Guarda en la cache el calendario de un hotel
+func (repository Cache) SetCalendar(ctx context.Context, calendar hotelsDAO.Calendar) error {
+	key := fmt.Sprintf(calendarKeyFormat, calendar.From.Format("2006-01-02"), calendar.To.Format("2006-01-02"))
+	repository.calendars.Set(calendar.HotelID, key, calendar, repository.ttl(repository.duration))
+	return nil
+}
+
+// Elimina de la cache todos los calendarios de un hotel, sin importar el rango
+// Sin hotelID elimina los calendarios de todos los hoteles
+func (repository Cache) InvalidateCalendars(ctx context.Context, hotelID string) error {
+	if hotelID == "" {
+		repository.calendars.Clear()
+		return nil
+	}
+	repository.calendars.DeleteAll(hotelID)
+	return nil
+}
@@ -0,0 +1,226 @@
+package hotels
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	hotelsDAO "hotels-api/dao/hotels"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheIsHotelAvailable(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCache(CacheConfig{MaxSize: 100, ItemsToPrune: 10, Duration: time.Minute})
+	cache.Create(ctx, hotelsDAO.Hotel{ID: "hotel", AvaiableRooms: 1})
+	cache.SetReservationsByHotelID(ctx, "hotel", []hotelsDAO.Reservation{
+		{HotelID: "hotel", CheckIn: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), CheckOut: time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)},
+	})
+	cache.SetActiveHolds(ctx, "hotel", nil)
+
+	// La noche del check-out queda libre, igual que en MongoDB
+	available, err := cache.IsHotelAvailable(ctx, "hotel", "2025-03-03", "2025-03-05")
+	assert.NoError(t, err)
+	assert.True(t, available)
+
+	available, err = cache.IsHotelAvailable(ctx, "hotel", "2025-03-02", "2025-03-03")
+	assert.NoError(t, err)
+	assert.False(t, available)
+}
+
+func TestCacheIsHotelAvailableWithoutReservations(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCache(CacheConfig{MaxSize: 100, ItemsToPrune: 10, Duration: time.Minute})
+	cache.Create(ctx, hotelsDAO.Hotel{ID: "hotel", AvaiableRooms: 1})
+
+	// Sin las reservas en cache no responde, para que se consulte a MongoDB
+	_, err := cache.GetAvailability(ctx, []string{"hotel"}, "2025-03-01", "2025-03-02")
+	assert.Error(t, err)
+}
+
+func TestCacheGetReservationByID(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCache(CacheConfig{MaxSize: 100, ItemsToPrune: 10, Duration: time.Minute})
+
+	_, err := cache.GetReservationByID(ctx, "reservation")
+	assert.Error(t, err)
+
+	cache.CreateReservation(ctx, hotelsDAO.Reservation{ID: "reservation", HotelID: "hotel", UserID: "1"})
+	reservation, err := cache.GetReservationByID(ctx, "reservation")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", reservation.UserID)
+
+	// Al cancelarla se saca de la cache, la cancelada se lee de MongoDB
+	cache.CancelReservation(ctx, "reservation", hotelsDAO.Cancellation{})
+	_, err = cache.GetReservationByID(ctx, "reservation")
+	assert.Error(t, err)
+}
+
+func TestCacheReservationLists(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCache(CacheConfig{MaxSize: 100, ItemsToPrune: 10, Duration: time.Minute})
+	reservations := []hotelsDAO.Reservation{{ID: "reservation", HotelID: "hotel", UserID: "1"}}
+
+	// Hasta que se guardan las listas no hay nada en cache
+	_, err := cache.GetReservationsByHotelID(ctx, "hotel")
+	assert.Error(t, err)
+	_, err = cache.GetReservationsByUserID(ctx, "1")
+	assert.Error(t, err)
+	_, err = cache.GetReservationsByUserAndHotelID(ctx, "hotel", "1")
+	assert.Error(t, err)
+
+	cache.SetReservationsByHotelID(ctx, "hotel", reservations)
+	cache.SetReservationsByUserID(ctx, "1", reservations)
+	cache.SetReservationsByUserAndHotelID(ctx, "hotel", "1", reservations)
+	cache.SetReservationsByUserID(ctx, "2", []hotelsDAO.Reservation{})
+
+	byHotel, err := cache.GetReservationsByHotelID(ctx, "hotel")
+	assert.NoError(t, err)
+	assert.Equal(t, reservations, byHotel)
+	byUser, err := cache.GetReservationsByUserID(ctx, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, reservations, byUser)
+	byUserAndHotel, err := cache.GetReservationsByUserAndHotelID(ctx, "hotel", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, reservations, byUserAndHotel)
+
+	// Una reserva nueva del usuario 1 en el hotel invalida sus tres listas, pero no las de otros usuarios
+	cache.InvalidateReservations(ctx, "hotel", "1")
+	_, err = cache.GetReservationsByHotelID(ctx, "hotel")
+	assert.Error(t, err)
+	_, err = cache.GetReservationsByUserID(ctx, "1")
+	assert.Error(t, err)
+	_, err = cache.GetReservationsByUserAndHotelID(ctx, "hotel", "1")
+	assert.Error(t, err)
+	_, err = cache.GetReservationsByUserID(ctx, "2")
+	assert.NoError(t, err)
+}
+
+func TestCacheIsHotelAvailableWithHolds(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCache(CacheConfig{MaxSize: 100, ItemsToPrune: 10, Duration: time.Minute})
+	cache.Create(ctx, hotelsDAO.Hotel{ID: "hotel", AvaiableRooms: 1})
+	cache.SetReservationsByHotelID(ctx, "hotel", []hotelsDAO.Reservation{})
+
+	// Sin las retenciones en cache no responde, MongoDB las cuenta
+	_, err := cache.IsHotelAvailable(ctx, "hotel", "2025-03-01", "2025-03-02")
+	assert.Error(t, err)
+
+	hold := hotelsDAO.Hold{HotelID: "hotel", CheckIn: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), CheckOut: time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC), RoomCount: 1}
+	active, expired := hold, hold
+	active.ExpiresAt = time.Now().Add(time.Minute)
+	expired.ExpiresAt = time.Now().Add(-time.Minute)
+
+	// Una retencion vencida ya no ocupa la habitacion, aunque el barrido todavia no la haya borrado
+	cache.SetActiveHolds(ctx, "hotel", []hotelsDAO.Hold{expired})
+	available, err := cache.IsHotelAvailable(ctx, "hotel", "2025-03-01", "2025-03-02")
+	assert.NoError(t, err)
+	assert.True(t, available)
+
+	cache.SetActiveHolds(ctx, "hotel", []hotelsDAO.Hold{active})
+	available, err = cache.IsHotelAvailable(ctx, "hotel", "2025-03-01", "2025-03-02")
+	assert.NoError(t, err)
+	assert.False(t, available)
+
+	cache.InvalidateHolds(ctx, "hotel")
+	_, err = cache.IsHotelAvailable(ctx, "hotel", "2025-03-01", "2025-03-02")
+	assert.Error(t, err)
+}
+
+func TestCacheIsHotelAvailableWithWaitlistOffers(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCache(CacheConfig{MaxSize: 100, ItemsToPrune: 10, Duration: time.Minute})
+	cache.Create(ctx, hotelsDAO.Hotel{ID: "hotel", AvaiableRooms: 1})
+	cache.SetReservationsByHotelID(ctx, "hotel", []hotelsDAO.Reservation{})
+
+	// Una oferta vigente ocupa la habitacion igual que una retencion, hasta que vence
+	expiresAt := time.Now().Add(time.Minute)
+	offer := hotelsDAO.WaitlistEntry{
+		HotelID:        "hotel",
+		CheckIn:        time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		CheckOut:       time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC),
+		RoomType:       hotelsDAO.DefaultRoomType,
+		RoomCount:      1,
+		Status:         hotelsDAO.WaitlistOffered,
+		OfferExpiresAt: &expiresAt,
+	}
+	cache.SetActiveHolds(ctx, "hotel", []hotelsDAO.Hold{offer.AsHold()})
+	available, err := cache.IsHotelAvailable(ctx, "hotel", "2025-03-02", "2025-03-03")
+	assert.NoError(t, err)
+	assert.False(t, available)
+
+	expiredAt := time.Now().Add(-time.Minute)
+	offer.OfferExpiresAt = &expiredAt
+	cache.SetActiveHolds(ctx, "hotel", []hotelsDAO.Hold{offer.AsHold()})
+	available, err = cache.IsHotelAvailable(ctx, "hotel", "2025-03-02", "2025-03-03")
+	assert.NoError(t, err)
+	assert.True(t, available)
+}
+
+func TestCacheHotelNotFound(t *testing.T) {
+	ctx := context.Background()
+
+	// Sin NotFoundDuration no se recuerda la ausencia
+	cache := NewCache(CacheConfig{MaxSize: 100, ItemsToPrune: 10, Duration: time.Minute})
+	cache.SetHotelNotFound(ctx, "hotel")
+	_, err := cache.GetHotelByID(ctx, "hotel")
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, hotelsDAO.ErrNotFound))
+
+	cache = NewCache(CacheConfig{MaxSize: 100, ItemsToPrune: 10, Duration: time.Minute, NotFoundDuration: time.Minute})
+	cache.SetHotelNotFound(ctx, "hotel")
+	_, err = cache.GetHotelByID(ctx, "hotel")
+	assert.True(t, errors.Is(err, hotelsDAO.ErrNotFound))
+
+	metrics, err := cache.Metrics(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), metrics.NotFoundHits)
+}
+
+func TestCacheMetrics(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCache(CacheConfig{MaxSize: 2, ItemsToPrune: 1, Duration: time.Minute})
+
+	cache.GetHotelByID(ctx, "hotel")
+	cache.Create(ctx, hotelsDAO.Hotel{ID: "hotel"})
+	cache.GetHotelByID(ctx, "hotel")
+	cache.GetHotelByID(ctx, "hotel")
+	metrics, err := cache.Metrics(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), metrics.Hits)
+	assert.Equal(t, int64(1), metrics.Misses)
+
+	// Borrar o reemplazar una entrada no es un desalojo
+	cache.Update(ctx, hotelsDAO.Hotel{ID: "hotel", Name: "Hotel"})
+	cache.Delete(ctx, "hotel")
+	time.Sleep(10 * time.Millisecond)
+	metrics, _ = cache.Metrics(ctx)
+	assert.Equal(t, int64(0), metrics.Evictions)
+
+	// Con lugar para dos entradas, la tercera hace que ccache saque la mas vieja
+	cache.Create(ctx, hotelsDAO.Hotel{ID: "a"})
+	cache.Create(ctx, hotelsDAO.Hotel{ID: "b"})
+	cache.Create(ctx, hotelsDAO.Hotel{ID: "c"})
+	assert.Eventually(t, func() bool {
+		metrics, _ := cache.Metrics(ctx)
+		return metrics.Evictions == 1
+	}, time.Second, time.Millisecond)
+}
+
+func TestCacheTTLJitter(t *testing.T) {
+	cache := NewCache(CacheConfig{MaxSize: 100, ItemsToPrune: 10, Duration: time.Minute, Jitter: 0.1})
+	different := false
+	for i := 0; i < 100; i++ {
+		ttl := cache.ttl(time.Minute)
+		assert.GreaterOrEqual(t, ttl, 54*time.Second)
+		assert.LessOrEqual(t, ttl, 66*time.Second)
+		different = different || ttl != time.Minute
+	}
+	assert.True(t, different)
+
+	// Sin jitter la duracion no cambia
+	cache = NewCache(CacheConfig{MaxSize: 100, ItemsToPrune: 10, Duration: time.Minute})
+	assert.Equal(t, time.Minute, cache.ttl(time.Minute))
+}
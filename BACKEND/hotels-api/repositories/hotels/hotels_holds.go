@@ -0,0 +1,134 @@
+package hotels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	hotelsDAO "hotels-api/dao/hotels"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Funcion para crear una retencion de habitaciones en MongoDB
+func (repository Mongo) CreateHold(ctx context.Context, hold hotelsDAO.Hold) (string, error) {
+	result, err := repository.client.Database(repository.database).Collection(repository.collection_holds).InsertOne(ctx, hold)
+	if err != nil {
+		return "", fmt.Errorf("error creating document: %w", err)
+	}
+
+	// Saca el ObjectID del resultado de la insercion
+	objectID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return "", fmt.Errorf("error converting mongo ID to object ID")
+	}
+	return objectID.Hex(), nil
+}
+
+// Funcion para obtener una retencion por su ID de MongoDB, aunque este vencida
+func (repository Mongo) GetHoldByID(ctx context.Context, id string) (hotelsDAO.Hold, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return hotelsDAO.Hold{}, fmt.Errorf("error converting id to mongo ID (%v): %w", err, hotelsDAO.ErrNotFound)
+	}
+
+	var hold hotelsDAO.Hold
+	err = repository.client.Database(repository.database).Collection(repository.collection_holds).FindOne(ctx, bson.M{"_id": objectID}).Decode(&hold)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return hotelsDAO.Hold{}, fmt.Errorf("hold with ID %s: %w", id, hotelsDAO.ErrNotFound)
+	}
+	if err != nil {
+		return hotelsDAO.Hold{}, fmt.Errorf("error finding document: %w", err)
+	}
+	return hold, nil
+}
+
+// Funcion para obtener las retenciones de un hotel que siguen vigentes en now
+func (repository Mongo) GetActiveHolds(ctx context.Context, hotelID string, now time.Time) ([]hotelsDAO.Hold, error) {
+	holds, err := repository.activeHolds(ctx, []string{hotelID}, now)
+	if err != nil {
+		return nil, err
+	}
+	return holds[hotelID], nil
+}
+
+// Funcion para eliminar una retencion de MongoDB
+func (repository Mongo) DeleteHold(ctx context.Context, id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("error converting id to mongo ID (%v): %w", err, hotelsDAO.ErrNotFound)
+	}
+	result, err := repository.client.Database(repository.database).Collection(repository.collection_holds).DeleteOne(ctx, bson.M{"_id": objectID})
+	if err != nil {
+		return fmt.Errorf("error deleting document: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return fmt.Errorf("hold with ID %s: %w", id, hotelsDAO.ErrNotFound)
+	}
+	return nil
+}
+
+// Funcion para reclamar una retencion que sigue vigente en now, eliminandola
+// Devuelve false si ya vencio o si otra peticion la reclamo o la libero antes
+func (repository Mongo) ClaimHold(ctx context.Context, id string, now time.Time) (bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, fmt.Errorf("error converting id to mongo ID (%v): %w", err, hotelsDAO.ErrNotFound)
+	}
+	filter := bson.M{"_id": objectID, "expires_at": bson.M{"$gt": now}}
+	result, err := repository.client.Database(repository.database).Collection(repository.collection_holds).DeleteOne(ctx, filter)
+	if err != nil {
+		return false, fmt.Errorf("error deleting document: %w", err)
+	}
+	return result.DeletedCount == 1, nil
+}
+
+// Funcion para eliminar las retenciones vencidas en now
+// Devuelve los hoteles que tenian retenciones vencidas, que ahora tienen lugar libre
+func (repository Mongo) DeleteExpiredHolds(ctx context.Context, now time.Time) ([]string, error) {
+	collection := repository.client.Database(repository.database).Collection(repository.collection_holds)
+	filter := bson.M{"expires_at": bson.M{"$lte": now}}
+
+	hotelIDs, err := collection.Distinct(ctx, "hotel_id", filter)
+	if err != nil {
+		return nil, fmt.Errorf("error finding expired holds: %w", err)
+	}
+	if len(hotelIDs) == 0 {
+		return nil, nil
+	}
+	if _, err := collection.DeleteMany(ctx, filter); err != nil {
+		return nil, fmt.Errorf("error deleting expired holds: %w", err)
+	}
+
+	released := make([]string, 0, len(hotelIDs))
+	for _, hotelID := range hotelIDs {
+		if id, ok := hotelID.(string); ok {
+			released = append(released, id)
+		}
+	}
+	return released, nil
+}
+
+// Funcion que obtiene, agrupadas por hotel, las retenciones vigentes en now
+func (repository Mongo) activeHolds(ctx context.Context, hotelIDs []string, now time.Time) (map[string][]hotelsDAO.Hold, error) {
+	filter := bson.M{
+		"hotel_id":   bson.M{"$in": hotelIDs},
+		"expires_at": bson.M{"$gt": now},
+	}
+	cursor, err := repository.client.Database(repository.database).Collection(repository.collection_holds).Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("error finding holds: %w", err)
+	}
+	var records []hotelsDAO.Hold
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("error decoding holds: %w", err)
+	}
+
+	holds := make(map[string][]hotelsDAO.Hold)
+	for _, hold := range records {
+		holds[hold.HotelID] = append(holds[hold.HotelID], hold)
+	}
+	return holds, nil
+}
@@ -0,0 +1,51 @@
+package hotels
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Funcion que intenta tomar el lock name para owner hasta now + lease
+// Solo lo toma si nadie lo tiene o si el que lo tenia lo dejo vencer; devuelve false si lo tiene otro
+func (repository Mongo) AcquireLock(ctx context.Context, name, owner string, now time.Time, lease time.Duration) (bool, error) {
+	// Si el lock existe y no vencio el filtro no lo encuentra, el upsert intenta insertar otro con el mismo _id y falla
+	filter := bson.M{"_id": name, "expires_at": bson.M{"$lte": now}}
+	update := bson.M{"$set": bson.M{"owner": owner, "expires_at": now.Add(lease)}}
+	_, err := repository.client.Database(repository.database).Collection(repository.collection_locks).
+		UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("error acquiring lock %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// Funcion que extiende el lock name hasta now + lease si todavia lo tiene owner
+// Devuelve false si owner lo perdio (vencio y lo tomo otro, o ya no existe)
+func (repository Mongo) RenewLock(ctx context.Context, name, owner string, now time.Time, lease time.Duration) (bool, error) {
+	filter := bson.M{"_id": name, "owner": owner}
+	update := bson.M{"$set": bson.M{"expires_at": now.Add(lease)}}
+	result, err := repository.client.Database(repository.database).Collection(repository.collection_locks).
+		UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("error renewing lock %s: %w", name, err)
+	}
+	return result.MatchedCount == 1, nil
+}
+
+// Funcion que libera el lock name si todavia lo tiene owner
+func (repository Mongo) ReleaseLock(ctx context.Context, name, owner string) error {
+	_, err := repository.client.Database(repository.database).Collection(repository.collection_locks).
+		DeleteOne(ctx, bson.M{"_id": name, "owner": owner})
+	if err != nil {
+		return fmt.Errorf("error releasing lock %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package hotels
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	hotelsDAO "hotels-api/dao/hotels"
+	"time"
+
+	"github.com/bradfitz/gomemcache/memcache"
+)
+
+type MemcachedConfig struct {
+	Host     string
+	Port     string
+	Duration time.Duration
+}
+
+// Cache compartida entre las replicas de hotels-api
+// El servicio la usa para los hoteles, entre la cache local de cada replica y MongoDB. Las listas de reservas y la
+// disponibilidad no se guardan aca: cambian con cada reserva y se calculan mejor desde la cache local o MongoDB
+type Memcached struct {
+	client   *memcache.Client
+	duration time.Duration
+}
+
+// Crea una nueva instancia de Memcached
+func NewMemcached(config MemcachedConfig) Memcached {
+	client := memcache.New(fmt.Sprintf("%s:%s", config.Host, config.Port))
+	return Memcached{
+		client:   client,
+		duration: config.Duration,
+	}
+}
+
+// Obtiene un hotel por su ID de Memcached
+func (repository Memcached) GetHotelByID(ctx context.Context, id string) (hotelsDAO.Hotel, error) {
+	var hotel hotelsDAO.Hotel
+	if err := repository.get(fmt.Sprintf(keyFormat, id), &hotel); err != nil {
+		return hotelsDAO.Hotel{}, err
+	}
+	return hotel, nil
+}
+
+// Guarda un hotel en Memcached
+func (repository Memcached) Create(ctx context.Context, hotel hotelsDAO.Hotel) (string, error) {
+	if err := repository.set(fmt.Sprintf(keyFormat, hotel.ID), hotel, 0); err != nil {
+		return "", err
+	}
+	return hotel.ID, nil
+}
+
+// Guarda un hotel en Memcached por ttl, cero usa la duracion de la configuracion
+func (repository Memcached) SetHotel(ctx context.Context, hotel hotelsDAO.Hotel, ttl time.Duration) error {
+	return repository.set(fmt.Sprintf(keyFormat, hotel.ID), hotel, ttl)
+}
+
+// Actualizar solo algunos campos obligaria a leer y volver a escribir el hotel, y otra replica podria escribir en el
+// medio; se borra y la proxima lectura lo trae completo de MongoDB
+func (repository Memcached) Update(ctx context.Context, hotel hotelsDAO.Hotel) error {
+	return repository.delete(fmt.Sprintf(keyFormat, hotel.ID))
+}
+
+// Elimina un hotel de Memcached
+func (repository Memcached) Delete(ctx context.Context, id string) error {
+	return repository.delete(fmt.Sprintf(keyFormat, id))
+}
+
+// Guarda una reserva en Memcached
+func (repository Memcached) CreateReservation(ctx context.Context, reservation hotelsDAO.Reservation) (string, error) {
+	if err := repository.set(fmt.Sprintf(reservationKeyFormat, reservation.ID), reservation, 0); err != nil {
+		return "", err
+	}
+	return reservation.ID, nil
+}
+
+// Reemplaza una reserva modificada en Memcached
+func (repository Memcached) UpdateReservation(ctx context.Context, reservation hotelsDAO.Reservation) error {
+	return repository.set(fmt.Sprintf(reservationKeyFormat, reservation.ID), reservation, 0)
+}
+
+// Elimina una reserva cancelada de Memcached, la cancelada se vuelve a leer de la base principal
+func (repository Memcached) CancelReservation(ctx context.Context, id string, cancellation hotelsDAO.Cancellation) error {
+	return repository.delete(fmt.Sprintf(reservationKeyFormat, id))
+}
+
+// Elimina la reserva de Memcached, igual que al cancelarla entera
+func (repository Memcached) CancelReservationRoom(ctx context.Context, id string, roomID string, penalty float64) (hotelsDAO.Reservation, error) {
+	return hotelsDAO.Reservation{}, repository.delete(fmt.Sprintf(reservationKeyFormat, id))
+}
+
+// Obtiene una reserva por su ID de Memcached
+func (repository Memcached) GetReservationByID(ctx context.Context, id string) (hotelsDAO.Reservation, error) {
+	var reservation hotelsDAO.Reservation
+	if err := repository.get(fmt.Sprintf(reservationKeyFormat, id), &reservation); err != nil {
+		return hotelsDAO.Reservation{}, err
+	}
+	return reservation, nil
+}
+
+func (repository Memcached) GetReservationsByHotelID(ctx context.Context, hotelID string) ([]hotelsDAO.Reservation, error) {
+	return nil, fmt.Errorf("GetReservationsByHotelID not supported in Memcached")
+}
+
+func (repository Memcached) GetReservationsByUserAndHotelID(ctx context.Context, hotelID string, userID string) ([]hotelsDAO.Reservation, error) {
+	return nil, fmt.Errorf("GetReservationsByUserAndHotelID not supported in Memcached")
+}
+
+func (repository Memcached) GetReservationsByUserID(ctx context.Context, userID string) ([]hotelsDAO.Reservation, error) {
+	return nil, fmt.Errorf("GetReservationsByUserID not supported in Memcached")
+}
+
+func (repository Memcached) GetAvailability(ctx context.Context, hotelIDs []string, checkIn, checkOut string) (map[string]bool, error) {
+	return nil, fmt.Errorf("GetAvailability not supported in Memcached")
+}
+
+// Funcion que lee una clave y decodifica su valor en value
+func (repository Memcached) get(key string, value interface{}) error {
+	item, err := repository.client.Get(key)
+	if errors.Is(err, memcache.ErrCacheMiss) {
+		return fmt.Errorf("not found item with key %s", key)
+	}
+	if err != nil {
+		return fmt.Errorf("error fetching %s from memcached: %w", key, err)
+	}
+	if err := json.Unmarshal(item.Value, value); err != nil {
+		return fmt.Errorf("error unmarshaling %s: %w", key, err)
+	}
+	return nil
+}
+
+// Funcion que guarda value en una clave por ttl, cero usa la duracion de la configuracion
+func (repository Memcached) set(key string, value interface{}, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = repository.duration
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("error marshaling %s: %w", key, err)
+	}
+	item := &memcache.Item{Key: key, Value: data, Expiration: int32(ttl / time.Second)}
+	if err := repository.client.Set(item); err != nil {
+		return fmt.Errorf("error storing %s in memcached: %w", key, err)
+	}
+	return nil
+}
+
+// Funcion que borra una clave; que no exista no es un error, el resultado es el mismo
+func (repository Memcached) delete(key string) error {
+	if err := repository.client.Delete(key); err != nil && !errors.Is(err, memcache.ErrCacheMiss) {
+		return fmt.Errorf("error deleting %s from memcached: %w", key, err)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package hotels
+
+import (
+	"context"
+	"fmt"
+	hotelsDAO "hotels-api/dao/hotels"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Funcion que completa con valores por defecto los campos que se agregaron a las reservas despues de crearlas
+// Cada campo solo se escribe en los documentos que no lo tienen, asi que se puede correr en cada arranque
+func (repository Mongo) MigrateReservations(ctx context.Context) error {
+	collection := repository.client.Database(repository.database).Collection(repository.collection_reservation)
+
+	defaults := bson.D{
+		{Key: "guests", Value: 1},
+		{Key: "room_count", Value: 1},
+		{Key: "total_price", Value: 0.0},
+		{Key: "currency", Value: "USD"},
+		{Key: "special_requests", Value: ""},
+		{Key: "contact_name", Value: ""},
+		{Key: "contact_email", Value: ""},
+		{Key: "contact_phone", Value: ""},
+		{Key: "status", Value: hotelsDAO.ReservationConfirmed},
+		{Key: "version", Value: int64(0)},
+	}
+	for _, field := range defaults {
+		filter := bson.M{field.Key: bson.M{"$exists": false}}
+		if _, err := collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{field.Key: field.Value}}); err != nil {
+			return fmt.Errorf("error backfilling reservations field %s: %w", field.Key, err)
+		}
+	}
+
+	// La fecha de creacion se saca del ObjectID, que guarda el momento en que se inserto el documento
+	filter := bson.M{"created_at": bson.M{"$exists": false}}
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{"created_at": bson.M{"$toDate": "$_id"}}}}}
+	if _, err := collection.UpdateMany(ctx, filter, update); err != nil {
+		return fmt.Errorf("error backfilling reservations field created_at: %w", err)
+	}
+
+	// Las reservas anteriores a los tipos de habitacion pasan a tener una unica linea del tipo por defecto
+	// con todos sus huespedes y su precio
+	filter = bson.M{"rooms": bson.M{"$exists": false}}
+	update = mongo.Pipeline{{{Key: "$set", Value: bson.M{"rooms": bson.A{bson.M{
+		"id":        bson.M{"$toString": "$_id"},
+		"room_type": hotelsDAO.DefaultRoomType,
+		"guests":    "$guests",
+		"price":     "$total_price",
+		"cancelled": false,
+	}}}}}}
+	if _, err := collection.UpdateMany(ctx, filter, update); err != nil {
+		return fmt.Errorf("error backfilling reservations field rooms: %w", err)
+	}
+
+	return nil
+}
@@ -2,8 +2,10 @@ package hotels
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	hotelsDAO "hotels-api/dao/hotels"
+	"hotels-api/services/availability"
 	"log"
 	"time"
 
@@ -21,6 +23,11 @@ type MongoConfig struct {
 	Database                string
 	Collection_hotels       string
 	Collection_reservations string
+	Collection_waitlist     string
+	Collection_holds        string
+	Collection_payments     string
+	Collection_idempotency  string
+	Collection_locks        string
 }
 
 type Mongo struct {
@@ -28,6 +35,11 @@ type Mongo struct {
 	database               string
 	collection_hotel       string
 	collection_reservation string
+	collection_waitlist    string
+	collection_holds       string
+	collection_payments    string
+	collection_idempotency string
+	collection_locks       string
 }
 
 const (
@@ -54,11 +66,69 @@ func NewMongo(config MongoConfig) Mongo {
 		log.Panicf("error connecting to mongo DB: %v", err)
 	}
 
+	// Indice geoespacial para las consultas por cercania sobre la ubicacion de los hoteles
+	// Los hoteles sin ubicacion no tienen el campo y el indice los ignora
+	_, err = client.Database(config.Database).Collection(config.Collection_hotels).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "location", Value: "2dsphere"}},
+	})
+	if err != nil {
+		log.Printf("error creating 2dsphere index on hotels: %v", err)
+	}
+
+	// Indice para buscar las reservas de un hotel que se solapan con un rango de fechas
+	_, err = client.Database(config.Database).Collection(config.Collection_reservations).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "hotel_id", Value: 1}, {Key: "check_in", Value: 1}, {Key: "check_out", Value: 1}},
+	})
+	if err != nil {
+		log.Printf("error creating availability index on reservations: %v", err)
+	}
+
+	// Indice para recorrer la lista de espera de un hotel por orden de llegada
+	_, err = client.Database(config.Database).Collection(config.Collection_waitlist).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "hotel_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: 1}},
+	})
+	if err != nil {
+		log.Printf("error creating index on waitlist: %v", err)
+	}
+
+	// Las retenciones vencidas las libera el servicio; el indice TTL borra las que hayan quedado
+	// una hora despues de vencer, por si el servicio estuvo caido
+	_, err = client.Database(config.Database).Collection(config.Collection_holds).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(3600)},
+		{Keys: bson.D{{Key: "hotel_id", Value: 1}, {Key: "expires_at", Value: 1}}},
+	})
+	if err != nil {
+		log.Printf("error creating indexes on holds: %v", err)
+	}
+
+	// Hay un solo pago por reserva, y los webhooks del procesador buscan el pago por su autorizacion
+	_, err = client.Database(config.Database).Collection(config.Collection_payments).Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "reservation_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "authorization_id", Value: 1}}},
+	})
+	if err != nil {
+		log.Printf("error creating indexes on payments: %v", err)
+	}
+
+	// Las claves de idempotencia se guardan un dia, despues la misma clave cuenta como una peticion nueva
+	_, err = client.Database(config.Database).Collection(config.Collection_idempotency).Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "created_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(24 * 3600),
+	})
+	if err != nil {
+		log.Printf("error creating index on idempotency keys: %v", err)
+	}
+
 	return Mongo{
 		client:                 client,
 		database:               config.Database,
 		collection_hotel:       config.Collection_hotels,
 		collection_reservation: config.Collection_reservations,
+		collection_waitlist:    config.Collection_waitlist,
+		collection_holds:       config.Collection_holds,
+		collection_payments:    config.Collection_payments,
+		collection_idempotency: config.Collection_idempotency,
+		collection_locks:       config.Collection_locks,
 	}
 }
 
@@ -66,13 +136,17 @@ func NewMongo(config MongoConfig) Mongo {
 func (repository Mongo) GetHotelByID(ctx context.Context, id string) (hotelsDAO.Hotel, error) {
 
 	//Crea el ObjectID de MongoDB a partir del ID para buscar el documento
+	// Un ID que no es un ObjectID no puede corresponder a ningun hotel
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return hotelsDAO.Hotel{}, fmt.Errorf("error converting id to mongo ID: %w", err)
+		return hotelsDAO.Hotel{}, fmt.Errorf("error converting id to mongo ID (%v): %w", err, hotelsDAO.ErrNotFound)
 	}
 
 	// Buscar el documento en MongoDB por su ID
 	result := repository.client.Database(repository.database).Collection(repository.collection_hotel).FindOne(ctx, bson.M{"_id": objectID})
+	if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+		return hotelsDAO.Hotel{}, fmt.Errorf("hotel with ID %s: %w", id, hotelsDAO.ErrNotFound)
+	}
 	if result.Err() != nil {
 		return hotelsDAO.Hotel{}, fmt.Errorf("error finding document: %w", result.Err())
 	}
@@ -160,6 +234,25 @@ func (repository Mongo) Update(ctx context.Context, hotel hotelsDAO.Hotel) error
 	if len(hotel.Images) > 0 { // Asumiendo que un slice vacio es el valor por defecto para Images
 		update["images"] = hotel.Images
 	}
+	if hotel.Location != nil {
+		update["location"] = hotel.Location
+	}
+	if hotel.Pricing != nil {
+		update["pricing"] = hotel.Pricing
+	}
+	if hotel.MaxGuestsPerRoom != 0 {
+		update["max_guests_per_room"] = hotel.MaxGuestsPerRoom
+	}
+	if len(hotel.RoomTypes) > 0 {
+		update["room_types"] = hotel.RoomTypes
+	}
+	if hotel.CancellationPolicy != nil {
+		update["cancellation_policy"] = hotel.CancellationPolicy
+	}
+	if hotel.Version != 0 {
+		update["version"] = hotel.Version
+		update["updated_at"] = hotel.UpdatedAt
+	}
 
 	// Actualiza el documento en MongoDB
 	if len(update) == 0 {
@@ -219,7 +312,35 @@ func (repository Mongo) CreateReservation(ctx context.Context, reservation hotel
 }
 
 // Funcion para cancelar una reserva en MongoDB
-func (repository Mongo) CancelReservation(ctx context.Context, id string) error {
+// La reserva queda guardada con su cancelacion, y el precio pasa a ser lo que se cobra: el total menos el reembolso
+func (repository Mongo) CancelReservation(ctx context.Context, id string, cancellation hotelsDAO.Cancellation) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return fmt.Errorf("error converting id to mongo ID (%v): %w", err, hotelsDAO.ErrNotFound)
+	}
+
+	// El filtro pide que no este cancelada, asi dos cancelaciones simultaneas no descuentan el reembolso dos veces
+	filter := bson.M{"_id": objectID, "status": bson.M{"$ne": hotelsDAO.ReservationCancelled}}
+	update := bson.M{
+		"$set": bson.M{
+			"status":       hotelsDAO.ReservationCancelled,
+			"cancellation": cancellation,
+		},
+		"$inc": bson.M{"total_price": -cancellation.Refund, "version": 1},
+	}
+	result, err := repository.client.Database(repository.database).Collection(repository.collection_reservation).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("error updating document: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("active reservation with ID %s: %w", id, hotelsDAO.ErrNotFound)
+	}
+
+	return nil
+}
+
+// Funcion para eliminar una reserva de MongoDB, solo para deshacer una reserva que no se pudo terminar de crear
+func (repository Mongo) DeleteReservation(ctx context.Context, id string) error {
 	// Convert reservation ID to MongoDB ObjectID
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -239,6 +360,131 @@ func (repository Mongo) CancelReservation(ctx context.Context, id string) error
 	return nil
 }
 
+// Funcion para guardar las fechas, las habitaciones y el precio de una reserva modificada en MongoDB
+// Una reserva cancelada no se puede modificar, y solo se guarda si nadie la modifico desde que se leyo (misma version):
+// si se cancelo una habitacion mientras tanto devuelve hotelsDAO.ErrConflict, para no pisar la cancelacion
+func (repository Mongo) UpdateReservation(ctx context.Context, reservation hotelsDAO.Reservation) error {
+	objectID, err := primitive.ObjectIDFromHex(reservation.ID)
+	if err != nil {
+		return fmt.Errorf("error converting id to mongo ID (%v): %w", err, hotelsDAO.ErrNotFound)
+	}
+	collection := repository.client.Database(repository.database).Collection(repository.collection_reservation)
+	filter := bson.M{"_id": objectID, "status": bson.M{"$ne": hotelsDAO.ReservationCancelled}, "version": reservation.Version}
+	update := bson.M{
+		"$set": bson.M{
+			"check_in":    reservation.CheckIn,
+			"check_out":   reservation.CheckOut,
+			"rooms":       reservation.Rooms,
+			"total_price": reservation.TotalPrice,
+		},
+		"$inc": bson.M{"version": 1},
+	}
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("error updating document: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		// Si sigue activa es que cambio la version
+		active, err := collection.CountDocuments(ctx, bson.M{"_id": objectID, "status": bson.M{"$ne": hotelsDAO.ReservationCancelled}})
+		if err != nil {
+			return fmt.Errorf("error finding document: %w", err)
+		}
+		if active > 0 {
+			return fmt.Errorf("reservation with ID %s: %w", reservation.ID, hotelsDAO.ErrConflict)
+		}
+		return fmt.Errorf("active reservation with ID %s: %w", reservation.ID, hotelsDAO.ErrNotFound)
+	}
+	return nil
+}
+
+// Funcion para obtener una reserva por su ID de MongoDB
+func (repository Mongo) GetReservationByID(ctx context.Context, id string) (hotelsDAO.Reservation, error) {
+	// Un ID que no es un ObjectID no puede corresponder a ninguna reserva
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return hotelsDAO.Reservation{}, fmt.Errorf("error converting id to mongo ID (%v): %w", err, hotelsDAO.ErrNotFound)
+	}
+
+	// Buscar el documento en MongoDB por su ID
+	result := repository.client.Database(repository.database).Collection(repository.collection_reservation).FindOne(ctx, bson.M{"_id": objectID})
+	if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+		return hotelsDAO.Reservation{}, fmt.Errorf("reservation with ID %s: %w", id, hotelsDAO.ErrNotFound)
+	}
+	if result.Err() != nil {
+		return hotelsDAO.Reservation{}, fmt.Errorf("error finding document: %w", result.Err())
+	}
+
+	// Decodificar el resultado
+	var reservation hotelsDAO.Reservation
+	if err := result.Decode(&reservation); err != nil {
+		return hotelsDAO.Reservation{}, fmt.Errorf("error decoding result: %w", err)
+	}
+	return reservation, nil
+}
+
+// Funcion para cancelar una habitacion de una reserva en MongoDB
+// Marca la habitacion como cancelada y descuenta sus huespedes y su precio de la reserva en una sola actualizacion
+func (repository Mongo) CancelReservationRoom(ctx context.Context, id string, roomID string, penalty float64) (hotelsDAO.Reservation, error) {
+	// Convert reservation ID to MongoDB ObjectID
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return hotelsDAO.Reservation{}, fmt.Errorf("error converting id to mongo ID (%v): %w", err, hotelsDAO.ErrNotFound)
+	}
+	collection := repository.client.Database(repository.database).Collection(repository.collection_reservation)
+
+	// Busca la reserva para saber cuantos huespedes y cuanto dinero tiene la habitacion
+	var reservation hotelsDAO.Reservation
+	err = collection.FindOne(ctx, bson.M{"_id": objectID}).Decode(&reservation)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return hotelsDAO.Reservation{}, fmt.Errorf("reservation with ID %s: %w", id, hotelsDAO.ErrNotFound)
+	}
+	if err != nil {
+		return hotelsDAO.Reservation{}, fmt.Errorf("error finding document: %w", err)
+	}
+	var room *hotelsDAO.ReservationRoom
+	for i := range reservation.Rooms {
+		if reservation.Rooms[i].ID == roomID && !reservation.Rooms[i].Cancelled {
+			room = &reservation.Rooms[i]
+		}
+	}
+	if room == nil {
+		return hotelsDAO.Reservation{}, fmt.Errorf("room %s in reservation %s: %w", roomID, id, hotelsDAO.ErrNotFound)
+	}
+
+	// El filtro vuelve a pedir que la habitacion este activa, asi dos cancelaciones simultaneas no la descuentan dos veces
+	filter := bson.M{
+		"_id":   objectID,
+		"rooms": bson.M{"$elemMatch": bson.M{"id": roomID, "cancelled": false}},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"rooms.$.cancelled":    true,
+			"rooms.$.cancelled_at": time.Now().UTC(),
+			"rooms.$.penalty":      penalty,
+		},
+		"$inc": bson.M{
+			"room_count":  -1,
+			"guests":      -room.Guests,
+			"total_price": -(room.Price - penalty),
+			"version":     1,
+		},
+	}
+	result := collection.FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+		return hotelsDAO.Reservation{}, fmt.Errorf("room %s in reservation %s: %w", roomID, id, hotelsDAO.ErrNotFound)
+	}
+	if result.Err() != nil {
+		return hotelsDAO.Reservation{}, fmt.Errorf("error updating document: %w", result.Err())
+	}
+
+	// Decodificar la reserva actualizada
+	var updated hotelsDAO.Reservation
+	if err := result.Decode(&updated); err != nil {
+		return hotelsDAO.Reservation{}, fmt.Errorf("error decoding result: %w", err)
+	}
+	return updated, nil
+}
+
 // Funcion para encontrar todas las reservas de un usuario en MongoDB
 func (repository Mongo) GetReservationsByUserID(ctx context.Context, userID string) ([]hotelsDAO.Reservation, error) {
 	// Buscar el documento en MongoDB por su ID
@@ -287,156 +533,124 @@ func (repository Mongo) GetReservationsByUserAndHotelID(ctx context.Context, hot
 	return reservations, nil
 }
 
-// Funcion para calcular la dispinibilidad de multiples hoteles de forma concurrente utilizando goroutines
-// GetAvailability verifica la disponibilidad de múltiples hoteles de forma concurrente
+// Funcion para calcular la disponibilidad de multiples hoteles
+// Hace siempre cuatro consultas, sin importar cuantos hoteles ni cuantos dias: la capacidad de los hoteles,
+// las reservas que se solapan con el rango, las retenciones vigentes y las ofertas vigentes de la lista de espera;
+// la ocupacion por noche se calcula en memoria
 func (repository Mongo) GetAvailability(ctx context.Context, hotelIDs []string, checkIn, checkOut string) (map[string]bool, error) {
-	type result struct {
-		hotelID   string
-		available bool
-		err       error
-	}
-
-	results := make(chan result, len(hotelIDs))
-
-	// Crear un WaitGroup para esperar a que todas las goroutines terminen
-	for _, id := range hotelIDs {
-		go func(hotelID string) {
-			available, err := repository.IsHotelAvailable(ctx, hotelID, checkIn, checkOut)
-			results <- result{
-				hotelID:   hotelID,
-				available: available,
-				err:       err,
-			}
-		}(id)
+	// Convertir las fechas
+	checkInTime, err := time.Parse("2006-01-02", checkIn)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing check-in date: %w", err)
+	}
+	checkOutTime, err := time.Parse("2006-01-02", checkOut)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing check-out date: %w", err)
 	}
 
-	// Recolectar resultados
-	availability := make(map[string]bool)
-	for i := 0; i < len(hotelIDs); i++ {
-		r := <-results
-		if r.err != nil {
-			return nil, fmt.Errorf("error checking availability for hotel %s: %w", r.hotelID, r.err)
+	// Convertir los IDs de los hoteles a ObjectID
+	objectIDs := make([]primitive.ObjectID, 0, len(hotelIDs))
+	for _, hotelID := range hotelIDs {
+		objectID, err := primitive.ObjectIDFromHex(hotelID)
+		if err != nil {
+			return nil, fmt.Errorf("error converting hotel ID %s to object ID: %w", hotelID, err)
 		}
-		availability[r.hotelID] = r.available
+		objectIDs = append(objectIDs, objectID)
 	}
 
-	return availability, nil
-}
+	// Obtener en una sola consulta cuantas habitaciones de cada tipo tienen todos los hoteles
+	cursor, err := repository.client.Database(repository.database).Collection(repository.collection_hotel).
+		Find(ctx, bson.M{"_id": bson.M{"$in": objectIDs}}, options.Find().SetProjection(bson.M{"avaiable_rooms": 1, "room_types": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("error finding hotels: %w", err)
+	}
+	var hotels []hotelsDAO.Hotel
+	if err := cursor.All(ctx, &hotels); err != nil {
+		return nil, fmt.Errorf("error decoding hotels: %w", err)
+	}
+	capacity := make(map[string]map[string]int, len(hotels))
+	for _, hotel := range hotels {
+		capacity[hotel.ID] = availability.RoomCapacity(hotel)
+	}
+	for _, hotelID := range hotelIDs {
+		if _, ok := capacity[hotelID]; !ok {
+			return nil, fmt.Errorf("error finding hotel %s: %w", hotelID, hotelsDAO.ErrNotFound)
+		}
+	}
 
-// IsHotelAvailable verifica la disponibilidad de un hotel para un rango de fechas
-func (repository Mongo) IsHotelAvailable(ctx context.Context, hotelID, checkIn, checkOut string) (bool, error) {
-	// Convertir el ID del hotel a ObjectID
-	objectID, err := primitive.ObjectIDFromHex(hotelID)
+	// Obtener en una sola consulta las reservas de todos los hoteles que ocupan alguna noche del rango
+	reservations, err := repository.reservationsInRange(ctx, hotelIDs, checkInTime, checkOutTime)
 	if err != nil {
-		return false, fmt.Errorf("error converting hotel ID to object ID: %w", err)
+		return nil, err
 	}
 
-	// Convertir las fechas
-	checkInTime, err := time.Parse("2006-01-02", checkIn)
+	// Las retenciones y las ofertas de la lista de espera vigentes ocupan sus habitaciones igual que las reservas
+	now := time.Now().UTC()
+	holds, err := repository.activeHolds(ctx, hotelIDs, now)
 	if err != nil {
-		return false, fmt.Errorf("error parsing check-in date: %w", err)
+		return nil, err
 	}
-	checkOutTime, err := time.Parse("2006-01-02", checkOut)
+	offers, err := repository.activeOffers(ctx, hotelIDs, now)
 	if err != nil {
-		return false, fmt.Errorf("error parsing check-out date: %w", err)
+		return nil, err
+	}
+	for _, held := range []map[string][]hotelsDAO.Hold{holds, offers} {
+		for hotelID, hotelHolds := range held {
+			for _, hold := range hotelHolds {
+				reservations[hotelID] = append(reservations[hotelID], hold.AsReservation())
+			}
+		}
 	}
 
-	// Primero obtener el hotel y su capacidad en una sola consulta
-	var av struct {
-		AvailableRooms int32 `bson:"avaiable_rooms"`
+	// Un hotel esta disponible si le queda una habitacion de algun tipo libre todas las noches de [check_in, check_out)
+	result := make(map[string]bool, len(hotelIDs))
+	for _, hotelID := range hotelIDs {
+		result[hotelID] = availability.IsAvailable(capacity[hotelID], reservations[hotelID], checkInTime, checkOutTime)
 	}
-	err = repository.client.Database(repository.database).Collection(repository.collection_hotel).
-		FindOne(ctx, bson.M{"_id": objectID}, options.FindOne().SetProjection(bson.M{"avaiable_rooms": 1, "_id": 0})).
-		Decode(&av)
+	return result, nil
+}
+
+// IsHotelAvailable verifica la disponibilidad de un hotel para un rango de fechas
+func (repository Mongo) IsHotelAvailable(ctx context.Context, hotelID, checkIn, checkOut string) (bool, error) {
+	result, err := repository.GetAvailability(ctx, []string{hotelID}, checkIn, checkOut)
 	if err != nil {
-		return false, fmt.Errorf("error finding hotel: %w", err)
+		return false, err
 	}
+	return result[hotelID], nil
+}
 
-	// Lista para almacenar los días
-	var days []string
-
-	// Iterar desde checkIn hasta checkOut
-	for current := checkInTime; !current.After(checkOutTime); current = current.AddDate(0, 0, 1) {
-		days = append(days, current.Format("2006-01-02"))
+// Funcion que obtiene las reservas no canceladas de un hotel que ocupan alguna noche de [from, to)
+// Solo trae lo que hace falta para contar habitaciones ocupadas, no todas las reservas del hotel
+func (repository Mongo) GetReservationsInRange(ctx context.Context, hotelID string, from, to time.Time) ([]hotelsDAO.Reservation, error) {
+	reservations, err := repository.reservationsInRange(ctx, []string{hotelID}, from, to)
+	if err != nil {
+		return nil, err
 	}
+	return reservations[hotelID], nil
+}
 
-	var maxreservations int = -1
-	for _, day := range days {
-		time, err := time.Parse("2006-01-02", day)
-		if err != nil {
-			return false, fmt.Errorf("error parsing date: %w", err)
-		}
-		pipeline := []bson.M{
-			{
-				"$match": bson.M{
-					"hotel_id":  hotelID,
-					"check_in":  bson.M{"$lte": time},
-					"check_out": bson.M{"$gt": time},
-				},
-			},
-			{
-				"$group": bson.M{
-					"_id":              nil,
-					"reservas_activas": bson.M{"$sum": 1},
-				},
-			},
-			{
-				"$project": bson.M{
-					"_id":              0,
-					"reservas_activas": 1,
-				},
-			},
-			{
-				"$unionWith": bson.M{
-					"coll": nil, // Esto es necesario para indicar que estamos agregando un documento manual
-					"pipeline": []bson.M{
-						{
-							"$documents": []bson.M{
-								{"reservas_activas": 0},
-							},
-						},
-					},
-				},
-			},
-			{
-				"$group": bson.M{
-					"_id":              nil,
-					"reservas_activas": bson.M{"$max": "$reservas_activas"},
-				},
-			},
-			{
-				"$project": bson.M{
-					"_id":              0,
-					"reservas_activas": 1,
-				},
-			},
-		}
-
-		// Ejecutar el pipeline
-		cursor, err := repository.client.Database(repository.database).
-			Collection(repository.collection_reservation).
-			Aggregate(ctx, pipeline)
-		if err != nil {
-			return false, fmt.Errorf("error aggregating reservations: %w", err)
-		}
-		defer cursor.Close(ctx)
-
-		// Obtener el resultado
-		var result struct {
-			ReservasActivas int `bson:"reservas_activas"`
-		}
-		if cursor.Next(ctx) {
-			if err := cursor.Decode(&result); err != nil {
-				return false, fmt.Errorf("error decoding result: %w", err)
-			}
-		} else {
-			return false, fmt.Errorf("no results found")
-		}
+// Funcion que obtiene, agrupadas por hotel, las reservas que ocupan alguna noche de [from, to)
+func (repository Mongo) reservationsInRange(ctx context.Context, hotelIDs []string, from, to time.Time) (map[string][]hotelsDAO.Reservation, error) {
+	filter := bson.M{
+		"hotel_id":  bson.M{"$in": hotelIDs},
+		"check_in":  bson.M{"$lt": to},
+		"check_out": bson.M{"$gt": from},
+		"status":    bson.M{"$ne": hotelsDAO.ReservationCancelled},
+	}
+	projection := bson.M{"hotel_id": 1, "check_in": 1, "check_out": 1, "room_count": 1, "rooms": 1, "status": 1}
+	cursor, err := repository.client.Database(repository.database).Collection(repository.collection_reservation).
+		Find(ctx, filter, options.Find().SetProjection(projection))
+	if err != nil {
+		return nil, fmt.Errorf("error finding reservations: %w", err)
+	}
+	var records []hotelsDAO.Reservation
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, fmt.Errorf("error decoding reservations: %w", err)
+	}
 
-		if result.ReservasActivas > maxreservations {
-			maxreservations = result.ReservasActivas
-		}
+	reservations := make(map[string][]hotelsDAO.Reservation)
+	for _, reservation := range records {
+		reservations[reservation.HotelID] = append(reservations[reservation.HotelID], reservation)
 	}
-	// Verificar disponibilidad
-	return maxreservations < int(av.AvailableRooms), nil
+	return reservations, nil
 }
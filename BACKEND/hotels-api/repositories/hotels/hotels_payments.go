@@ -0,0 +1,115 @@
+package hotels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	hotelsDAO "hotels-api/dao/hotels"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Funcion para crear el pago de una reserva en MongoDB
+func (repository Mongo) CreatePayment(ctx context.Context, payment hotelsDAO.Payment) (string, error) {
+	result, err := repository.client.Database(repository.database).Collection(repository.collection_payments).InsertOne(ctx, payment)
+	if err != nil {
+		return "", fmt.Errorf("error creating document: %w", err)
+	}
+
+	// Saca el ObjectID del resultado de la insercion
+	objectID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return "", fmt.Errorf("error converting mongo ID to object ID")
+	}
+	return objectID.Hex(), nil
+}
+
+// Funcion para obtener el pago de una reserva de MongoDB
+func (repository Mongo) GetPaymentByReservationID(ctx context.Context, reservationID string) (hotelsDAO.Payment, error) {
+	return repository.findPayment(ctx, bson.M{"reservation_id": reservationID}, "reservation "+reservationID)
+}
+
+// Funcion para obtener un pago por el ID de su autorizacion, que es como lo identifica el procesador de pagos
+func (repository Mongo) GetPaymentByAuthorizationID(ctx context.Context, authorizationID string) (hotelsDAO.Payment, error) {
+	return repository.findPayment(ctx, bson.M{"authorization_id": authorizationID}, "authorization "+authorizationID)
+}
+
+// Funcion para guardar los cambios de un pago en MongoDB
+// Solo se guarda si nadie lo modifico desde que se leyo (misma version), si no devuelve hotelsDAO.ErrConflict
+func (repository Mongo) UpdatePayment(ctx context.Context, payment hotelsDAO.Payment) error {
+	objectID, err := primitive.ObjectIDFromHex(payment.ID)
+	if err != nil {
+		return fmt.Errorf("error converting id to mongo ID (%v): %w", err, hotelsDAO.ErrNotFound)
+	}
+	filter := bson.M{"_id": objectID, "version": payment.Version}
+	update := bson.M{
+		"$set": bson.M{
+			"status":          payment.Status,
+			"captured_amount": payment.CapturedAmount,
+			"refunded_amount": payment.RefundedAmount,
+			"events":          payment.Events,
+			"updated_at":      payment.UpdatedAt,
+		},
+		"$inc": bson.M{"version": 1},
+	}
+	result, err := repository.client.Database(repository.database).Collection(repository.collection_payments).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("error updating document: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("payment with ID %s: %w", payment.ID, hotelsDAO.ErrConflict)
+	}
+	return nil
+}
+
+func (repository Mongo) findPayment(ctx context.Context, filter bson.M, description string) (hotelsDAO.Payment, error) {
+	var payment hotelsDAO.Payment
+	err := repository.client.Database(repository.database).Collection(repository.collection_payments).FindOne(ctx, filter).Decode(&payment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return hotelsDAO.Payment{}, fmt.Errorf("payment of %s: %w", description, hotelsDAO.ErrNotFound)
+	}
+	if err != nil {
+		return hotelsDAO.Payment{}, fmt.Errorf("error finding document: %w", err)
+	}
+	return payment, nil
+}
+
+// Funcion que registra una clave de idempotencia antes de procesar la peticion
+// Si la clave ya existia no la modifica: devuelve la registrada y false
+func (repository Mongo) ReserveIdempotencyKey(ctx context.Context, key hotelsDAO.IdempotencyKey) (hotelsDAO.IdempotencyKey, bool, error) {
+	collection := repository.client.Database(repository.database).Collection(repository.collection_idempotency)
+	_, err := collection.InsertOne(ctx, key)
+	if err == nil {
+		return key, true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return hotelsDAO.IdempotencyKey{}, false, fmt.Errorf("error creating document: %w", err)
+	}
+
+	var existing hotelsDAO.IdempotencyKey
+	if err := collection.FindOne(ctx, bson.M{"_id": key.ID}).Decode(&existing); err != nil {
+		return hotelsDAO.IdempotencyKey{}, false, fmt.Errorf("error finding document: %w", err)
+	}
+	return existing, false, nil
+}
+
+// Funcion que marca como procesada la peticion de una clave de idempotencia, guardando su resultado
+func (repository Mongo) CompleteIdempotencyKey(ctx context.Context, id string, result string) error {
+	update := bson.M{"$set": bson.M{"done": true, "result": result}}
+	_, err := repository.client.Database(repository.database).Collection(repository.collection_idempotency).UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return fmt.Errorf("error updating document: %w", err)
+	}
+	return nil
+}
+
+// Funcion que borra una clave de idempotencia cuya peticion fallo, para que se pueda reintentar
+func (repository Mongo) ReleaseIdempotencyKey(ctx context.Context, id string) error {
+	_, err := repository.client.Database(repository.database).Collection(repository.collection_idempotency).DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return fmt.Errorf("error deleting document: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package hotels
+
+import (
+	"context"
+	"fmt"
+	hotelsDAO "hotels-api/dao/hotels"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Funcion para anotar a un usuario en la lista de espera de un hotel
+func (repository Mongo) CreateWaitlistEntry(ctx context.Context, entry hotelsDAO.WaitlistEntry) (string, error) {
+	result, err := repository.client.Database(repository.database).Collection(repository.collection_waitlist).InsertOne(ctx, entry)
+	if err != nil {
+		return "", fmt.Errorf("error creating document: %w", err)
+	}
+
+	// Saca el ObjectID del resultado de la insercion
+	objectID, ok := result.InsertedID.(primitive.ObjectID)
+	if !ok {
+		return "", fmt.Errorf("error converting mongo ID to object ID")
+	}
+	return objectID.Hex(), nil
+}
+
+// Funcion para obtener las entradas de la lista de espera de un hotel en un estado, por orden de llegada
+func (repository Mongo) GetWaitlist(ctx context.Context, hotelID string, status string) ([]hotelsDAO.WaitlistEntry, error) {
+	filter := bson.M{"hotel_id": hotelID, "status": status}
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := repository.client.Database(repository.database).Collection(repository.collection_waitlist).Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error finding documents: %w", err)
+	}
+
+	// Decodificar el resultado
+	var entries []hotelsDAO.WaitlistEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("error decoding result: %w", err)
+	}
+	return entries, nil
+}
+
+// Funcion para ofrecerle una habitacion a una entrada que sigue esperando
+// Devuelve false si la entrada ya no estaba esperando (por ejemplo, otra instancia se la ofrecio antes)
+func (repository Mongo) OfferWaitlistEntry(ctx context.Context, id string, offeredAt, expiresAt time.Time) (bool, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, fmt.Errorf("error converting id to mongo ID: %w", err)
+	}
+	filter := bson.M{"_id": objectID, "status": hotelsDAO.WaitlistWaiting}
+	update := bson.M{"$set": bson.M{
+		"status":           hotelsDAO.WaitlistOffered,
+		"offered_at":       offeredAt,
+		"offer_expires_at": expiresAt,
+	}}
+	result, err := repository.client.Database(repository.database).Collection(repository.collection_waitlist).UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("error updating document: %w", err)
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// Funcion para marcar como vencidas las ofertas que no se usaron antes de now
+// Devuelve los hoteles que tenian ofertas vencidas, para ofrecerle el lugar al siguiente de la lista
+func (repository Mongo) ExpireWaitlistOffers(ctx context.Context, now time.Time) ([]string, error) {
+	collection := repository.client.Database(repository.database).Collection(repository.collection_waitlist)
+	filter := bson.M{"status": hotelsDAO.WaitlistOffered, "offer_expires_at": bson.M{"$lte": now}}
+
+	hotelIDs, err := collection.Distinct(ctx, "hotel_id", filter)
+	if err != nil {
+		return nil, fmt.Errorf("error finding expired offers: %w", err)
+	}
+	if len(hotelIDs) == 0 {
+		return nil, nil
+	}
+	if _, err := collection.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"status": hotelsDAO.WaitlistExpired}}); err != nil {
+		return nil, fmt.Errorf("error expiring offers: %w", err)
+	}
+
+	expired := make([]string, 0, len(hotelIDs))
+	for _, hotelID := range hotelIDs {
+		if id, ok := hotelID.(string); ok {
+			expired = append(expired, id)
+		}
+	}
+	return expired, nil
+}
+
+// Funcion para marcar como usadas las ofertas de un usuario en un hotel que se solapan con una reserva
+func (repository Mongo) CompleteWaitlistOffers(ctx context.Context, hotelID, userID string, checkIn, checkOut time.Time) error {
+	filter := bson.M{
+		"hotel_id":  hotelID,
+		"user_id":   userID,
+		"status":    hotelsDAO.WaitlistOffered,
+		"check_in":  bson.M{"$lt": checkOut},
+		"check_out": bson.M{"$gt": checkIn},
+	}
+	update := bson.M{"$set": bson.M{"status": hotelsDAO.WaitlistBooked}}
+	if _, err := repository.client.Database(repository.database).Collection(repository.collection_waitlist).UpdateMany(ctx, filter, update); err != nil {
+		return fmt.Errorf("error updating documents: %w", err)
+	}
+	return nil
+}
+
+// Funcion que obtiene, agrupadas por hotel y como retenciones, las ofertas de la lista de espera vigentes en now
+func (repository Mongo) activeOffers(ctx context.Context, hotelIDs []string, now time.Time) (map[string][]hotelsDAO.Hold, error) {
+	filter := bson.M{
+		"hotel_id":         bson.M{"$in": hotelIDs},
+		"status":           hotelsDAO.WaitlistOffered,
+		"offer_expires_at": bson.M{"$gt": now},
+	}
+	cursor, err := repository.client.Database(repository.database).Collection(repository.collection_waitlist).Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("error finding waitlist offers: %w", err)
+	}
+	var entries []hotelsDAO.WaitlistEntry
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, fmt.Errorf("error decoding waitlist offers: %w", err)
+	}
+
+	offers := make(map[string][]hotelsDAO.Hold)
+	for _, entry := range entries {
+		offers[entry.HotelID] = append(offers[entry.HotelID], entry.AsHold())
+	}
+	return offers, nil
+}
@@ -0,0 +1,139 @@
+package availability
+
+import (
+	hotelsDAO "hotels-api/dao/hotels"
+	"time"
+)
+
+// Calculo de disponibilidad compartido por los repositorios (MongoDB y cache) y el servicio de hoteles
+// Una estadia ocupa las noches del rango semiabierto [check_in, check_out): la noche del check_out no se ocupa,
+// asi una reserva puede empezar el mismo dia en que termina otra
+
+// Funcion que lleva una fecha a la medianoche UTC de ese dia, para comparar noches sin importar la hora
+func Date(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Funcion que devuelve las noches de [checkIn, checkOut). Si checkOut no es posterior a checkIn no hay noches
+func Nights(checkIn, checkOut time.Time) []time.Time {
+	var nights []time.Time
+	for night := Date(checkIn); night.Before(Date(checkOut)); night = night.AddDate(0, 0, 1) {
+		nights = append(nights, night)
+	}
+	return nights
+}
+
+// Funcion que indica si dos estadias comparten al menos una noche
+func Overlaps(checkIn, checkOut, otherCheckIn, otherCheckOut time.Time) bool {
+	return Date(checkIn).Before(Date(otherCheckOut)) && Date(otherCheckIn).Before(Date(checkOut))
+}
+
+// Funcion que cuenta las habitaciones no canceladas de una reserva por tipo, una reserva cancelada no tiene ninguna
+// Las reservas anteriores a los tipos de habitacion cuentan RoomCount habitaciones (al menos una) del tipo por defecto
+func ActiveRooms(reservation hotelsDAO.Reservation) map[string]int {
+	rooms := make(map[string]int)
+	if reservation.Status == hotelsDAO.ReservationCancelled {
+		return rooms
+	}
+	if len(reservation.Rooms) == 0 {
+		count := reservation.RoomCount
+		if count == 0 {
+			count = 1
+		}
+		rooms[hotelsDAO.DefaultRoomType] = count
+		return rooms
+	}
+	for _, room := range reservation.Rooms {
+		if !room.Cancelled {
+			rooms[room.RoomType]++
+		}
+	}
+	return rooms
+}
+
+// Funcion que cuenta cuantas habitaciones estan reservadas cada noche de [checkIn, checkOut)
+// Las noches sin reservas no aparecen en el mapa
+func ReservedByNight(reservations []hotelsDAO.Reservation, checkIn, checkOut time.Time) map[time.Time]int {
+	reserved := make(map[time.Time]int)
+	for _, nights := range reservedByTypeAndNight(reservations, checkIn, checkOut) {
+		for night, count := range nights {
+			reserved[night] += count
+		}
+	}
+	return reserved
+}
+
+// Funcion que calcula, para cada tipo de habitacion, el maximo de habitaciones reservadas en alguna noche de [checkIn, checkOut)
+func ReservedRoomsByType(reservations []hotelsDAO.Reservation, checkIn, checkOut time.Time) map[string]int {
+	reserved := make(map[string]int)
+	for roomType, nights := range reservedByTypeAndNight(reservations, checkIn, checkOut) {
+		for _, count := range nights {
+			if count > reserved[roomType] {
+				reserved[roomType] = count
+			}
+		}
+	}
+	return reserved
+}
+
+// Funcion que devuelve cuantas habitaciones tiene el hotel de cada tipo
+// Un hotel sin tipos tiene todas sus habitaciones del tipo por defecto
+func RoomCapacity(hotel hotelsDAO.Hotel) map[string]int {
+	capacity := make(map[string]int)
+	if len(hotel.RoomTypes) == 0 {
+		capacity[hotelsDAO.DefaultRoomType] = hotel.AvaiableRooms
+		return capacity
+	}
+	for _, roomType := range hotel.RoomTypes {
+		capacity[roomType.Code] += roomType.Rooms
+	}
+	return capacity
+}
+
+// Funcion que indica si queda libre al menos una habitacion de algun tipo todas las noches de [checkIn, checkOut)
+// capacity tiene las habitaciones de cada tipo, una habitacion de otro tipo libre no cubre una noche llena
+// Un rango sin noches no esta disponible
+func IsAvailable(capacity map[string]int, reservations []hotelsDAO.Reservation, checkIn, checkOut time.Time) bool {
+	nights := Nights(checkIn, checkOut)
+	if len(nights) == 0 {
+		return false
+	}
+	reserved := reservedByTypeAndNight(reservations, checkIn, checkOut)
+	for roomType, rooms := range capacity {
+		free := true
+		for _, night := range nights {
+			if reserved[roomType][night] >= rooms {
+				free = false
+				break
+			}
+		}
+		if free {
+			return true
+		}
+	}
+	return false
+}
+
+// Funcion que reparte las habitaciones activas de cada reserva entre las noches de [checkIn, checkOut) que ocupa
+func reservedByTypeAndNight(reservations []hotelsDAO.Reservation, checkIn, checkOut time.Time) map[string]map[time.Time]int {
+	from, to := Date(checkIn), Date(checkOut)
+	reserved := make(map[string]map[time.Time]int)
+	for _, reservation := range reservations {
+		if !Overlaps(reservation.CheckIn, reservation.CheckOut, from, to) {
+			continue
+		}
+		rooms := ActiveRooms(reservation)
+		for _, night := range Nights(reservation.CheckIn, reservation.CheckOut) {
+			if night.Before(from) || !night.Before(to) {
+				continue
+			}
+			for roomType, count := range rooms {
+				if reserved[roomType] == nil {
+					reserved[roomType] = make(map[time.Time]int)
+				}
+				reserved[roomType][night] += count
+			}
+		}
+	}
+	return reserved
+}
@@ -0,0 +1,145 @@
+package availability
+
+import (
+	"testing"
+	"time"
+
+	hotelsDAO "hotels-api/dao/hotels"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestNights(t *testing.T) {
+	assert.Equal(t, []time.Time{date(2024, 12, 30), date(2024, 12, 31)}, Nights(date(2024, 12, 30), date(2025, 1, 1)))
+	// La hora no cambia las noches
+	assert.Len(t, Nights(date(2024, 12, 30).Add(15*time.Hour), date(2024, 12, 31).Add(10*time.Hour)), 1)
+	assert.Empty(t, Nights(date(2024, 12, 30), date(2024, 12, 30)))
+	assert.Empty(t, Nights(date(2024, 12, 30), date(2024, 12, 29)))
+}
+
+func TestOverlaps(t *testing.T) {
+	assert.True(t, Overlaps(date(2025, 3, 1), date(2025, 3, 3), date(2025, 3, 2), date(2025, 3, 4)))
+	assert.True(t, Overlaps(date(2025, 3, 1), date(2025, 3, 5), date(2025, 3, 2), date(2025, 3, 3)))
+	// Una estadia que termina el dia que empieza la otra no la pisa
+	assert.False(t, Overlaps(date(2025, 3, 1), date(2025, 3, 3), date(2025, 3, 3), date(2025, 3, 5)))
+	assert.False(t, Overlaps(date(2025, 3, 3), date(2025, 3, 5), date(2025, 3, 1), date(2025, 3, 3)))
+}
+
+func TestReservedByNight(t *testing.T) {
+	reserved := ReservedByNight([]hotelsDAO.Reservation{
+		{CheckIn: date(2024, 12, 9), CheckOut: date(2024, 12, 11)},
+		{CheckIn: date(2024, 12, 10), CheckOut: date(2024, 12, 12), RoomCount: 2},
+		{CheckIn: date(2024, 12, 12), CheckOut: date(2024, 12, 14)},
+	}, date(2024, 12, 10), date(2024, 12, 12))
+	assert.Equal(t, map[time.Time]int{
+		date(2024, 12, 10): 3,
+		date(2024, 12, 11): 2,
+	}, reserved)
+}
+
+func TestReservedRoomsByType(t *testing.T) {
+	reservations := []hotelsDAO.Reservation{
+		// Reserva anterior a los tipos: dos habitaciones del tipo por defecto
+		{CheckIn: date(2025, 3, 1), CheckOut: date(2025, 3, 3), RoomCount: 2},
+		{CheckIn: date(2025, 3, 2), CheckOut: date(2025, 3, 4), Rooms: []hotelsDAO.ReservationRoom{
+			{RoomType: "suite"},
+			{RoomType: hotelsDAO.DefaultRoomType},
+			{RoomType: hotelsDAO.DefaultRoomType, Cancelled: true},
+		}},
+		// Termina el dia que empieza el rango pedido, no ocupa ninguna noche
+		{CheckIn: date(2025, 2, 27), CheckOut: date(2025, 3, 1), Rooms: []hotelsDAO.ReservationRoom{{RoomType: "suite"}}},
+	}
+
+	reserved := ReservedRoomsByType(reservations, date(2025, 3, 1), date(2025, 3, 4))
+	assert.Equal(t, 3, reserved[hotelsDAO.DefaultRoomType])
+	assert.Equal(t, 1, reserved["suite"])
+
+	// Solo la noche del 3 de marzo
+	reserved = ReservedRoomsByType(reservations, date(2025, 3, 3), date(2025, 3, 4))
+	assert.Equal(t, 1, reserved[hotelsDAO.DefaultRoomType])
+	assert.Equal(t, 1, reserved["suite"])
+}
+
+func TestIsAvailable(t *testing.T) {
+	reservations := []hotelsDAO.Reservation{
+		{CheckIn: date(2025, 3, 1), CheckOut: date(2025, 3, 3)},
+		{CheckIn: date(2025, 3, 2), CheckOut: date(2025, 3, 3)},
+	}
+
+	tests := []struct {
+		name      string
+		capacity  int
+		checkIn   time.Time
+		checkOut  time.Time
+		available bool
+	}{
+		{"Full night", 2, date(2025, 3, 2), date(2025, 3, 3), false},
+		{"Free room every night", 3, date(2025, 3, 1), date(2025, 3, 5), true},
+		{"Back to back with the check-out", 2, date(2025, 3, 3), date(2025, 3, 5), true},
+		{"Back to back with the check-in", 1, date(2025, 2, 27), date(2025, 3, 1), true},
+		{"Spans the full night", 2, date(2025, 2, 28), date(2025, 3, 4), false},
+		{"No nights", 5, date(2025, 3, 10), date(2025, 3, 10), false},
+		{"No rooms", 0, date(2025, 3, 10), date(2025, 3, 11), false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.available, IsAvailable(standardRooms(test.capacity), reservations, test.checkIn, test.checkOut))
+		})
+	}
+}
+
+func TestIsAvailableByRoomType(t *testing.T) {
+	capacity := map[string]int{hotelsDAO.DefaultRoomType: 1, "suite": 1}
+	reservations := []hotelsDAO.Reservation{
+		{CheckIn: date(2025, 3, 1), CheckOut: date(2025, 3, 3), Rooms: []hotelsDAO.ReservationRoom{{RoomType: hotelsDAO.DefaultRoomType}}},
+		{CheckIn: date(2025, 3, 2), CheckOut: date(2025, 3, 4), Rooms: []hotelsDAO.ReservationRoom{{RoomType: "suite"}}},
+	}
+
+	// Dos habitaciones y dos reservas, pero el 1 y el 3 de marzo queda libre una de algun tipo
+	assert.True(t, IsAvailable(capacity, reservations, date(2025, 3, 1), date(2025, 3, 2)))
+	assert.True(t, IsAvailable(capacity, reservations, date(2025, 3, 3), date(2025, 3, 4)))
+	// El 2 de marzo los dos tipos estan llenos
+	assert.False(t, IsAvailable(capacity, reservations, date(2025, 3, 2), date(2025, 3, 3)))
+	// Ningun tipo esta libre todas las noches, aunque cada noche quede alguna habitacion
+	assert.False(t, IsAvailable(capacity, reservations, date(2025, 3, 1), date(2025, 3, 4)))
+}
+
+func TestRoomCapacity(t *testing.T) {
+	assert.Equal(t, map[string]int{hotelsDAO.DefaultRoomType: 3}, RoomCapacity(hotelsDAO.Hotel{AvaiableRooms: 3}))
+	hotel := hotelsDAO.Hotel{AvaiableRooms: 3, RoomTypes: []hotelsDAO.RoomType{{Code: "double", Rooms: 2}, {Code: "suite", Rooms: 1}}}
+	assert.Equal(t, map[string]int{"double": 2, "suite": 1}, RoomCapacity(hotel))
+}
+
+func TestIsAvailableWithHolds(t *testing.T) {
+	hold := hotelsDAO.Hold{
+		CheckIn:   date(2025, 3, 1),
+		CheckOut:  date(2025, 3, 3),
+		RoomCount: 1,
+		Rooms:     []hotelsDAO.ReservationRoom{{RoomType: hotelsDAO.DefaultRoomType}},
+	}
+	reservations := []hotelsDAO.Reservation{{CheckIn: date(2025, 3, 1), CheckOut: date(2025, 3, 2)}}
+
+	assert.True(t, IsAvailable(standardRooms(2), reservations, date(2025, 3, 1), date(2025, 3, 2)))
+	assert.False(t, IsAvailable(standardRooms(2), append(reservations, hold.AsReservation()), date(2025, 3, 1), date(2025, 3, 2)))
+	assert.True(t, IsAvailable(standardRooms(2), append(reservations, hold.AsReservation()), date(2025, 3, 2), date(2025, 3, 3)))
+}
+
+func TestIsAvailableWithCancelledReservations(t *testing.T) {
+	reservations := []hotelsDAO.Reservation{
+		{CheckIn: date(2025, 3, 1), CheckOut: date(2025, 3, 3), Status: hotelsDAO.ReservationConfirmed},
+		{CheckIn: date(2025, 3, 1), CheckOut: date(2025, 3, 3), Status: hotelsDAO.ReservationCancelled},
+	}
+
+	// La reserva cancelada queda guardada pero no ocupa su habitacion
+	assert.Empty(t, ActiveRooms(reservations[1]))
+	assert.True(t, IsAvailable(standardRooms(2), reservations, date(2025, 3, 1), date(2025, 3, 3)))
+}
+
+// Capacidad de un hotel sin tipos de habitacion
+func standardRooms(rooms int) map[string]int {
+	return map[string]int{hotelsDAO.DefaultRoomType: rooms}
+}
@@ -0,0 +1,94 @@
+package hotels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	hotelsDAO "hotels-api/dao/hotels"
+	hotelsDomain "hotels-api/domain/hotels"
+)
+
+// Funcion que obtiene una reserva por su ID, primero de la cache y si no esta de la base principal
+// Solo la puede ver el usuario que la hizo o un administrador del hotel
+func (service Service) GetReservationByID(ctx context.Context, id string, userID string) (hotelsDomain.Reservation, error) {
+	reservation, err := service.reservations.Get(ctx, id)
+	if err != nil {
+		return hotelsDomain.Reservation{}, fmt.Errorf("error getting reservation: %w", err)
+	}
+	if err := service.authorizeReservation(ctx, reservation, userID); err != nil {
+		return hotelsDomain.Reservation{}, err
+	}
+	return reservationToDomain(reservation), nil
+}
+
+// Funcion que verifica que el usuario pueda ver y cambiar la reserva
+// El hotel solo se busca si el usuario no es el dueño, para ver si es administrador
+func (service Service) authorizeReservation(ctx context.Context, reservation hotelsDAO.Reservation, userID string) error {
+	if userID != "" && userID == reservation.UserID {
+		return nil
+	}
+	hotel, err := service.GetHotelByID(ctx, reservation.HotelID)
+	if err != nil && !errors.Is(err, hotelsDAO.ErrNotFound) {
+		return fmt.Errorf("error getting hotel: %w", err)
+	}
+	if !canAccessReservation(hotel, reservation, userID) {
+		return hotelsDomain.ForbiddenError{Message: fmt.Sprintf("user %s can't access reservation %s", userID, reservation.ID)}
+	}
+	return nil
+}
+
+// Funcion que verifica que el usuario administre el hotel de la reserva, para lo que no puede hacer el cliente
+// como cobrar o devolver el pago
+func (service Service) authorizeManager(ctx context.Context, reservationID string, userID string) error {
+	reservation, err := service.reservations.Get(ctx, reservationID)
+	if err != nil {
+		return fmt.Errorf("error getting reservation: %w", err)
+	}
+	hotel, err := service.GetHotelByID(ctx, reservation.HotelID)
+	if err != nil && !errors.Is(err, hotelsDAO.ErrNotFound) {
+		return fmt.Errorf("error getting hotel: %w", err)
+	}
+	if !isManager(hotel, userID) {
+		return hotelsDomain.ForbiddenError{Message: fmt.Sprintf("user %s doesn't manage the hotel of reservation %s", userID, reservationID)}
+	}
+	return nil
+}
+
+// Funcion que verifica que el usuario pueda listar las reservas de userID: tiene que ser el mismo usuario o, si
+// la lista es de un hotel, uno de sus administradores
+func (service Service) authorizeUserReservations(ctx context.Context, hotelID string, userID string, requesterID string) error {
+	if requesterID != "" && requesterID == userID {
+		return nil
+	}
+	if hotelID != "" {
+		hotel, err := service.GetHotelByID(ctx, hotelID)
+		if err != nil && !errors.Is(err, hotelsDAO.ErrNotFound) {
+			return fmt.Errorf("error getting hotel: %w", err)
+		}
+		if isManager(hotel, requesterID) {
+			return nil
+		}
+	}
+	return hotelsDomain.ForbiddenError{Message: fmt.Sprintf("user %s can't list the reservations of user %s", requesterID, userID)}
+}
+
+// Funcion que indica si el usuario es el dueño de la reserva o administra su hotel
+func canAccessReservation(hotel hotelsDomain.Hotel, reservation hotelsDAO.Reservation, userID string) bool {
+	if userID == "" {
+		return false
+	}
+	return userID == reservation.UserID || isManager(hotel, userID)
+}
+
+// Funcion que indica si el usuario administra el hotel
+func isManager(hotel hotelsDomain.Hotel, userID string) bool {
+	if userID == "" {
+		return false
+	}
+	for _, managerID := range hotel.ManagerIDs {
+		if managerID == userID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package hotels
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	hotelsDAO "hotels-api/dao/hotels"
+	hotelsDomain "hotels-api/domain/hotels"
+	repositories "hotels-api/repositories/hotels"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanAccessReservation(t *testing.T) {
+	hotel := hotelsDomain.Hotel{ID: "hotel-1", ManagerIDs: []string{"7", "8"}}
+	reservation := hotelsDAO.Reservation{ID: "reservation-1", HotelID: hotel.ID, UserID: "1"}
+
+	tests := []struct {
+		name   string
+		hotel  hotelsDomain.Hotel
+		userID string
+		access bool
+	}{
+		{"Owner", hotel, "1", true},
+		{"Hotel manager", hotel, "8", true},
+		{"Another user", hotel, "2", false},
+		{"Manager of a missing hotel", hotelsDomain.Hotel{}, "7", false},
+		{"Anonymous", hotel, "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.access, canAccessReservation(test.hotel, reservation, test.userID))
+		})
+	}
+
+	// Una reserva sin usuario no la puede ver cualquiera sin usuario
+	assert.False(t, canAccessReservation(hotel, hotelsDAO.Reservation{HotelID: hotel.ID}, ""))
+}
+
+func TestIsManager(t *testing.T) {
+	hotel := hotelsDomain.Hotel{ID: "hotel-1", ManagerIDs: []string{"7", "8"}}
+
+	// El dueño de una reserva no puede cobrar ni devolver el pago, solo los administradores del hotel
+	assert.True(t, isManager(hotel, "7"))
+	assert.False(t, isManager(hotel, "1"))
+	assert.False(t, isManager(hotel, ""))
+	assert.False(t, isManager(hotelsDomain.Hotel{ManagerIDs: []string{""}}, ""))
+}
+
+func TestGetReservationsAccess(t *testing.T) {
+	ctx := context.Background()
+	main := &countingRepository{
+		hotels: map[string]hotelsDAO.Hotel{"hotel": {ID: "hotel", ManagerIDs: []string{"7"}}},
+		reservations: []hotelsDAO.Reservation{
+			{ID: "reservation-1", HotelID: "hotel", UserID: "1"},
+			{ID: "reservation-2", HotelID: "hotel", UserID: "2"},
+		},
+	}
+	cache := repositories.NewCache(repositories.CacheConfig{MaxSize: 100, ItemsToPrune: 10, Duration: time.Minute})
+	service := NewService(main, cache, nil, nil, nil, nil, nil, nil)
+
+	// Las reservas de un usuario solo las ve ese usuario
+	_, err := service.GetReservationsByUserID(ctx, "1", "1")
+	assert.NoError(t, err)
+	_, err = service.GetReservationsByUserID(ctx, "1", "2")
+	assert.ErrorAs(t, err, &hotelsDomain.ForbiddenError{})
+	_, err = service.GetReservationsByUserID(ctx, "1", "7")
+	assert.ErrorAs(t, err, &hotelsDomain.ForbiddenError{})
+
+	// Las de un usuario en un hotel tambien las ven los administradores del hotel
+	_, err = service.GetReservationsByUserAndHotelID(ctx, "hotel", "1", "7")
+	assert.NoError(t, err)
+	_, err = service.GetReservationsByUserAndHotelID(ctx, "hotel", "1", "2")
+	assert.ErrorAs(t, err, &hotelsDomain.ForbiddenError{})
+	_, err = service.GetReservationsByUserAndHotelID(ctx, "hotel", "1", "")
+	assert.ErrorAs(t, err, &hotelsDomain.ForbiddenError{})
+
+	// Las del hotel las ven completas sus administradores, el resto solo ve las suyas
+	reservations, err := service.GetReservationsByHotelID(ctx, "hotel", "7")
+	assert.NoError(t, err)
+	assert.Len(t, reservations, 2)
+	reservations, err = service.GetReservationsByHotelID(ctx, "hotel", "2")
+	assert.NoError(t, err)
+	assert.Len(t, reservations, 1)
+	assert.Equal(t, "reservation-2", reservations[0].ID)
+	reservations, err = service.GetReservationsByHotelID(ctx, "hotel", "")
+	assert.NoError(t, err)
+	assert.Empty(t, reservations)
+}
@@ -0,0 +1,250 @@
+package hotels
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"hotels-api/clients/queues"
+	hotelsDAO "hotels-api/dao/hotels"
+	hotelsDomain "hotels-api/domain/hotels"
+	repositories "hotels-api/repositories/hotels"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Repositorio principal que solo sabe buscar hoteles y listar las reservas de un usuario, y cuenta las consultas
+// El resto de las funciones no se usan en estas pruebas
+type countingRepository struct {
+	MainRepository
+	hotels       map[string]hotelsDAO.Hotel
+	reservations []hotelsDAO.Reservation
+	reads        int
+	hotelReads   atomic.Int64
+	release      chan struct{} // Si no es nil, las busquedas de hoteles esperan a que se cierre
+}
+
+func (repository *countingRepository) GetHotelByID(ctx context.Context, id string) (hotelsDAO.Hotel, error) {
+	repository.hotelReads.Add(1)
+	if repository.release != nil {
+		<-repository.release
+	}
+	hotel, ok := repository.hotels[id]
+	if !ok {
+		return hotelsDAO.Hotel{}, hotelsDAO.ErrNotFound
+	}
+	return hotel, nil
+}
+
+func (repository *countingRepository) Update(ctx context.Context, hotel hotelsDAO.Hotel) error {
+	repository.hotels[hotel.ID] = hotel
+	return nil
+}
+
+func (repository *countingRepository) Delete(ctx context.Context, id string) error {
+	delete(repository.hotels, id)
+	return nil
+}
+
+func (repository *countingRepository) GetReservationsByUserID(ctx context.Context, userID string) ([]hotelsDAO.Reservation, error) {
+	repository.reads++
+	return repository.reservations, nil
+}
+
+func (repository *countingRepository) GetReservationsByHotelID(ctx context.Context, hotelID string) ([]hotelsDAO.Reservation, error) {
+	repository.reads++
+	return repository.reservations, nil
+}
+
+func (repository *countingRepository) GetReservationsByUserAndHotelID(ctx context.Context, hotelID string, userID string) ([]hotelsDAO.Reservation, error) {
+	repository.reads++
+	return repository.reservations, nil
+}
+
+func TestGetReservationsByUserIDCache(t *testing.T) {
+	ctx := context.Background()
+	main := &countingRepository{reservations: []hotelsDAO.Reservation{{ID: "reservation-1", HotelID: "hotel", UserID: "1"}}}
+	cache := repositories.NewCache(repositories.CacheConfig{MaxSize: 100, ItemsToPrune: 10, Duration: time.Minute})
+	service := NewService(main, cache, nil, nil, nil, nil, nil, nil)
+
+	// La primera lectura no esta en cache y va a MongoDB, la segunda sale de la cache
+	reservations, err := service.GetReservationsByUserID(ctx, "1", "1")
+	assert.NoError(t, err)
+	assert.Len(t, reservations, 1)
+	assert.Equal(t, 1, main.reads)
+
+	reservations, err = service.GetReservationsByUserID(ctx, "1", "1")
+	assert.NoError(t, err)
+	assert.Len(t, reservations, 1)
+	assert.Equal(t, 1, main.reads)
+
+	// Despues de una reserva nueva del usuario la lista se vuelve a leer y la incluye
+	main.reservations = append(main.reservations, hotelsDAO.Reservation{ID: "reservation-2", HotelID: "hotel", UserID: "1"})
+	assert.NoError(t, cache.InvalidateReservations(ctx, "hotel", "1"))
+	reservations, err = service.GetReservationsByUserID(ctx, "1", "1")
+	assert.NoError(t, err)
+	assert.Len(t, reservations, 2)
+	assert.Equal(t, 2, main.reads)
+}
+
+func TestGetHotelByIDCoalescesMisses(t *testing.T) {
+	main := &countingRepository{hotels: map[string]hotelsDAO.Hotel{"hotel": {ID: "hotel", Name: "Hotel"}}, release: make(chan struct{})}
+	cache := repositories.NewCache(repositories.CacheConfig{MaxSize: 100, ItemsToPrune: 10, Duration: time.Minute})
+	service := NewService(main, cache, nil, nil, nil, nil, nil, nil)
+
+	// Todas las lecturas llegan con el hotel fuera de la cache, pero solo una va a MongoDB
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hotel, err := service.GetHotelByID(context.Background(), "hotel")
+			assert.NoError(t, err)
+			assert.Equal(t, "Hotel", hotel.Name)
+		}()
+	}
+	assert.Eventually(t, func() bool { return main.hotelReads.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	close(main.release)
+	wg.Wait()
+	assert.Equal(t, int64(1), main.hotelReads.Load())
+
+	// Despues queda en cache
+	_, err := service.GetHotelByID(context.Background(), "hotel")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), main.hotelReads.Load())
+}
+
+func TestGetHotelByIDCachesNotFound(t *testing.T) {
+	ctx := context.Background()
+	main := &countingRepository{hotels: map[string]hotelsDAO.Hotel{}}
+	cache := repositories.NewCache(repositories.CacheConfig{MaxSize: 100, ItemsToPrune: 10, Duration: time.Minute, NotFoundDuration: time.Minute})
+	service := NewService(main, cache, nil, nil, nil, nil, nil, nil)
+
+	// El segundo pedido de un hotel que no existe se responde desde la cache, y sigue siendo ErrNotFound
+	for i := 0; i < 2; i++ {
+		_, err := service.GetHotelByID(ctx, "missing")
+		assert.True(t, errors.Is(err, hotelsDAO.ErrNotFound))
+	}
+	assert.Equal(t, int64(1), main.hotelReads.Load())
+
+	// Si el hotel se crea despues, la cache guarda el hotel en lugar de la ausencia
+	_, err := cache.Create(ctx, hotelsDAO.Hotel{ID: "missing", Name: "Nuevo"})
+	assert.NoError(t, err)
+	hotel, err := service.GetHotelByID(ctx, "missing")
+	assert.NoError(t, err)
+	assert.Equal(t, "Nuevo", hotel.Name)
+}
+
+func TestGetHotelByIDUsesSharedCache(t *testing.T) {
+	ctx := context.Background()
+	main := &countingRepository{hotels: map[string]hotelsDAO.Hotel{"hotel": {ID: "hotel", Name: "Hotel", Version: 1}}}
+	config := repositories.CacheConfig{MaxSize: 100, ItemsToPrune: 10, Duration: time.Minute}
+	// Otra cache en memoria hace de Memcached, compartida por las dos replicas
+	shared := repositories.NewCache(config)
+	first := NewService(main, repositories.NewCache(config), shared, nil, nil, nil, nil, nil)
+	second := NewService(main, repositories.NewCache(config), shared, nil, nil, nil, nil, nil)
+
+	// La primera replica lo lee de MongoDB y la segunda de la cache compartida
+	_, err := first.GetHotelByID(ctx, "hotel")
+	assert.NoError(t, err)
+	hotel, err := second.GetHotelByID(ctx, "hotel")
+	assert.NoError(t, err)
+	assert.Equal(t, "Hotel", hotel.Name)
+	assert.Equal(t, int64(1), main.hotelReads.Load())
+}
+
+func TestHandleHotelNewEvictsStaleHotels(t *testing.T) {
+	ctx := context.Background()
+	main := &countingRepository{hotels: map[string]hotelsDAO.Hotel{"hotel": {ID: "hotel", Name: "Hotel", Version: 1}}}
+	cache := repositories.NewCache(repositories.CacheConfig{MaxSize: 100, ItemsToPrune: 10, Duration: time.Minute, NotFoundDuration: time.Minute})
+	service := NewService(main, cache, nil, nil, nil, nil, nil, nil)
+	_, err := service.GetHotelByID(ctx, "hotel")
+	assert.NoError(t, err)
+
+	// Un evento de una version que ya esta en cache (repetido, o de esta misma replica) no la borra
+	service.HandleHotelNew(hotelsDomain.HotelNew{Operation: "UPDATE", HotelID: "hotel", Version: 1})
+	_, err = service.GetHotelByID(ctx, "hotel")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), main.hotelReads.Load())
+
+	// Una version mayor, modificada en otra replica, se vuelve a leer de MongoDB
+	main.hotels["hotel"] = hotelsDAO.Hotel{ID: "hotel", Name: "Renovado", Version: 2}
+	service.HandleHotelNew(hotelsDomain.HotelNew{Operation: "UPDATE", HotelID: "hotel", Version: 2})
+	hotel, err := service.GetHotelByID(ctx, "hotel")
+	assert.NoError(t, err)
+	assert.Equal(t, "Renovado", hotel.Name)
+	assert.Equal(t, int64(2), main.hotelReads.Load())
+
+	// El borrado en otra replica tambien se ve en esta
+	delete(main.hotels, "hotel")
+	service.HandleHotelNew(hotelsDomain.HotelNew{Operation: "DELETE", HotelID: "hotel", Version: 3})
+	_, err = service.GetHotelByID(ctx, "hotel")
+	assert.True(t, errors.Is(err, hotelsDAO.ErrNotFound))
+
+	// Y al crearse en otra replica se olvida que no existia
+	main.hotels["hotel"] = hotelsDAO.Hotel{ID: "hotel", Name: "Nuevo", Version: 4}
+	service.HandleHotelNew(hotelsDomain.HotelNew{Operation: "CREATE", HotelID: "hotel", Version: 4})
+	hotel, err = service.GetHotelByID(ctx, "hotel")
+	assert.NoError(t, err)
+	assert.Equal(t, "Nuevo", hotel.Name)
+}
+
+func TestHandleHotelNewInvalidatesReservations(t *testing.T) {
+	ctx := context.Background()
+	main := &countingRepository{reservations: []hotelsDAO.Reservation{{ID: "reservation-1", HotelID: "hotel", UserID: "1"}}}
+	cache := repositories.NewCache(repositories.CacheConfig{MaxSize: 100, ItemsToPrune: 10, Duration: time.Minute})
+	service := NewService(main, cache, nil, nil, nil, nil, nil, nil)
+	_, err := service.GetReservationsByUserID(ctx, "1", "1")
+	assert.NoError(t, err)
+	_, err = cache.Create(ctx, hotelsDAO.Hotel{ID: "hotel", AvaiableRooms: 2})
+	assert.NoError(t, err)
+	assert.NoError(t, cache.SetReservationsByHotelID(ctx, "hotel", main.reservations))
+	assert.NoError(t, cache.SetActiveHolds(ctx, "hotel", nil))
+	_, err = cache.IsHotelAvailable(ctx, "hotel", "2030-01-01", "2030-01-02")
+	assert.NoError(t, err)
+
+	// Otra replica reservo en el hotel: esta vuelve a leer las reservas del usuario y las retenciones del hotel
+	main.reservations = append(main.reservations, hotelsDAO.Reservation{ID: "reservation-2", HotelID: "hotel", UserID: "1"})
+	service.HandleHotelNew(hotelsDomain.HotelNew{Operation: "RESERVATIONS", HotelID: "hotel", UserID: "1", ReservationID: "reservation-2"})
+	reservations, err := service.GetReservationsByUserID(ctx, "1", "1")
+	assert.NoError(t, err)
+	assert.Len(t, reservations, 2)
+	assert.Equal(t, 2, main.reads)
+	// Aunque vuelvan a estar las reservas del hotel, sin sus retenciones la cache ya no responde la disponibilidad
+	assert.NoError(t, cache.SetReservationsByHotelID(ctx, "hotel", main.reservations))
+	_, err = cache.IsHotelAvailable(ctx, "hotel", "2030-01-01", "2030-01-02")
+	assert.Error(t, err)
+}
+
+func TestSharedCacheOutage(t *testing.T) {
+	ctx := context.Background()
+	// Un puerto local donde no escucha nadie hace de Memcached caido
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	assert.NoError(t, listener.Close())
+	shared := repositories.NewMemcached(repositories.MemcachedConfig{Host: "127.0.0.1", Port: port, Duration: time.Minute})
+
+	main := &countingRepository{hotels: map[string]hotelsDAO.Hotel{"hotel": {ID: "hotel", Name: "Hotel", Version: 1}}}
+	cache := repositories.NewCache(repositories.CacheConfig{MaxSize: 100, ItemsToPrune: 10, Duration: time.Minute})
+	service := NewService(main, cache, shared, queues.NewMock(), nil, nil, nil, nil)
+
+	// Las lecturas siguen yendo a MongoDB y las escrituras terminan bien aunque no se pueda escribir la cache
+	hotel, err := service.GetHotelByID(ctx, "hotel")
+	assert.NoError(t, err)
+	assert.Equal(t, "Hotel", hotel.Name)
+	assert.NoError(t, service.Update(ctx, hotelsDomain.Hotel{ID: "hotel", Name: "Renovado"}))
+	assert.Equal(t, "Renovado", main.hotels["hotel"].Name)
+	assert.NoError(t, service.Delete(ctx, "hotel"))
+	assert.NotContains(t, main.hotels, "hotel")
+
+	// Cada falla queda contada en las metricas
+	metrics, err := service.CacheMetrics(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), metrics.Errors)
+}
@@ -0,0 +1,108 @@
+package hotels
+
+import (
+	"context"
+	"fmt"
+	hotelsDAO "hotels-api/dao/hotels"
+	hotelsDomain "hotels-api/domain/hotels"
+	"hotels-api/services/availability"
+	"time"
+)
+
+// Un calendario cubre como mucho un año
+const maxCalendarNights = 366
+
+// Funcion que arma el calendario de disponibilidad de un hotel para las noches de [from, to)
+// El calendario se guarda en la cache y se invalida cuando cambian el hotel o sus reservas
+func (service Service) Calendar(ctx context.Context, hotelID string, from, to time.Time) (hotelsDomain.Calendar, error) {
+	from, to = toDate(from), toDate(to)
+	if !to.After(from) {
+		return hotelsDomain.Calendar{}, hotelsDomain.ValidationError{Field: "to", Message: "must be after from"}
+	}
+	if len(availability.Nights(from, to)) > maxCalendarNights {
+		return hotelsDomain.Calendar{}, hotelsDomain.ValidationError{Field: "to", Message: fmt.Sprintf("can't be more than %d nights after from", maxCalendarNights)}
+	}
+
+	// Se intenta obtener el calendario de la cache
+	if record, err := service.cacheRepository.GetCalendar(ctx, hotelID, from, to); err == nil {
+		return calendarToDomain(record), nil
+	}
+
+	// Si no esta en la cache se calcula con el hotel y las reservas de la base principal
+	hotel, err := service.GetHotelByID(ctx, hotelID)
+	if err != nil {
+		return hotelsDomain.Calendar{}, err
+	}
+	reservations, err := service.mainRepository.GetReservationsInRange(ctx, hotelID, from, to)
+	if err != nil {
+		return hotelsDomain.Calendar{}, fmt.Errorf("error getting reservations from repository: %w", err)
+	}
+	// Las retenciones y las ofertas de la lista de espera tambien ocupan habitaciones
+	now := time.Now()
+	held, err := service.heldRooms(ctx, hotelID, "", "", now)
+	if err != nil {
+		return hotelsDomain.Calendar{}, err
+	}
+	calendar := calculateCalendar(hotel, append(held, reservations...), from, to, now)
+
+	// Se guarda el calendario en la cache
+	if err := service.cacheRepository.SetCalendar(ctx, calendarToDAO(calendar)); err != nil {
+		service.cacheFailed("error saving calendar in cache", err)
+	}
+	return calendar, nil
+}
+
+// Funcion que calcula el calendario sin acceder a los repositorios
+// Una noche se puede reservar si le quedan habitaciones y no es anterior a now
+func calculateCalendar(hotel hotelsDomain.Hotel, reservations []hotelsDAO.Reservation, from, to time.Time, now time.Time) hotelsDomain.Calendar {
+	reserved := availability.ReservedByNight(reservations, from, to)
+	quote := calculateQuote(hotel, from, to, 1, reserved)
+	today := toDate(now.UTC())
+
+	calendar := hotelsDomain.Calendar{
+		HotelID: hotel.ID,
+		From:    from,
+		To:      to,
+		Nights:  make([]hotelsDomain.CalendarNight, 0, len(quote.Nights)),
+	}
+	for _, night := range quote.Nights {
+		roomsLeft := hotel.AvaiableRooms - reserved[night.Date]
+		if roomsLeft < 0 {
+			roomsLeft = 0
+		}
+		calendar.Nights = append(calendar.Nights, hotelsDomain.CalendarNight{
+			Date:      night.Date,
+			RoomsLeft: roomsLeft,
+			Price:     night.Price,
+			Bookable:  roomsLeft > 0 && !night.Date.Before(today),
+		})
+	}
+	return calendar
+}
+
+// Funciones para pasar el calendario entre el formato de dominio y el de la cache
+func calendarToDAO(calendar hotelsDomain.Calendar) hotelsDAO.Calendar {
+	record := hotelsDAO.Calendar{
+		HotelID: calendar.HotelID,
+		From:    calendar.From,
+		To:      calendar.To,
+		Nights:  make([]hotelsDAO.CalendarNight, 0, len(calendar.Nights)),
+	}
+	for _, night := range calendar.Nights {
+		record.Nights = append(record.Nights, hotelsDAO.CalendarNight(night))
+	}
+	return record
+}
+
+func calendarToDomain(record hotelsDAO.Calendar) hotelsDomain.Calendar {
+	calendar := hotelsDomain.Calendar{
+		HotelID: record.HotelID,
+		From:    record.From,
+		To:      record.To,
+		Nights:  make([]hotelsDomain.CalendarNight, 0, len(record.Nights)),
+	}
+	for _, night := range record.Nights {
+		calendar.Nights = append(calendar.Nights, hotelsDomain.CalendarNight(night))
+	}
+	return calendar
+}
@@ -0,0 +1,35 @@
+package hotels
+
+import (
+	"testing"
+	"time"
+
+	hotelsDAO "hotels-api/dao/hotels"
+	hotelsDomain "hotels-api/domain/hotels"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateCalendar(t *testing.T) {
+	hotel := hotelsDomain.Hotel{
+		ID:            "hotel",
+		PricePerNight: 100,
+		AvaiableRooms: 2,
+		Pricing:       &hotelsDomain.Pricing{WeekendRate: 150},
+	}
+	reservations := []hotelsDAO.Reservation{
+		{CheckIn: date(2025, 1, 2), CheckOut: date(2025, 1, 4)},
+		{CheckIn: date(2025, 1, 3), CheckOut: date(2025, 1, 4), RoomCount: 1},
+	}
+	now := time.Date(2025, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	// Del miercoles 1 al sabado 4 de enero de 2025
+	calendar := calculateCalendar(hotel, reservations, date(2025, 1, 1), date(2025, 1, 5), now)
+	assert.Equal(t, "hotel", calendar.HotelID)
+	assert.Equal(t, []hotelsDomain.CalendarNight{
+		{Date: date(2025, 1, 1), RoomsLeft: 2, Price: 100, Bookable: false},
+		{Date: date(2025, 1, 2), RoomsLeft: 1, Price: 100, Bookable: true},
+		{Date: date(2025, 1, 3), RoomsLeft: 0, Price: 150, Bookable: false},
+		{Date: date(2025, 1, 4), RoomsLeft: 2, Price: 150, Bookable: true},
+	}, calendar.Nights)
+}
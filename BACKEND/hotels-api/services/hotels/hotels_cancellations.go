@@ -0,0 +1,277 @@
+package hotels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	hotelsDAO "hotels-api/dao/hotels"
+	hotelsDomain "hotels-api/domain/hotels"
+	"log"
+	"time"
+)
+
+// Politicas de cancelacion predefinidas, un hotel tambien puede definir las suyas con la politica custom
+// Cada regla devuelve su porcentaje si se cancela al menos esas horas antes del check-in; sin regla que aplique no se devuelve nada
+var cancellationPolicies = map[string][]hotelsDomain.CancellationRule{
+	"flexible":       {{HoursBeforeCheckIn: 24, RefundPercent: 100}},
+	"moderate":       {{HoursBeforeCheckIn: 120, RefundPercent: 100}, {HoursBeforeCheckIn: 48, RefundPercent: 50}},
+	"non_refundable": {},
+}
+
+const (
+	defaultCancellationPolicy = "flexible" // Politica de los hoteles que no definen una
+	customCancellationPolicy  = "custom"
+)
+
+// Funcion que calcula cuanto se cobraria y cuanto se devolveria si se cancelara la reserva ahora, sin cancelarla
+func (service Service) PreviewCancellation(ctx context.Context, id string, userID string) (hotelsDomain.Cancellation, error) {
+	reservation, hotel, err := service.cancellableReservation(ctx, id, userID)
+	if err != nil {
+		return hotelsDomain.Cancellation{}, err
+	}
+	return calculateCancellation(hotel, activeAmount(reservation), reservation.CheckIn, time.Now().UTC()), nil
+}
+
+// Funcion que cancela una reserva aplicando la politica de cancelacion del hotel
+// La reserva queda guardada como cancelada con la penalidad y el reembolso, y el pago se ajusta a lo que corresponde cobrar
+func (service Service) CancelReservation(ctx context.Context, id string, userID string) (hotelsDomain.Cancellation, error) {
+	reservation, hotel, err := service.cancellableReservation(ctx, id, userID)
+	if err != nil {
+		return hotelsDomain.Cancellation{}, err
+	}
+	cancellation := calculateCancellation(hotel, activeAmount(reservation), reservation.CheckIn, time.Now().UTC())
+
+	// El cliente termina pagando las penalidades, las de las habitaciones que cancelo antes y la de ahora
+	// Se ajusta el pago antes de cancelar, si falla la reserva sigue en pie
+	retained := roundPrice(reservation.TotalPrice - cancellation.Refund)
+	if err := service.settlePayment(ctx, id, retained, true, "cancel-"+id); err != nil {
+		return hotelsDomain.Cancellation{}, err
+	}
+
+	// Marca la reserva como cancelada en el repositorio principal (MongoDB)
+	if err := service.mainRepository.CancelReservation(ctx, id, cancellationToDAO(cancellation)); err != nil {
+		return hotelsDomain.Cancellation{}, fmt.Errorf("error canceling reservation from main repository: %w", err)
+	}
+
+	// Saca la reserva de la cache, la cancelada se vuelve a leer de la base principal
+	service.reservations.Delete(ctx, id)
+	if err := service.cacheRepository.InvalidateReservations(ctx, reservation.HotelID, reservation.UserID); err != nil {
+		service.cacheFailed("error invalidating reservations in cache", err)
+	}
+	if err := service.cacheRepository.InvalidateCalendars(ctx, reservation.HotelID); err != nil {
+		service.cacheFailed("error invalidating calendars in cache", err)
+	}
+	service.publishReservationsChanged(reservation.HotelID, reservation.UserID, id)
+
+	// Se libero lugar, se le ofrece a la lista de espera
+	// La cancelacion ya se hizo, asi que un error aca no la hace fallar
+	if err := service.ProcessWaitlist(ctx, reservation.HotelID); err != nil {
+		log.Printf("error processing waitlist of hotel %s: %v", reservation.HotelID, err)
+	}
+
+	return cancellation, nil
+}
+
+// Funcion que cancela una habitacion de una reserva aplicando la politica de cancelacion al precio de esa habitacion
+// Si era la ultima habitacion activa se cancela la reserva entera
+func (service Service) CancelReservationRoom(ctx context.Context, id string, roomID string, userID string) (hotelsDomain.Cancellation, error) {
+	reservation, hotel, err := service.cancellableReservation(ctx, id, userID)
+	if err != nil {
+		return hotelsDomain.Cancellation{}, err
+	}
+	var room *hotelsDAO.ReservationRoom
+	active := 0
+	for i := range reservation.Rooms {
+		if reservation.Rooms[i].Cancelled {
+			continue
+		}
+		active++
+		if reservation.Rooms[i].ID == roomID {
+			room = &reservation.Rooms[i]
+		}
+	}
+	if room == nil {
+		return hotelsDomain.Cancellation{}, fmt.Errorf("room %s in reservation %s: %w", roomID, id, hotelsDAO.ErrNotFound)
+	}
+	if active == 1 {
+		return service.CancelReservation(ctx, id, userID)
+	}
+	cancellation := calculateCancellation(hotel, room.Price, reservation.CheckIn, time.Now().UTC())
+
+	// Si ya se cobro se devuelve lo que corresponde, si solo esta autorizado se cobra el nuevo total al hacer el check-in
+	retained := roundPrice(reservation.TotalPrice - cancellation.Refund)
+	if err := service.settlePayment(ctx, id, retained, false, "cancel-"+id+"-"+roomID); err != nil {
+		return hotelsDomain.Cancellation{}, err
+	}
+
+	// Cancela la habitacion en el repositorio principal (MongoDB)
+	if _, err := service.mainRepository.CancelReservationRoom(ctx, id, roomID, cancellation.Penalty); err != nil {
+		return hotelsDomain.Cancellation{}, fmt.Errorf("error canceling reservation room from main repository: %w", err)
+	}
+
+	// Intenta cancelar la habitacion en el repositorio de cache
+	if _, err := service.cacheRepository.CancelReservationRoom(ctx, id, roomID, cancellation.Penalty); err != nil {
+		service.cacheFailed("error canceling reservation room from cache", err)
+	}
+	if err := service.cacheRepository.InvalidateReservations(ctx, reservation.HotelID, reservation.UserID); err != nil {
+		service.cacheFailed("error invalidating reservations in cache", err)
+	}
+	if err := service.cacheRepository.InvalidateCalendars(ctx, reservation.HotelID); err != nil {
+		service.cacheFailed("error invalidating calendars in cache", err)
+	}
+	service.publishReservationsChanged(reservation.HotelID, reservation.UserID, id)
+
+	// Se libero una habitacion, se le ofrece a la lista de espera
+	if err := service.ProcessWaitlist(ctx, reservation.HotelID); err != nil {
+		log.Printf("error processing waitlist of hotel %s: %v", reservation.HotelID, err)
+	}
+
+	return cancellation, nil
+}
+
+// Funcion que obtiene una reserva que se puede cancelar y su hotel, para la politica de cancelacion
+// Solo la puede cancelar el usuario que la hizo o un administrador del hotel
+// Si el hotel ya no existe se usa la politica por defecto
+func (service Service) cancellableReservation(ctx context.Context, id string, userID string) (hotelsDAO.Reservation, hotelsDomain.Hotel, error) {
+	reservation, err := service.mainRepository.GetReservationByID(ctx, id)
+	if err != nil {
+		return hotelsDAO.Reservation{}, hotelsDomain.Hotel{}, fmt.Errorf("error getting reservation from main repository: %w", err)
+	}
+	hotel, err := service.GetHotelByID(ctx, reservation.HotelID)
+	if err != nil && !errors.Is(err, hotelsDAO.ErrNotFound) {
+		return hotelsDAO.Reservation{}, hotelsDomain.Hotel{}, fmt.Errorf("error getting hotel: %w", err)
+	}
+	// Se verifica antes que el estado, para no contarle a otro usuario si la reserva esta cancelada
+	if !canAccessReservation(hotel, reservation, userID) {
+		return hotelsDAO.Reservation{}, hotelsDomain.Hotel{}, hotelsDomain.ForbiddenError{Message: fmt.Sprintf("user %s can't access reservation %s", userID, id)}
+	}
+	if reservation.Status == hotelsDAO.ReservationCancelled {
+		return hotelsDAO.Reservation{}, hotelsDomain.Hotel{}, hotelsDomain.UnprocessableError{Field: "reservation", Message: "is already cancelled"}
+	}
+	return reservation, hotel, nil
+}
+
+// Funcion que calcula la penalidad y el reembolso de cancelar amount en now, segun la politica del hotel
+// Se aplica la regla de mayor anticipacion que se cumpla; el check-in es a la hora de check-in del hotel, si la tiene
+func calculateCancellation(hotel hotelsDomain.Hotel, amount float64, checkIn time.Time, now time.Time) hotelsDomain.Cancellation {
+	name, rules := cancellationRules(hotel.CancellationPolicy)
+	checkInAt := toDate(checkIn)
+	if !hotel.CheckInTime.IsZero() {
+		clock := hotel.CheckInTime.UTC()
+		checkInAt = checkInAt.Add(time.Duration(clock.Hour())*time.Hour + time.Duration(clock.Minute())*time.Minute)
+	}
+	hoursLeft := checkInAt.Sub(now).Hours()
+
+	percent := 0.0
+	applied := -1
+	for _, rule := range rules {
+		if hoursLeft >= float64(rule.HoursBeforeCheckIn) && rule.HoursBeforeCheckIn > applied {
+			applied = rule.HoursBeforeCheckIn
+			percent = rule.RefundPercent
+		}
+	}
+
+	amount = roundPrice(amount)
+	refund := roundPrice(amount * percent / 100)
+	return hotelsDomain.Cancellation{
+		Policy:        name,
+		RefundPercent: percent,
+		Amount:        amount,
+		Penalty:       roundPrice(amount - refund),
+		Refund:        refund,
+		CancelledAt:   now,
+	}
+}
+
+// Funcion que devuelve el nombre y las reglas de una politica, usando la politica por defecto si el hotel no tiene
+func cancellationRules(policy *hotelsDomain.CancellationPolicy) (string, []hotelsDomain.CancellationRule) {
+	if policy == nil || policy.Name == "" {
+		return defaultCancellationPolicy, cancellationPolicies[defaultCancellationPolicy]
+	}
+	if policy.Name == customCancellationPolicy {
+		return policy.Name, policy.Rules
+	}
+	return policy.Name, cancellationPolicies[policy.Name]
+}
+
+// Funcion que valida la politica de cancelacion de un hotel: una predefinida sin reglas o una custom con reglas
+func validateCancellationPolicy(policy *hotelsDomain.CancellationPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	if policy.Name != customCancellationPolicy {
+		if _, ok := cancellationPolicies[policy.Name]; !ok {
+			return hotelsDomain.ValidationError{Field: "cancellation_policy", Message: fmt.Sprintf("unknown policy %q", policy.Name)}
+		}
+		if len(policy.Rules) > 0 {
+			return hotelsDomain.ValidationError{Field: "cancellation_policy", Message: "rules are only allowed in custom policies"}
+		}
+		return nil
+	}
+	if len(policy.Rules) == 0 {
+		return hotelsDomain.ValidationError{Field: "cancellation_policy", Message: "custom policies need at least one rule"}
+	}
+	hours := make(map[int]bool)
+	for _, rule := range policy.Rules {
+		if rule.HoursBeforeCheckIn < 0 {
+			return hotelsDomain.ValidationError{Field: "cancellation_policy", Message: "hours_before_check_in can't be negative"}
+		}
+		if rule.RefundPercent < 0 || rule.RefundPercent > 100 {
+			return hotelsDomain.ValidationError{Field: "cancellation_policy", Message: "refund_percent must be between 0 and 100"}
+		}
+		if hours[rule.HoursBeforeCheckIn] {
+			return hotelsDomain.ValidationError{Field: "cancellation_policy", Message: fmt.Sprintf("more than one rule for %d hours", rule.HoursBeforeCheckIn)}
+		}
+		hours[rule.HoursBeforeCheckIn] = true
+	}
+	return nil
+}
+
+// Precio de las habitaciones que siguen activas, que es lo que se cancela al cancelar la reserva entera
+func activeAmount(reservation hotelsDAO.Reservation) float64 {
+	if len(reservation.Rooms) == 0 {
+		return reservation.TotalPrice
+	}
+	amount := 0.0
+	for _, room := range reservation.Rooms {
+		if !room.Cancelled {
+			amount += room.Price
+		}
+	}
+	return amount
+}
+
+// Funciones para pasar las politicas y las cancelaciones entre el formato de dominio y el de base de datos
+func cancellationPolicyToDAO(policy *hotelsDomain.CancellationPolicy) *hotelsDAO.CancellationPolicy {
+	if policy == nil {
+		return nil
+	}
+	record := &hotelsDAO.CancellationPolicy{Name: policy.Name}
+	for _, rule := range policy.Rules {
+		record.Rules = append(record.Rules, hotelsDAO.CancellationRule(rule))
+	}
+	return record
+}
+
+func cancellationPolicyToDomain(record *hotelsDAO.CancellationPolicy) *hotelsDomain.CancellationPolicy {
+	if record == nil {
+		return nil
+	}
+	policy := &hotelsDomain.CancellationPolicy{Name: record.Name}
+	for _, rule := range record.Rules {
+		policy.Rules = append(policy.Rules, hotelsDomain.CancellationRule(rule))
+	}
+	return policy
+}
+
+func cancellationToDAO(cancellation hotelsDomain.Cancellation) hotelsDAO.Cancellation {
+	return hotelsDAO.Cancellation(cancellation)
+}
+
+func cancellationToDomain(record *hotelsDAO.Cancellation) *hotelsDomain.Cancellation {
+	if record == nil {
+		return nil
+	}
+	cancellation := hotelsDomain.Cancellation(*record)
+	return &cancellation
+}
@@ -0,0 +1,72 @@
+package hotels
+
+import (
+	"testing"
+	"time"
+
+	hotelsDomain "hotels-api/domain/hotels"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateCancellation(t *testing.T) {
+	checkIn := date(2025, 6, 10)
+	moderate := hotelsDomain.Hotel{CancellationPolicy: &hotelsDomain.CancellationPolicy{Name: "moderate"}}
+
+	tests := []struct {
+		name    string
+		hotel   hotelsDomain.Hotel
+		now     time.Time
+		policy  string
+		penalty float64
+		refund  float64
+	}{
+		{"Flexible by default, a day before", hotelsDomain.Hotel{}, checkIn.Add(-24 * time.Hour), "flexible", 0, 300},
+		{"Flexible by default, too late", hotelsDomain.Hotel{}, checkIn.Add(-23 * time.Hour), "flexible", 300, 0},
+		{"Moderate, a week before", moderate, checkIn.AddDate(0, 0, -7), "moderate", 0, 300},
+		{"Moderate, three days before", moderate, checkIn.AddDate(0, 0, -3), "moderate", 150, 150},
+		{"Moderate, the same day", moderate, checkIn, "moderate", 300, 0},
+		{"Non refundable", hotelsDomain.Hotel{CancellationPolicy: &hotelsDomain.CancellationPolicy{Name: "non_refundable"}}, checkIn.AddDate(0, 1, 0), "non_refundable", 300, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cancellation := calculateCancellation(test.hotel, 300, checkIn, test.now)
+			assert.Equal(t, test.policy, cancellation.Policy)
+			assert.Equal(t, test.penalty, cancellation.Penalty)
+			assert.Equal(t, test.refund, cancellation.Refund)
+			assert.Equal(t, 300.0, cancellation.Amount)
+		})
+	}
+}
+
+func TestCalculateCancellationAtCheckInTime(t *testing.T) {
+	// Con check-in a las 15, cancelar a las 16 del dia anterior todavia es 23 horas antes
+	hotel := hotelsDomain.Hotel{
+		CheckInTime: time.Date(0, 1, 1, 15, 0, 0, 0, time.UTC),
+		CancellationPolicy: &hotelsDomain.CancellationPolicy{Name: "custom", Rules: []hotelsDomain.CancellationRule{
+			{HoursBeforeCheckIn: 24, RefundPercent: 100},
+			{HoursBeforeCheckIn: 12, RefundPercent: 25},
+		}},
+	}
+	cancellation := calculateCancellation(hotel, 200, date(2025, 6, 10), time.Date(2025, 6, 9, 16, 0, 0, 0, time.UTC))
+	assert.Equal(t, 25.0, cancellation.RefundPercent)
+	assert.Equal(t, 50.0, cancellation.Refund)
+	assert.Equal(t, 150.0, cancellation.Penalty)
+}
+
+func TestValidateCancellationPolicy(t *testing.T) {
+	assert.NoError(t, validateCancellationPolicy(nil))
+	assert.NoError(t, validateCancellationPolicy(&hotelsDomain.CancellationPolicy{Name: "moderate"}))
+	assert.NoError(t, validateCancellationPolicy(&hotelsDomain.CancellationPolicy{Name: "custom", Rules: []hotelsDomain.CancellationRule{{HoursBeforeCheckIn: 72, RefundPercent: 80}}}))
+
+	invalid := []*hotelsDomain.CancellationPolicy{
+		{Name: "strict"},
+		{Name: "flexible", Rules: []hotelsDomain.CancellationRule{{HoursBeforeCheckIn: 72, RefundPercent: 80}}},
+		{Name: "custom"},
+		{Name: "custom", Rules: []hotelsDomain.CancellationRule{{HoursBeforeCheckIn: 72, RefundPercent: 120}}},
+		{Name: "custom", Rules: []hotelsDomain.CancellationRule{{HoursBeforeCheckIn: 72, RefundPercent: 80}, {HoursBeforeCheckIn: 72, RefundPercent: 50}}},
+	}
+	for _, policy := range invalid {
+		assert.ErrorAs(t, validateCancellationPolicy(policy), &hotelsDomain.ValidationError{}, policy.Name)
+	}
+}
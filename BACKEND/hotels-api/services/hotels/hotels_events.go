@@ -0,0 +1,73 @@
+package hotels
+
+import (
+	"context"
+	"fmt"
+	hotelsDomain "hotels-api/domain/hotels"
+	"log"
+)
+
+// Funcion que recibe los eventos de hoteles de todas las replicas (incluida esta) y saca de la cache local lo que
+// quedo viejo. Sin esto, una replica seguiria devolviendo un hotel modificado en otra hasta que venza la cache
+func (service Service) HandleHotelNew(hotelNew hotelsDomain.HotelNew) {
+	if err := service.evictHotel(context.Background(), hotelNew); err != nil {
+		log.Printf("error evicting hotel %s after %s event: %v", hotelNew.HotelID, hotelNew.Operation, err)
+	}
+}
+
+// Funcion que decide segun el evento si hay que borrar el hotel y sus calendarios de la cache local
+// Los eventos pueden llegar repetidos o desordenados: si la copia local tiene una version igual o mayor a la del
+// evento ya esta al dia (por ejemplo la replica que hizo el cambio) y no se toca
+func (service Service) evictHotel(ctx context.Context, hotelNew hotelsDomain.HotelNew) error {
+	switch hotelNew.Operation {
+	case "CREATE":
+		// Solo puede haber quedado guardado que el hotel no existia
+		if _, err := service.cacheRepository.GetHotelByID(ctx, hotelNew.HotelID); err == nil {
+			return nil
+		}
+		if err := service.cacheRepository.Delete(ctx, hotelNew.HotelID); err != nil {
+			return fmt.Errorf("error deleting hotel from cache: %w", err)
+		}
+	case "UPDATE", "DELETE":
+		if cached, err := service.cacheRepository.GetHotelByID(ctx, hotelNew.HotelID); err == nil && cached.Version >= hotelNew.Version {
+			return nil
+		}
+		if err := service.cacheRepository.Delete(ctx, hotelNew.HotelID); err != nil {
+			return fmt.Errorf("error deleting hotel from cache: %w", err)
+		}
+		if err := service.cacheRepository.InvalidateCalendars(ctx, hotelNew.HotelID); err != nil {
+			return fmt.Errorf("error invalidating calendars in cache: %w", err)
+		}
+	case "RESERVATIONS":
+		// Cambiaron las reservas o las retenciones del hotel en alguna replica, lo que dependa de ellas se vuelve a leer
+		if hotelNew.ReservationID != "" {
+			if err := service.cacheRepository.DeleteReservation(ctx, hotelNew.ReservationID); err != nil {
+				return fmt.Errorf("error deleting reservation from cache: %w", err)
+			}
+		}
+		if err := service.cacheRepository.InvalidateReservations(ctx, hotelNew.HotelID, hotelNew.UserID); err != nil {
+			return fmt.Errorf("error invalidating reservations in cache: %w", err)
+		}
+		if err := service.cacheRepository.InvalidateHolds(ctx, hotelNew.HotelID); err != nil {
+			return fmt.Errorf("error invalidating holds in cache: %w", err)
+		}
+		if err := service.cacheRepository.InvalidateCalendars(ctx, hotelNew.HotelID); err != nil {
+			return fmt.Errorf("error invalidating calendars in cache: %w", err)
+		}
+	}
+	return nil
+}
+
+// Funcion que avisa a todas las replicas que cambiaron las reservas o las retenciones de un hotel, para que saquen de
+// su cache local las listas de reservas, las retenciones y los calendarios del hotel, y la reserva si hay
+// Esta replica ya invalido su cache, asi que si no se puede avisar solo se registra: en las demas vence sola
+func (service Service) publishReservationsChanged(hotelID, userID, reservationID string) {
+	if err := service.eventsQueue.Publish(hotelsDomain.HotelNew{
+		Operation:     "RESERVATIONS",
+		HotelID:       hotelID,
+		ReservationID: reservationID,
+		UserID:        userID,
+	}); err != nil {
+		log.Printf("error publishing reservations change of hotel %s: %v", hotelID, err)
+	}
+}
@@ -0,0 +1,245 @@
+package hotels
+
+import (
+	"context"
+	"fmt"
+	hotelsDAO "hotels-api/dao/hotels"
+	hotelsDomain "hotels-api/domain/hotels"
+	"log"
+	"time"
+)
+
+// Tiempo que dura una retencion si no se confirma antes
+const holdDuration = 15 * time.Minute
+
+// Funcion que retiene habitaciones mientras el usuario termina de pagar
+// Se valida igual que una reserva, y mientras no vence sus habitaciones no se pueden reservar ni retener
+func (service Service) CreateHold(ctx context.Context, hold hotelsDomain.Hold) (hotelsDomain.Hold, error) {
+	reservation := hotelsDomain.Reservation{
+		HotelID:   hold.HotelID,
+		UserID:    hold.UserID,
+		CheckIn:   hold.CheckIn,
+		CheckOut:  hold.CheckOut,
+		Guests:    hold.Guests,
+		RoomCount: hold.RoomCount,
+		Rooms:     hold.Rooms,
+	}
+
+	// La estadia se valida antes de bloquear el hotel, porque consulta a users-api
+	hotel, _, err := service.checkStay(ctx, &reservation, "")
+	if err != nil {
+		return hotelsDomain.Hold{}, err
+	}
+
+	// Se bloquea el hotel igual que al reservar, para que nadie tome las habitaciones mientras se retienen
+	var record hotelsDAO.Hold
+	err = service.withHotelLock(ctx, reservation.HotelID, func(lock hotelLock) error {
+		if _, err := service.checkRoomsFree(ctx, hotel, reservation, ""); err != nil {
+			return err
+		}
+		if err := lock.confirm(ctx); err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		record = hotelsDAO.Hold{
+			HotelID:   reservation.HotelID,
+			UserID:    reservation.UserID,
+			CheckIn:   reservation.CheckIn,
+			CheckOut:  reservation.CheckOut,
+			Guests:    reservation.Guests,
+			RoomCount: reservation.RoomCount,
+			Rooms:     reservationRoomsToDAO(reservation.Rooms),
+			CreatedAt: now,
+			ExpiresAt: now.Add(holdDuration),
+		}
+		id, err := service.mainRepository.CreateHold(ctx, record)
+		if err != nil {
+			return fmt.Errorf("error creating hold in main repository: %w", err)
+		}
+		record.ID = id
+		return nil
+	})
+	if err != nil {
+		return hotelsDomain.Hold{}, err
+	}
+
+	// La retencion ocupa habitaciones en los calendarios y en la disponibilidad del hotel
+	if err := service.cacheRepository.InvalidateHolds(ctx, record.HotelID); err != nil {
+		service.cacheFailed("error invalidating holds in cache", err)
+	}
+	if err := service.cacheRepository.InvalidateCalendars(ctx, record.HotelID); err != nil {
+		service.cacheFailed("error invalidating calendars in cache", err)
+	}
+	service.publishReservationsChanged(record.HotelID, "", "")
+	return holdToDomain(record), nil
+}
+
+// Funcion que convierte una retencion vigente en una reserva confirmada
+// Las fechas, el usuario y las habitaciones salen de la retencion; de la reserva solo se usan los datos de contacto
+// y el token de pago, que se tiene que autorizar igual que al reservar directamente
+// Solo la puede confirmar el usuario que la hizo, la reserva queda a su nombre
+func (service Service) ConfirmHold(ctx context.Context, id string, userID string, details hotelsDomain.Reservation) (string, error) {
+	key := details.IdempotencyKey
+	details.IdempotencyKey = ""
+	return service.idempotent(ctx, key, userID, "hold-"+id, details, func(key string) (string, error) {
+		details.IdempotencyKey = key
+		return service.confirmHold(ctx, id, userID, details)
+	})
+}
+
+func (service Service) confirmHold(ctx context.Context, id string, userID string, details hotelsDomain.Reservation) (string, error) {
+	hold, err := service.mainRepository.GetHoldByID(ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("error getting hold from main repository: %w", err)
+	}
+	if userID == "" || userID != hold.UserID {
+		return "", hotelsDomain.ForbiddenError{Message: fmt.Sprintf("user %s can't confirm hold %s", userID, hold.ID)}
+	}
+	// Si ya vencio no se autoriza el pago; la retencion se vuelve a verificar al reclamarla con el hotel bloqueado
+	if !hold.ExpiresAt.After(time.Now()) {
+		return "", hotelsDomain.UnprocessableError{Field: "hold", Message: "is expired"}
+	}
+
+	// Las habitaciones se vuelven a cotizar y se les asigna un ID nuevo al reservar
+	rooms := reservationRoomsToDomain(hold.Rooms)
+	for i := range rooms {
+		rooms[i].ID = ""
+		rooms[i].Price = 0
+	}
+	reservationID, err := service.createReservation(ctx, hotelsDomain.Reservation{
+		HotelID:         hold.HotelID,
+		UserID:          hold.UserID,
+		CheckIn:         hold.CheckIn,
+		CheckOut:        hold.CheckOut,
+		Rooms:           rooms,
+		SpecialRequests: details.SpecialRequests,
+		ContactName:     details.ContactName,
+		ContactEmail:    details.ContactEmail,
+		ContactPhone:    details.ContactPhone,
+		PaymentToken:    details.PaymentToken,
+		IdempotencyKey:  details.IdempotencyKey,
+	}, hold.ID)
+	if err != nil {
+		return "", err
+	}
+
+	// La reserva ya ocupa las habitaciones de la retencion, que se reclamo al reservar
+	if err := service.cacheRepository.InvalidateHolds(ctx, hold.HotelID); err != nil {
+		service.cacheFailed("error invalidating holds in cache", err)
+	}
+	return reservationID, nil
+}
+
+// Funcion que libera una retencion antes de que venza, por ejemplo si el usuario abandona el pago
+// La puede liberar el usuario que la hizo o un administrador del hotel
+func (service Service) ReleaseHold(ctx context.Context, id string, userID string) error {
+	hold, err := service.mainRepository.GetHoldByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("error getting hold from main repository: %w", err)
+	}
+	reservation := hold.AsReservation()
+	reservation.ID = hold.ID
+	if err := service.authorizeReservation(ctx, reservation, userID); err != nil {
+		return err
+	}
+	if err := service.mainRepository.DeleteHold(ctx, id); err != nil {
+		return fmt.Errorf("error deleting hold from main repository: %w", err)
+	}
+	return service.roomsReleased(ctx, hold.HotelID)
+}
+
+// Funcion que elimina las retenciones vencidas y le ofrece ese lugar a la lista de espera
+func (service Service) ReleaseExpiredHolds(ctx context.Context) error {
+	hotelIDs, err := service.mainRepository.DeleteExpiredHolds(ctx, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("error deleting expired holds: %w", err)
+	}
+	for _, hotelID := range hotelIDs {
+		if err := service.roomsReleased(ctx, hotelID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Funcion que se llama cuando un hotel recupera habitaciones sin que se cancele una reserva
+func (service Service) roomsReleased(ctx context.Context, hotelID string) error {
+	if err := service.cacheRepository.InvalidateHolds(ctx, hotelID); err != nil {
+		service.cacheFailed("error invalidating holds in cache", err)
+	}
+	if err := service.cacheRepository.InvalidateCalendars(ctx, hotelID); err != nil {
+		service.cacheFailed("error invalidating calendars in cache", err)
+	}
+	service.publishReservationsChanged(hotelID, "", "")
+	if err := service.ProcessWaitlist(ctx, hotelID); err != nil {
+		log.Printf("error processing waitlist of hotel %s: %v", hotelID, err)
+	}
+	return nil
+}
+
+// Funcion que devuelve, como reservas, las habitaciones ocupadas de un hotel que todavia no son reservas:
+// las retenciones vigentes y las ofertas de la lista de espera
+// No se cuentan las ofertas de userID, que ese usuario puede usar para reservar, ni la retencion holdID
+func (service Service) heldRooms(ctx context.Context, hotelID, userID, holdID string, now time.Time) ([]hotelsDAO.Reservation, error) {
+	holds, err := service.mainRepository.GetActiveHolds(ctx, hotelID, now)
+	if err != nil {
+		return nil, fmt.Errorf("error getting holds from main repository: %w", err)
+	}
+	offers, err := service.activeWaitlistOffers(ctx, hotelID, now)
+	if err != nil {
+		return nil, err
+	}
+	// Se guardan juntas para la disponibilidad desde la cache, que descarta las que vencen
+	occupying := append(append(make([]hotelsDAO.Hold, 0, len(holds)+len(offers)), holds...), offers...)
+	if err := service.cacheRepository.SetActiveHolds(ctx, hotelID, occupying); err != nil {
+		service.cacheFailed("error caching holds", err)
+	}
+
+	held := make([]hotelsDAO.Reservation, 0, len(occupying))
+	for _, hold := range holds {
+		if hold.ID != holdID {
+			held = append(held, hold.AsReservation())
+		}
+	}
+	for _, offer := range offers {
+		if offer.UserID != userID {
+			held = append(held, offer.AsReservation())
+		}
+	}
+	return held, nil
+}
+
+// Funcion que vence las ofertas de la lista de espera y libera las retenciones vencidas cada interval,
+// hasta que se cancela el contexto
+func (service Service) RunSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := service.ReleaseExpiredHolds(ctx); err != nil {
+				log.Printf("error releasing expired holds: %v", err)
+			}
+			if err := service.ExpireWaitlistOffers(ctx); err != nil {
+				log.Printf("error sweeping waitlist offers: %v", err)
+			}
+		}
+	}
+}
+
+func holdToDomain(record hotelsDAO.Hold) hotelsDomain.Hold {
+	return hotelsDomain.Hold{
+		ID:        record.ID,
+		HotelID:   record.HotelID,
+		UserID:    record.UserID,
+		CheckIn:   record.CheckIn,
+		CheckOut:  record.CheckOut,
+		Guests:    record.Guests,
+		RoomCount: record.RoomCount,
+		Rooms:     reservationRoomsToDomain(record.Rooms),
+		CreatedAt: record.CreatedAt,
+		ExpiresAt: record.ExpiresAt,
+	}
+}
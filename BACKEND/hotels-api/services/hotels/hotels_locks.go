@@ -0,0 +1,71 @@
+package hotels
+
+import (
+	"context"
+	hotelsDomain "hotels-api/domain/hotels"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	hotelLockLease = 10 * time.Second      // Si la instancia que tiene el lock se cae, a los 10 segundos lo puede tomar otra
+	hotelLockWait  = 5 * time.Second       // Tiempo maximo de espera antes de devolver que el hotel esta ocupado
+	hotelLockRetry = 50 * time.Millisecond // Espera entre intentos
+)
+
+// Lock de un hotel tomado por una peticion
+type hotelLock struct {
+	service Service
+	name    string
+	owner   string
+}
+
+// Funcion que verifica que el lock siga siendo de esta peticion y lo extiende otro hotelLockLease
+// Se llama justo antes de guardar: si el lock vencio mientras tanto, otra peticion pudo haber tomado el hotel y lo
+// verificado ya no vale, entonces devuelve un hotelsDomain.ConflictError
+func (lock hotelLock) confirm(ctx context.Context) error {
+	held, err := lock.service.mainRepository.RenewLock(ctx, lock.name, lock.owner, time.Now().UTC(), hotelLockLease)
+	if err != nil {
+		return err
+	}
+	if !held {
+		return hotelsDomain.ConflictError{Message: "the hotel lock expired before saving, try again"}
+	}
+	return nil
+}
+
+// Funcion que ejecuta run con el hotel bloqueado, asi las verificaciones de disponibilidad y el guardado de una reserva,
+// una retencion o una modificacion no se mezclan con las de otra peticion sobre el mismo hotel, aunque sea de otra instancia
+// Si no se consigue el lock en hotelLockWait devuelve un hotelsDomain.ConflictError
+// run no tiene que llamar a otros servicios, y antes de guardar tiene que confirmar que el lock sigue siendo suyo
+func (service Service) withHotelLock(ctx context.Context, hotelID string, run func(lock hotelLock) error) error {
+	name := "hotel:" + hotelID
+	owner := uuid.New().String()
+	deadline := time.Now().Add(hotelLockWait)
+	for {
+		acquired, err := service.mainRepository.AcquireLock(ctx, name, owner, time.Now().UTC(), hotelLockLease)
+		if err != nil {
+			return err
+		}
+		if acquired {
+			break
+		}
+		if time.Now().After(deadline) {
+			return hotelsDomain.ConflictError{Message: "the hotel is busy with another reservation, try again"}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(hotelLockRetry):
+		}
+	}
+	defer func() {
+		// Si no se puede liberar se libera solo cuando vence
+		if err := service.mainRepository.ReleaseLock(context.Background(), name, owner); err != nil {
+			log.Printf("error releasing lock of hotel %s: %v", hotelID, err)
+		}
+	}()
+	return run(hotelLock{service: service, name: name, owner: owner})
+}
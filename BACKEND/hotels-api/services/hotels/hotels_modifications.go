@@ -0,0 +1,213 @@
+package hotels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	hotelsDAO "hotels-api/dao/hotels"
+	hotelsDomain "hotels-api/domain/hotels"
+	"hotels-api/services/availability"
+	"log"
+	"time"
+)
+
+// Veces que se reintenta guardar una modificacion si al mismo tiempo se cancelo una habitacion de la reserva
+const reservationUpdateAttempts = 3
+
+// Funcion que cambia las fechas o el tipo de habitacion de una reserva sin perder las habitaciones que ya tiene
+// La disponibilidad del nuevo rango se verifica con el hotel bloqueado y sin contar las noches de la propia reserva,
+// y las habitaciones se vuelven a cotizar. El nuevo precio no puede superar lo autorizado en el pago
+// Solo la puede cambiar el usuario que la hizo o un administrador del hotel
+func (service Service) ModifyReservation(ctx context.Context, id string, userID string, change hotelsDomain.ReservationChange) (hotelsDomain.Reservation, error) {
+	current, err := service.mainRepository.GetReservationByID(ctx, id)
+	if err != nil {
+		return hotelsDomain.Reservation{}, fmt.Errorf("error getting reservation from main repository: %w", err)
+	}
+	if err := service.authorizeReservation(ctx, current, userID); err != nil {
+		return hotelsDomain.Reservation{}, err
+	}
+
+	var previous, updated hotelsDAO.Reservation
+	err = service.withHotelLock(ctx, current.HotelID, func(lock hotelLock) error {
+		for attempt := 1; ; attempt++ {
+			// Se vuelve a leer con el hotel bloqueado, pudo cambiar mientras se esperaba el lock
+			reservation, err := service.mainRepository.GetReservationByID(ctx, id)
+			if err != nil {
+				return fmt.Errorf("error getting reservation from main repository: %w", err)
+			}
+			previous = reservation
+			updated, err = service.modifyReservation(ctx, lock, reservation, change, time.Now().UTC())
+			// Las cancelaciones no bloquean el hotel, si se cancelo una habitacion mientras tanto se vuelve a intentar
+			if !errors.Is(err, hotelsDAO.ErrConflict) || attempt == reservationUpdateAttempts {
+				return err
+			}
+		}
+	})
+	if err != nil {
+		return hotelsDomain.Reservation{}, err
+	}
+
+	// Cambiaron las noches ocupadas del hotel, y las que se liberaron se le ofrecen a la lista de espera
+	if err := service.cacheRepository.InvalidateCalendars(ctx, updated.HotelID); err != nil {
+		service.cacheFailed("error invalidating calendars in cache", err)
+	}
+	service.publishReservationsChanged(updated.HotelID, updated.UserID, updated.ID)
+	if err := service.ProcessWaitlist(ctx, updated.HotelID); err != nil {
+		log.Printf("error processing waitlist of hotel %s: %v", updated.HotelID, err)
+	}
+
+	// La modificacion ya se hizo, asi que si no se puede avisar solo se registra
+	if err := service.reservationsQueue.PublishReservation(hotelsDomain.ReservationNew{
+		Operation:        "UPDATE",
+		ReservationID:    updated.ID,
+		HotelID:          updated.HotelID,
+		UserID:           updated.UserID,
+		CheckIn:          updated.CheckIn,
+		CheckOut:         updated.CheckOut,
+		PreviousCheckIn:  previous.CheckIn,
+		PreviousCheckOut: previous.CheckOut,
+		TotalPrice:       updated.TotalPrice,
+	}); err != nil {
+		log.Printf("error publishing reservation update %s: %v", updated.ID, err)
+	}
+
+	return reservationToDomain(updated), nil
+}
+
+// Funcion que verifica, cotiza y guarda el cambio de una reserva, con el hotel ya bloqueado
+func (service Service) modifyReservation(ctx context.Context, lock hotelLock, reservation hotelsDAO.Reservation, change hotelsDomain.ReservationChange, now time.Time) (hotelsDAO.Reservation, error) {
+	if reservation.Status == hotelsDAO.ReservationCancelled {
+		return hotelsDAO.Reservation{}, hotelsDomain.UnprocessableError{Field: "reservation", Message: "is cancelled"}
+	}
+	hotel, err := service.GetHotelByID(ctx, reservation.HotelID)
+	if err != nil {
+		return hotelsDAO.Reservation{}, fmt.Errorf("error getting hotel: %w", err)
+	}
+	modified, err := applyReservationChange(hotel, reservation, change, now)
+	if err != nil {
+		return hotelsDAO.Reservation{}, err
+	}
+
+	// Las noches de la propia reserva no cuentan como ocupadas, si no no se podria correr un dia
+	reservations, err := service.mainRepository.GetReservationsInRange(ctx, hotel.ID, toDate(modified.CheckIn), toDate(modified.CheckOut))
+	if err != nil {
+		return hotelsDAO.Reservation{}, fmt.Errorf("error getting reservations from repository: %w", err)
+	}
+	others := make([]hotelsDAO.Reservation, 0, len(reservations))
+	for _, other := range reservations {
+		if other.ID != reservation.ID {
+			others = append(others, other)
+		}
+	}
+	held, err := service.heldRooms(ctx, hotel.ID, reservation.UserID, "", now)
+	if err != nil {
+		return hotelsDAO.Reservation{}, err
+	}
+	if err := checkRoomsAvailable(hotel, append(held, others...), modified); err != nil {
+		return hotelsDAO.Reservation{}, err
+	}
+
+	// Se vuelven a cotizar las habitaciones activas; las canceladas conservan su penalidad
+	repriceReservation(hotel, &modified, availability.ReservedByNight(others, toDate(modified.CheckIn), toDate(modified.CheckOut)))
+
+	// El pago se autorizo por el precio original, no se puede cobrar mas sin una autorizacion nueva
+	payment, err := service.mainRepository.GetPaymentByReservationID(ctx, reservation.ID)
+	if err != nil && !errors.Is(err, hotelsDAO.ErrNotFound) {
+		return hotelsDAO.Reservation{}, fmt.Errorf("error getting payment from main repository: %w", err)
+	}
+	if err == nil {
+		if payment.Status != hotelsDAO.PaymentAuthorized {
+			return hotelsDAO.Reservation{}, hotelsDomain.UnprocessableError{Field: "payment", Message: fmt.Sprintf("is %s, the reservation can't be modified", payment.Status)}
+		}
+		if modified.TotalPrice > payment.AuthorizedAmount {
+			return hotelsDAO.Reservation{}, hotelsDomain.UnprocessableError{Field: "total_price", Message: fmt.Sprintf("%.2f exceeds the authorized %.2f, cancel and book again", modified.TotalPrice, payment.AuthorizedAmount)}
+		}
+	}
+
+	// Lo verificado solo vale si el hotel sigue bloqueado por esta peticion
+	if err := lock.confirm(ctx); err != nil {
+		return hotelsDAO.Reservation{}, err
+	}
+	if err := service.mainRepository.UpdateReservation(ctx, modified); err != nil {
+		return hotelsDAO.Reservation{}, fmt.Errorf("error updating reservation in main repository: %w", err)
+	}
+	modified.Version++
+	service.reservations.Set(ctx, modified.ID, modified)
+	if err := service.cacheRepository.InvalidateReservations(ctx, modified.HotelID, modified.UserID); err != nil {
+		service.cacheFailed("error invalidating reservations in cache", err)
+	}
+	return modified, nil
+}
+
+// Funcion que aplica un cambio a una reserva sin acceder a los repositorios: valida las fechas nuevas y el tipo de
+// habitacion y devuelve la reserva con el cambio, todavia sin cotizar
+func applyReservationChange(hotel hotelsDomain.Hotel, reservation hotelsDAO.Reservation, change hotelsDomain.ReservationChange, now time.Time) (hotelsDAO.Reservation, error) {
+	modified := reservation
+	if !change.CheckIn.IsZero() {
+		modified.CheckIn = change.CheckIn
+	}
+	if !change.CheckOut.IsZero() {
+		modified.CheckOut = change.CheckOut
+	}
+	if !toDate(modified.CheckOut).After(toDate(modified.CheckIn)) {
+		return hotelsDAO.Reservation{}, hotelsDomain.ValidationError{Field: "check_out", Message: "must be after check_in"}
+	}
+	if toDate(modified.CheckIn).Before(toDate(now)) {
+		return hotelsDAO.Reservation{}, hotelsDomain.ValidationError{Field: "check_in", Message: "can't be in the past"}
+	}
+
+	// Se copian las habitaciones para no modificar las de la reserva original
+	changed := !toDate(modified.CheckIn).Equal(toDate(reservation.CheckIn)) || !toDate(modified.CheckOut).Equal(toDate(reservation.CheckOut))
+	modified.Rooms = append([]hotelsDAO.ReservationRoom(nil), reservation.Rooms...)
+	if change.RoomType != "" {
+		roomType, ok := findRoomType(roomTypesOf(hotel), change.RoomType)
+		if !ok {
+			return hotelsDAO.Reservation{}, hotelsDomain.UnprocessableError{Field: "room_type", Message: fmt.Sprintf("room type %s doesn't exist in the hotel", change.RoomType)}
+		}
+		for i := range modified.Rooms {
+			if modified.Rooms[i].Cancelled || modified.Rooms[i].RoomType == roomType.Code {
+				continue
+			}
+			if maxGuests := roomMaxGuests(hotel, roomType); modified.Rooms[i].Guests > maxGuests {
+				return hotelsDAO.Reservation{}, hotelsDomain.UnprocessableError{Field: "guests", Message: fmt.Sprintf("exceeds %d guests per %s room", maxGuests, roomType.Code)}
+			}
+			modified.Rooms[i].RoomType = roomType.Code
+			changed = true
+		}
+	}
+	if !changed {
+		return hotelsDAO.Reservation{}, hotelsDomain.ValidationError{Field: "check_in", Message: "nothing to change"}
+	}
+	return modified, nil
+}
+
+// Funcion que verifica que las habitaciones activas de la reserva esten libres todas sus noches
+func checkRoomsAvailable(hotel hotelsDomain.Hotel, reservations []hotelsDAO.Reservation, reservation hotelsDAO.Reservation) error {
+	roomTypes := make(map[string]hotelsDomain.RoomType)
+	for _, roomType := range roomTypesOf(hotel) {
+		roomTypes[roomType.Code] = roomType
+	}
+	reserved := availability.ReservedRoomsByType(reservations, toDate(reservation.CheckIn), toDate(reservation.CheckOut))
+	for roomType, count := range availability.ActiveRooms(reservation) {
+		if reserved[roomType]+count > roomTypes[roomType].Rooms {
+			return hotelsDomain.UnprocessableError{Field: "rooms", Message: fmt.Sprintf("not enough %s rooms available", roomType)}
+		}
+	}
+	return nil
+}
+
+// Funcion que cotiza las habitaciones activas de la reserva para sus fechas
+// El total es lo de las habitaciones activas mas las penalidades de las canceladas
+func repriceReservation(hotel hotelsDomain.Hotel, reservation *hotelsDAO.Reservation, occupancy map[time.Time]int) {
+	total := 0.0
+	for i, room := range reservation.Rooms {
+		if room.Cancelled {
+			total += room.Penalty
+			continue
+		}
+		roomType, _ := findRoomType(roomTypesOf(hotel), room.RoomType)
+		reservation.Rooms[i].Price = roomPrice(hotel, roomType, room.Guests, reservation.CheckIn, reservation.CheckOut, occupancy)
+		total += reservation.Rooms[i].Price
+	}
+	reservation.TotalPrice = roundPrice(total)
+}
@@ -0,0 +1,113 @@
+package hotels
+
+import (
+	"testing"
+
+	hotelsDAO "hotels-api/dao/hotels"
+	hotelsDomain "hotels-api/domain/hotels"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func modificationHotel() hotelsDomain.Hotel {
+	return hotelsDomain.Hotel{
+		ID:            "hotel-1",
+		PricePerNight: 100,
+		RoomTypes: []hotelsDomain.RoomType{
+			{Code: "single", Rooms: 1, MaxGuests: 1, PricePerNight: 100},
+			{Code: "double", Rooms: 2, MaxGuests: 2, PricePerNight: 150},
+		},
+	}
+}
+
+func TestApplyReservationChange(t *testing.T) {
+	hotel := modificationHotel()
+	now := date(2025, 6, 1)
+	reservation := hotelsDAO.Reservation{
+		ID:       "reservation-1",
+		HotelID:  hotel.ID,
+		CheckIn:  date(2025, 6, 10),
+		CheckOut: date(2025, 6, 12),
+		Rooms:    []hotelsDAO.ReservationRoom{{RoomType: "double", Guests: 2}, {RoomType: "double", Guests: 1, Cancelled: true}},
+	}
+
+	// Correr la salida no toca las habitaciones de la reserva original
+	modified, err := applyReservationChange(hotel, reservation, hotelsDomain.ReservationChange{CheckOut: date(2025, 6, 14)}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, date(2025, 6, 10), modified.CheckIn)
+	assert.Equal(t, date(2025, 6, 14), modified.CheckOut)
+
+	// Cambiar de tipo solo afecta a las habitaciones activas
+	_, err = applyReservationChange(hotel, reservation, hotelsDomain.ReservationChange{RoomType: "single"}, now)
+	assert.IsType(t, hotelsDomain.UnprocessableError{}, err, "two guests don't fit in a single room")
+	reservation.Rooms[0].Guests = 1
+	modified, err = applyReservationChange(hotel, reservation, hotelsDomain.ReservationChange{RoomType: "single"}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "single", modified.Rooms[0].RoomType)
+	assert.Equal(t, "double", modified.Rooms[1].RoomType)
+	assert.Equal(t, "double", reservation.Rooms[0].RoomType)
+
+	tests := []struct {
+		name   string
+		change hotelsDomain.ReservationChange
+		err    error
+	}{
+		{"Nothing to change", hotelsDomain.ReservationChange{CheckIn: date(2025, 6, 10), RoomType: "double"}, hotelsDomain.ValidationError{}},
+		{"Check out before check in", hotelsDomain.ReservationChange{CheckIn: date(2025, 6, 12)}, hotelsDomain.ValidationError{}},
+		{"Check in in the past", hotelsDomain.ReservationChange{CheckIn: date(2025, 5, 30)}, hotelsDomain.ValidationError{}},
+		{"Unknown room type", hotelsDomain.ReservationChange{RoomType: "suite"}, hotelsDomain.UnprocessableError{}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := applyReservationChange(hotel, reservation, test.change, now)
+			assert.IsType(t, test.err, err)
+		})
+	}
+}
+
+func TestCheckRoomsAvailable(t *testing.T) {
+	hotel := modificationHotel()
+	reservation := hotelsDAO.Reservation{
+		ID:       "reservation-1",
+		CheckIn:  date(2025, 6, 10),
+		CheckOut: date(2025, 6, 12),
+		Rooms:    []hotelsDAO.ReservationRoom{{RoomType: "double", Guests: 2}},
+	}
+	other := hotelsDAO.Reservation{
+		ID:       "reservation-2",
+		CheckIn:  date(2025, 6, 11),
+		CheckOut: date(2025, 6, 13),
+		Rooms:    []hotelsDAO.ReservationRoom{{RoomType: "double", Guests: 2}},
+	}
+
+	// Queda una habitacion doble libre aunque la otra reserva se superponga
+	assert.NoError(t, checkRoomsAvailable(hotel, []hotelsDAO.Reservation{other}, reservation))
+
+	// Con las dos dobles ocupadas ya no entra
+	full := other
+	full.Rooms = append(full.Rooms, hotelsDAO.ReservationRoom{RoomType: "double", Guests: 1})
+	assert.IsType(t, hotelsDomain.UnprocessableError{}, checkRoomsAvailable(hotel, []hotelsDAO.Reservation{full}, reservation))
+
+	// Si la otra reserva esta cancelada sus habitaciones vuelven a estar libres
+	full.Status = hotelsDAO.ReservationCancelled
+	assert.NoError(t, checkRoomsAvailable(hotel, []hotelsDAO.Reservation{full}, reservation))
+}
+
+func TestRepriceReservation(t *testing.T) {
+	hotel := modificationHotel()
+	reservation := hotelsDAO.Reservation{
+		CheckIn:  date(2025, 6, 10),
+		CheckOut: date(2025, 6, 13),
+		Rooms: []hotelsDAO.ReservationRoom{
+			{RoomType: "single", Guests: 1, Price: 200},
+			{RoomType: "double", Guests: 2, Price: 300, Cancelled: true, Penalty: 75},
+		},
+		TotalPrice: 275,
+	}
+
+	// Tres noches de la simple mas la penalidad de la doble cancelada
+	repriceReservation(hotel, &reservation, nil)
+	assert.Equal(t, 300.0, reservation.Rooms[0].Price)
+	assert.Equal(t, 300.0, reservation.Rooms[1].Price)
+	assert.Equal(t, 375.0, reservation.TotalPrice)
+}
@@ -0,0 +1,389 @@
+package hotels
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	hotelsDAO "hotels-api/dao/hotels"
+	hotelsDomain "hotels-api/domain/hotels"
+	"log"
+	"strings"
+	"time"
+)
+
+// Veces que se reintenta guardar un evento de pago si otro cambio el pago al mismo tiempo
+const paymentUpdateAttempts = 3
+
+// Funcion que autoriza el pago de una reserva antes de guardarla
+// Un rechazo del procesador se devuelve como hotelsDomain.PaymentError
+func (service Service) authorizePayment(ctx context.Context, record hotelsDAO.Reservation, token, idempotencyKey string) (hotelsDomain.PaymentResult, error) {
+	result, err := service.paymentGateway.Authorize(ctx, hotelsDomain.PaymentRequest{
+		Reference:      fmt.Sprintf("hotel %s, user %s", record.HotelID, record.UserID),
+		Amount:         record.TotalPrice,
+		Currency:       record.Currency,
+		Token:          token,
+		IdempotencyKey: idempotencyKey,
+	})
+	if err != nil {
+		return hotelsDomain.PaymentResult{}, fmt.Errorf("error authorizing payment: %w", err)
+	}
+	if !result.Approved {
+		return hotelsDomain.PaymentResult{}, hotelsDomain.PaymentError{Message: result.DeclineReason}
+	}
+	return result, nil
+}
+
+// Funcion que anula una autorizacion de una reserva que no se llego a guardar
+// La reserva ya fallo, asi que un error aca solo se registra
+func (service Service) voidAuthorization(ctx context.Context, authorizationID string) {
+	result, err := service.paymentGateway.Void(ctx, authorizationID, "")
+	if err != nil {
+		log.Printf("error voiding authorization %s: %v", authorizationID, err)
+		return
+	}
+	if !result.Approved {
+		log.Printf("error voiding authorization %s: %s", authorizationID, result.DeclineReason)
+	}
+}
+
+// Funcion que obtiene el pago de una reserva
+// Solo lo puede ver el usuario que hizo la reserva o un administrador del hotel
+func (service Service) GetPayment(ctx context.Context, reservationID string, userID string) (hotelsDomain.Payment, error) {
+	reservation, err := service.reservations.Get(ctx, reservationID)
+	if err != nil {
+		return hotelsDomain.Payment{}, fmt.Errorf("error getting reservation: %w", err)
+	}
+	if err := service.authorizeReservation(ctx, reservation, userID); err != nil {
+		return hotelsDomain.Payment{}, err
+	}
+	payment, err := service.mainRepository.GetPaymentByReservationID(ctx, reservationID)
+	if err != nil {
+		return hotelsDomain.Payment{}, fmt.Errorf("error getting payment from main repository: %w", err)
+	}
+	return paymentToDomain(payment), nil
+}
+
+// Funcion que cobra el pago autorizado de una reserva, por ejemplo al hacer el check-in
+// Sin monto se cobra el precio actual de la reserva, que baja si se cancelaron habitaciones
+// Solo lo puede hacer un administrador del hotel
+func (service Service) CapturePayment(ctx context.Context, reservationID string, userID string, amount float64, idempotencyKey string) (hotelsDomain.Payment, error) {
+	if err := service.authorizeManager(ctx, reservationID, userID); err != nil {
+		return hotelsDomain.Payment{}, err
+	}
+	return service.capturePayment(ctx, reservationID, amount, idempotencyKey)
+}
+
+func (service Service) capturePayment(ctx context.Context, reservationID string, amount float64, idempotencyKey string) (hotelsDomain.Payment, error) {
+	payment, err := service.mainRepository.GetPaymentByReservationID(ctx, reservationID)
+	if err != nil {
+		return hotelsDomain.Payment{}, fmt.Errorf("error getting payment from main repository: %w", err)
+	}
+	if payment.Status != hotelsDAO.PaymentAuthorized {
+		return hotelsDomain.Payment{}, hotelsDomain.UnprocessableError{Field: "payment", Message: fmt.Sprintf("can't be captured, it's %s", payment.Status)}
+	}
+	if amount < 0 {
+		return hotelsDomain.Payment{}, hotelsDomain.ValidationError{Field: "amount", Message: "must be a positive number"}
+	}
+	if amount == 0 {
+		reservation, err := service.mainRepository.GetReservationByID(ctx, reservationID)
+		if err != nil {
+			return hotelsDomain.Payment{}, fmt.Errorf("error getting reservation from main repository: %w", err)
+		}
+		amount = reservation.TotalPrice
+	}
+	if amount > payment.AuthorizedAmount {
+		return hotelsDomain.Payment{}, hotelsDomain.UnprocessableError{Field: "amount", Message: fmt.Sprintf("exceeds the authorized %.2f", payment.AuthorizedAmount)}
+	}
+
+	result, err := service.paymentGateway.Capture(ctx, payment.AuthorizationID, roundPrice(amount), idempotencyKey)
+	if err != nil {
+		return hotelsDomain.Payment{}, fmt.Errorf("error capturing payment: %w", err)
+	}
+	if !result.Approved {
+		return hotelsDomain.Payment{}, hotelsDomain.PaymentError{Message: result.DeclineReason}
+	}
+	return service.recordPaymentEvent(ctx, payment, paymentEvent(hotelsDAO.PaymentEventCaptured, payment, result))
+}
+
+// Funcion que devuelve parte o todo lo cobrado de una reserva. Sin monto se devuelve todo lo que falta devolver
+// Solo lo puede hacer un administrador del hotel
+func (service Service) RefundPayment(ctx context.Context, reservationID string, userID string, amount float64, idempotencyKey string) (hotelsDomain.Payment, error) {
+	if err := service.authorizeManager(ctx, reservationID, userID); err != nil {
+		return hotelsDomain.Payment{}, err
+	}
+	return service.refundPayment(ctx, reservationID, amount, idempotencyKey)
+}
+
+func (service Service) refundPayment(ctx context.Context, reservationID string, amount float64, idempotencyKey string) (hotelsDomain.Payment, error) {
+	payment, err := service.mainRepository.GetPaymentByReservationID(ctx, reservationID)
+	if err != nil {
+		return hotelsDomain.Payment{}, fmt.Errorf("error getting payment from main repository: %w", err)
+	}
+	if payment.Status != hotelsDAO.PaymentCaptured && payment.Status != hotelsDAO.PaymentPartiallyRefunded {
+		return hotelsDomain.Payment{}, hotelsDomain.UnprocessableError{Field: "payment", Message: fmt.Sprintf("can't be refunded, it's %s", payment.Status)}
+	}
+	if amount < 0 {
+		return hotelsDomain.Payment{}, hotelsDomain.ValidationError{Field: "amount", Message: "must be a positive number"}
+	}
+	refundable := roundPrice(payment.CapturedAmount - payment.RefundedAmount)
+	if amount == 0 {
+		amount = refundable
+	}
+	if amount > refundable {
+		return hotelsDomain.Payment{}, hotelsDomain.UnprocessableError{Field: "amount", Message: fmt.Sprintf("exceeds the refundable %.2f", refundable)}
+	}
+
+	result, err := service.paymentGateway.Refund(ctx, payment.AuthorizationID, roundPrice(amount), idempotencyKey)
+	if err != nil {
+		return hotelsDomain.Payment{}, fmt.Errorf("error refunding payment: %w", err)
+	}
+	if !result.Approved {
+		return hotelsDomain.Payment{}, hotelsDomain.PaymentError{Message: result.DeclineReason}
+	}
+	return service.recordPaymentEvent(ctx, payment, paymentEvent(hotelsDAO.PaymentEventRefunded, payment, result))
+}
+
+// Funcion que ajusta el pago de una reserva a lo que el cliente tiene que pagar (retained) despues de una cancelacion
+// Si ya se cobro se devuelve la diferencia. Si solo esta autorizado y se cancela la reserva entera (final) se cobra
+// lo retenido, o se anula la autorizacion si no hay nada que cobrar; si no, el nuevo total se cobra al hacer el check-in
+// Las reservas anteriores a los pagos no tienen pago y no hay nada que ajustar
+func (service Service) settlePayment(ctx context.Context, reservationID string, retained float64, final bool, idempotencyKey string) error {
+	payment, err := service.mainRepository.GetPaymentByReservationID(ctx, reservationID)
+	if errors.Is(err, hotelsDAO.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error getting payment from main repository: %w", err)
+	}
+
+	// La clave de idempotencia evita ajustar dos veces si se reintenta la cancelacion
+	switch payment.Status {
+	case hotelsDAO.PaymentAuthorized:
+		if !final {
+			return nil
+		}
+		if retained > 0 {
+			_, err := service.capturePayment(ctx, reservationID, retained, idempotencyKey)
+			return err
+		}
+		result, err := service.paymentGateway.Void(ctx, payment.AuthorizationID, idempotencyKey)
+		if err != nil {
+			return fmt.Errorf("error voiding payment: %w", err)
+		}
+		if !result.Approved {
+			return hotelsDomain.PaymentError{Message: result.DeclineReason}
+		}
+		_, err = service.recordPaymentEvent(ctx, payment, paymentEvent(hotelsDAO.PaymentEventVoided, payment, result))
+		return err
+	case hotelsDAO.PaymentCaptured, hotelsDAO.PaymentPartiallyRefunded:
+		refund := roundPrice(payment.CapturedAmount - payment.RefundedAmount - retained)
+		if refund <= 0 {
+			return nil
+		}
+		_, err := service.refundPayment(ctx, reservationID, refund, idempotencyKey)
+		return err
+	}
+	return nil
+}
+
+// Funcion que procesa un webhook del procesador de pagos
+// Los eventos repetidos, incluidos los de operaciones que ya se registraron al hacerlas, se ignoran
+func (service Service) HandlePaymentWebhook(ctx context.Context, payload []byte, signature string) error {
+	if !service.paymentGateway.VerifyWebhook(payload, signature) {
+		return hotelsDomain.ValidationError{Field: "signature", Message: "is not valid"}
+	}
+	var event hotelsDomain.PaymentEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return hotelsDomain.ValidationError{Field: "payload", Message: err.Error()}
+	}
+	if strings.TrimSpace(event.ID) == "" {
+		return hotelsDomain.ValidationError{Field: "id", Message: "is required"}
+	}
+
+	payment, err := service.mainRepository.GetPaymentByAuthorizationID(ctx, event.AuthorizationID)
+	if err != nil {
+		return fmt.Errorf("error getting payment from main repository: %w", err)
+	}
+	_, err = service.recordPaymentEvent(ctx, payment, paymentEventToDAO(event))
+	return err
+}
+
+// Funcion que aplica un evento a un pago y lo guarda
+// Si otro cambio el pago al mismo tiempo se vuelve a leer y se reintenta
+func (service Service) recordPaymentEvent(ctx context.Context, payment hotelsDAO.Payment, event hotelsDAO.PaymentEvent) (hotelsDomain.Payment, error) {
+	for attempt := 1; ; attempt++ {
+		updated, changed, err := applyPaymentEvent(payment, event, time.Now().UTC())
+		if err != nil {
+			return hotelsDomain.Payment{}, err
+		}
+		if !changed {
+			return paymentToDomain(payment), nil
+		}
+		err = service.mainRepository.UpdatePayment(ctx, updated)
+		if err == nil {
+			updated.Version++
+			return paymentToDomain(updated), nil
+		}
+		if !errors.Is(err, hotelsDAO.ErrConflict) || attempt == paymentUpdateAttempts {
+			return hotelsDomain.Payment{}, fmt.Errorf("error updating payment in main repository: %w", err)
+		}
+		payment, err = service.mainRepository.GetPaymentByReservationID(ctx, payment.ReservationID)
+		if err != nil {
+			return hotelsDomain.Payment{}, fmt.Errorf("error getting payment from main repository: %w", err)
+		}
+	}
+}
+
+// Funcion que calcula como queda un pago despues de un evento
+// Devuelve false si el evento ya estaba aplicado, y un hotelsDomain.UnprocessableError si no corresponde al estado del pago
+func applyPaymentEvent(payment hotelsDAO.Payment, event hotelsDAO.PaymentEvent, now time.Time) (hotelsDAO.Payment, bool, error) {
+	for _, applied := range payment.Events {
+		if applied.ID == event.ID {
+			return payment, false, nil
+		}
+	}
+	invalid := hotelsDomain.UnprocessableError{Field: "payment", Message: fmt.Sprintf("can't apply %s to a %s payment", event.Type, payment.Status)}
+
+	switch event.Type {
+	case hotelsDAO.PaymentEventAuthorized:
+		// La autorizacion se registra al crear el pago, el aviso del procesador no cambia nada
+	case hotelsDAO.PaymentEventCaptured:
+		if payment.Status != hotelsDAO.PaymentAuthorized {
+			return payment, false, invalid
+		}
+		payment.Status = hotelsDAO.PaymentCaptured
+		payment.CapturedAmount = event.Amount
+	case hotelsDAO.PaymentEventRefunded:
+		if payment.Status != hotelsDAO.PaymentCaptured && payment.Status != hotelsDAO.PaymentPartiallyRefunded {
+			return payment, false, invalid
+		}
+		refunded := roundPrice(payment.RefundedAmount + event.Amount)
+		if refunded > payment.CapturedAmount {
+			return payment, false, hotelsDomain.UnprocessableError{Field: "amount", Message: "refunds exceed the captured amount"}
+		}
+		payment.RefundedAmount = refunded
+		payment.Status = hotelsDAO.PaymentPartiallyRefunded
+		if refunded == payment.CapturedAmount {
+			payment.Status = hotelsDAO.PaymentRefunded
+		}
+	case hotelsDAO.PaymentEventVoided:
+		if payment.Status != hotelsDAO.PaymentAuthorized {
+			return payment, false, invalid
+		}
+		payment.Status = hotelsDAO.PaymentVoided
+	default:
+		return payment, false, hotelsDomain.ValidationError{Field: "type", Message: fmt.Sprintf("unknown payment event %s", event.Type)}
+	}
+
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = now
+	}
+	payment.Events = append(append([]hotelsDAO.PaymentEvent(nil), payment.Events...), event)
+	payment.UpdatedAt = now
+	return payment, true, nil
+}
+
+// Funcion que ejecuta una peticion una sola vez por clave de idempotencia y devuelve su resultado
+// Si la clave ya se proceso devuelve el mismo resultado, y si se esta procesando un hotelsDomain.ConflictError
+// La clave es de cada usuario y de cada operacion (scope); si se repite con otros datos (payload) se devuelve
+// un hotelsDomain.UnprocessableError en vez del resultado de la otra peticion
+// run recibe la clave ya separada por usuario y operacion, para usarla con el procesador de pagos
+// Sin clave la peticion se ejecuta siempre
+func (service Service) idempotent(ctx context.Context, key, userID, scope string, payload interface{}, run func(key string) (string, error)) (string, error) {
+	if key == "" {
+		return run("")
+	}
+	hash, err := payloadHash(payload)
+	if err != nil {
+		return "", err
+	}
+	id := idempotencyID(userID, scope, key)
+	record, created, err := service.mainRepository.ReserveIdempotencyKey(ctx, hotelsDAO.IdempotencyKey{
+		ID:          id,
+		Key:         key,
+		UserID:      userID,
+		Scope:       scope,
+		PayloadHash: hash,
+		CreatedAt:   time.Now().UTC(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("error reserving idempotency key: %w", err)
+	}
+	if !created {
+		if record.PayloadHash != hash {
+			return "", hotelsDomain.UnprocessableError{Field: "Idempotency-Key", Message: "was already used with a different request"}
+		}
+		if !record.Done {
+			return "", hotelsDomain.ConflictError{Message: "a request with this Idempotency-Key is still being processed"}
+		}
+		return record.Result, nil
+	}
+
+	result, err := run(id)
+	if err != nil {
+		// Si fallo se puede reintentar con la misma clave
+		if releaseErr := service.mainRepository.ReleaseIdempotencyKey(ctx, id); releaseErr != nil {
+			log.Printf("error releasing idempotency key %s: %v", id, releaseErr)
+		}
+		return "", err
+	}
+	// La peticion ya se hizo, asi que un error aca no la hace fallar
+	if err := service.mainRepository.CompleteIdempotencyKey(ctx, id, result); err != nil {
+		log.Printf("error completing idempotency key %s: %v", id, err)
+	}
+	return result, nil
+}
+
+// Funcion que arma el ID de una clave de idempotencia con el usuario y la operacion
+func idempotencyID(userID, scope, key string) string {
+	return fmt.Sprintf("%s:%s:%s", userID, scope, key)
+}
+
+// Funcion que calcula el hash de los datos de una peticion, para reconocer un reintento de la misma
+func payloadHash(payload interface{}) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("error hashing request: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Evento de una operacion hecha por la API, con el mismo ID que usa el procesador en su webhook
+func paymentEvent(eventType string, payment hotelsDAO.Payment, result hotelsDomain.PaymentResult) hotelsDAO.PaymentEvent {
+	return hotelsDAO.PaymentEvent{
+		ID:              result.TransactionID,
+		Type:            eventType,
+		AuthorizationID: payment.AuthorizationID,
+		Amount:          result.Amount,
+	}
+}
+
+// Funciones para pasar los pagos entre el formato de dominio y el de base de datos
+func paymentToDomain(record hotelsDAO.Payment) hotelsDomain.Payment {
+	events := make([]hotelsDomain.PaymentEvent, 0, len(record.Events))
+	for _, event := range record.Events {
+		events = append(events, hotelsDomain.PaymentEvent(event))
+	}
+	return hotelsDomain.Payment{
+		ID:               record.ID,
+		ReservationID:    record.ReservationID,
+		AuthorizationID:  record.AuthorizationID,
+		Status:           record.Status,
+		Currency:         record.Currency,
+		AuthorizedAmount: record.AuthorizedAmount,
+		CapturedAmount:   record.CapturedAmount,
+		RefundedAmount:   record.RefundedAmount,
+		Events:           events,
+		Version:          record.Version,
+		CreatedAt:        record.CreatedAt,
+		UpdatedAt:        record.UpdatedAt,
+	}
+}
+
+func paymentEventToDAO(event hotelsDomain.PaymentEvent) hotelsDAO.PaymentEvent {
+	return hotelsDAO.PaymentEvent(event)
+}
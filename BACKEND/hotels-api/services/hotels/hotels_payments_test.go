@@ -0,0 +1,131 @@
+package hotels
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	hotelsDAO "hotels-api/dao/hotels"
+	hotelsDomain "hotels-api/domain/hotels"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyPaymentEvent(t *testing.T) {
+	now := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	payment := hotelsDAO.Payment{
+		AuthorizationID:  "auth_1",
+		Status:           hotelsDAO.PaymentAuthorized,
+		AuthorizedAmount: 300,
+		Events:           []hotelsDAO.PaymentEvent{{ID: "auth_1", Type: hotelsDAO.PaymentEventAuthorized, Amount: 300}},
+	}
+
+	payment, changed, err := applyPaymentEvent(payment, hotelsDAO.PaymentEvent{ID: "cap_1", Type: hotelsDAO.PaymentEventCaptured, Amount: 250}, now)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, hotelsDAO.PaymentCaptured, payment.Status)
+	assert.Equal(t, 250.0, payment.CapturedAmount)
+	assert.Equal(t, now, payment.Events[1].CreatedAt)
+
+	// El webhook de una operacion ya registrada no se aplica dos veces
+	_, changed, err = applyPaymentEvent(payment, hotelsDAO.PaymentEvent{ID: "cap_1", Type: hotelsDAO.PaymentEventCaptured, Amount: 250}, now)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+
+	payment, _, err = applyPaymentEvent(payment, hotelsDAO.PaymentEvent{ID: "ref_1", Type: hotelsDAO.PaymentEventRefunded, Amount: 100}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, hotelsDAO.PaymentPartiallyRefunded, payment.Status)
+	payment, _, err = applyPaymentEvent(payment, hotelsDAO.PaymentEvent{ID: "ref_2", Type: hotelsDAO.PaymentEventRefunded, Amount: 150}, now)
+	assert.NoError(t, err)
+	assert.Equal(t, hotelsDAO.PaymentRefunded, payment.Status)
+	assert.Equal(t, 250.0, payment.RefundedAmount)
+	assert.Len(t, payment.Events, 4)
+}
+
+func TestApplyPaymentEventInvalid(t *testing.T) {
+	now := time.Now()
+	voided := hotelsDAO.Payment{Status: hotelsDAO.PaymentVoided}
+
+	// Lo anulado no se puede cobrar
+	_, _, err := applyPaymentEvent(voided, hotelsDAO.PaymentEvent{ID: "cap_1", Type: hotelsDAO.PaymentEventCaptured, Amount: 100}, now)
+	assert.ErrorAs(t, err, &hotelsDomain.UnprocessableError{})
+
+	// No se puede devolver mas de lo cobrado
+	captured := hotelsDAO.Payment{Status: hotelsDAO.PaymentCaptured, CapturedAmount: 100}
+	_, _, err = applyPaymentEvent(captured, hotelsDAO.PaymentEvent{ID: "ref_1", Type: hotelsDAO.PaymentEventRefunded, Amount: 150}, now)
+	assert.ErrorAs(t, err, &hotelsDomain.UnprocessableError{})
+
+	_, _, err = applyPaymentEvent(captured, hotelsDAO.PaymentEvent{ID: "x", Type: "payment.unknown"}, now)
+	assert.ErrorAs(t, err, &hotelsDomain.ValidationError{})
+}
+
+// Repositorio principal que solo guarda claves de idempotencia en memoria
+type idempotencyRepository struct {
+	MainRepository
+	keys map[string]hotelsDAO.IdempotencyKey
+}
+
+func (repository *idempotencyRepository) ReserveIdempotencyKey(ctx context.Context, key hotelsDAO.IdempotencyKey) (hotelsDAO.IdempotencyKey, bool, error) {
+	if existing, ok := repository.keys[key.ID]; ok {
+		return existing, false, nil
+	}
+	repository.keys[key.ID] = key
+	return key, true, nil
+}
+
+func (repository *idempotencyRepository) CompleteIdempotencyKey(ctx context.Context, id string, result string) error {
+	key := repository.keys[id]
+	key.Done, key.Result = true, result
+	repository.keys[id] = key
+	return nil
+}
+
+func (repository *idempotencyRepository) ReleaseIdempotencyKey(ctx context.Context, id string) error {
+	delete(repository.keys, id)
+	return nil
+}
+
+func TestIdempotent(t *testing.T) {
+	ctx := context.Background()
+	service := Service{mainRepository: &idempotencyRepository{keys: map[string]hotelsDAO.IdempotencyKey{}}}
+	runs := 0
+	run := func(result string) func(key string) (string, error) {
+		return func(key string) (string, error) {
+			runs++
+			return result, nil
+		}
+	}
+	payload := hotelsDomain.Reservation{HotelID: "hotel", UserID: "1"}
+
+	// Un reintento con los mismos datos devuelve el mismo resultado sin volver a ejecutar la peticion
+	result, err := service.idempotent(ctx, "key", "1", "reservation", payload, run("reservation-1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "reservation-1", result)
+	result, err = service.idempotent(ctx, "key", "1", "reservation", payload, run("reservation-2"))
+	assert.NoError(t, err)
+	assert.Equal(t, "reservation-1", result)
+	assert.Equal(t, 1, runs)
+
+	// La misma clave con otros datos no devuelve el resultado de la primera peticion
+	_, err = service.idempotent(ctx, "key", "1", "reservation", hotelsDomain.Reservation{HotelID: "other", UserID: "1"}, run("reservation-3"))
+	assert.ErrorAs(t, err, &hotelsDomain.UnprocessableError{})
+	assert.Equal(t, 1, runs)
+
+	// Otro usuario u otra operacion pueden usar la misma clave
+	result, err = service.idempotent(ctx, "key", "2", "reservation", payload, run("reservation-4"))
+	assert.NoError(t, err)
+	assert.Equal(t, "reservation-4", result)
+	result, err = service.idempotent(ctx, "key", "1", "hold-1", payload, run("reservation-5"))
+	assert.NoError(t, err)
+	assert.Equal(t, "reservation-5", result)
+
+	// Si la peticion falla la clave se libera para reintentar
+	_, err = service.idempotent(ctx, "retry", "1", "reservation", payload, func(key string) (string, error) {
+		return "", errors.New("payment gateway down")
+	})
+	assert.Error(t, err)
+	result, err = service.idempotent(ctx, "retry", "1", "reservation", payload, run("reservation-6"))
+	assert.NoError(t, err)
+	assert.Equal(t, "reservation-6", result)
+}
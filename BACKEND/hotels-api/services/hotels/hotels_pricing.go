@@ -0,0 +1,179 @@
+package hotels
+
+import (
+	"context"
+	"fmt"
+	hotelsDAO "hotels-api/dao/hotels"
+	hotelsDomain "hotels-api/domain/hotels"
+	"hotels-api/services/availability"
+	"math"
+	"time"
+)
+
+// Funcion que cotiza una estadia en un hotel: una tarifa por noche (base, fin de semana o temporada) mas los recargos
+// por ocupacion y huespedes extra, y al total se le aplica el descuento por cantidad de noches
+// Las noches van de checkIn (inclusive) a checkOut (exclusive)
+func (service Service) Quote(ctx context.Context, hotelID string, checkIn, checkOut time.Time, guests int) (hotelsDomain.Quote, error) {
+	checkIn, checkOut = toDate(checkIn), toDate(checkOut)
+	if !checkOut.After(checkIn) {
+		return hotelsDomain.Quote{}, hotelsDomain.ValidationError{Field: "check_out", Message: "must be after check_in"}
+	}
+	if guests <= 0 {
+		guests = 1
+	}
+
+	// Obtiene el hotel con sus reglas de precio
+	hotel, err := service.GetHotelByID(ctx, hotelID)
+	if err != nil {
+		return hotelsDomain.Quote{}, err
+	}
+
+	// La ocupacion se calcula con las reservas de la base principal, la cache puede no tenerlas todas
+	reservations, err := service.mainRepository.GetReservationsInRange(ctx, hotelID, checkIn, checkOut)
+	if err != nil {
+		return hotelsDomain.Quote{}, fmt.Errorf("error getting reservations from repository: %w", err)
+	}
+
+	quote := calculateQuote(hotel, checkIn, checkOut, guests, availability.ReservedByNight(reservations, checkIn, checkOut))
+	quote.HotelID = hotelID
+	return quote, nil
+}
+
+// Funcion que calcula la cotizacion sin acceder a los repositorios
+// reserved tiene la cantidad de habitaciones reservadas por noche, para los recargos por ocupacion
+func calculateQuote(hotel hotelsDomain.Hotel, checkIn, checkOut time.Time, guests int, reserved map[time.Time]int) hotelsDomain.Quote {
+	pricing := hotelsDomain.Pricing{}
+	if hotel.Pricing != nil {
+		pricing = *hotel.Pricing
+	}
+
+	quote := hotelsDomain.Quote{
+		CheckIn:  checkIn,
+		CheckOut: checkOut,
+		Guests:   guests,
+		Nights:   make([]hotelsDomain.NightPrice, 0),
+	}
+	for night := checkIn; night.Before(checkOut); night = night.AddDate(0, 0, 1) {
+		price := hotelsDomain.NightPrice{
+			Date:    night,
+			Weekend: isWeekendNight(night),
+			Rate:    hotel.PricePerNight,
+		}
+
+		// Tarifa de la noche: la de fin de semana pisa a la base y la de temporada pisa a ambas
+		if price.Weekend && pricing.WeekendRate > 0 {
+			price.Rate = pricing.WeekendRate
+		}
+		if season, ok := findSeason(pricing.Seasons, night); ok {
+			price.Season = season.Name
+			if season.Rate > 0 {
+				price.Rate = season.Rate
+			}
+			if price.Weekend && season.WeekendRate > 0 {
+				price.Rate = season.WeekendRate
+			}
+		}
+
+		// Recargo por ocupacion: el mayor cuyo umbral se alcanza esa noche
+		if hotel.AvaiableRooms > 0 {
+			occupancy := float64(reserved[night]) / float64(hotel.AvaiableRooms)
+			percent := 0.0
+			for _, surcharge := range pricing.OccupancySurcharges {
+				if occupancy >= surcharge.MinOccupancy && surcharge.Percent > percent {
+					percent = surcharge.Percent
+				}
+			}
+			price.OccupancySurcharge = roundPrice(price.Rate * percent / 100)
+		}
+
+		// Cargo por los huespedes que superan los incluidos en la tarifa
+		if pricing.IncludedGuests > 0 && guests > pricing.IncludedGuests {
+			price.ExtraGuestFee = roundPrice(float64(guests-pricing.IncludedGuests) * pricing.ExtraGuestFee)
+		}
+
+		price.Price = roundPrice(price.Rate + price.OccupancySurcharge + price.ExtraGuestFee)
+		quote.Subtotal += price.Price
+		quote.Nights = append(quote.Nights, price)
+	}
+	quote.Subtotal = roundPrice(quote.Subtotal)
+
+	// Descuento por estadia larga: el mayor de los que piden menos o igual noches que la estadia
+	percent := 0.0
+	for _, discount := range pricing.StayDiscounts {
+		if len(quote.Nights) >= discount.MinNights && discount.Percent > percent {
+			percent = discount.Percent
+		}
+	}
+	quote.Discount = roundPrice(quote.Subtotal * percent / 100)
+	quote.Total = roundPrice(quote.Subtotal - quote.Discount)
+
+	return quote
+}
+
+// Funcion que busca la primera temporada que contiene la noche (From y To inclusive)
+func findSeason(seasons []hotelsDomain.Season, night time.Time) (hotelsDomain.Season, bool) {
+	for _, season := range seasons {
+		if !night.Before(toDate(season.From)) && !night.After(toDate(season.To)) {
+			return season, true
+		}
+	}
+	return hotelsDomain.Season{}, false
+}
+
+// Las noches de fin de semana son las de viernes y sabado
+func isWeekendNight(night time.Time) bool {
+	return night.Weekday() == time.Friday || night.Weekday() == time.Saturday
+}
+
+// Funcion que lleva una fecha a la medianoche UTC de ese dia, para comparar noches sin importar la hora
+func toDate(date time.Time) time.Time {
+	return availability.Date(date)
+}
+
+// Redondea un precio a centavos
+func roundPrice(price float64) float64 {
+	return math.Round(price*100) / 100
+}
+
+// Funciones para pasar las reglas de precio entre el formato de dominio y el de base de datos
+func pricingToDAO(pricing *hotelsDomain.Pricing) *hotelsDAO.Pricing {
+	if pricing == nil {
+		return nil
+	}
+	record := &hotelsDAO.Pricing{
+		WeekendRate:    pricing.WeekendRate,
+		IncludedGuests: pricing.IncludedGuests,
+		ExtraGuestFee:  pricing.ExtraGuestFee,
+	}
+	for _, season := range pricing.Seasons {
+		record.Seasons = append(record.Seasons, hotelsDAO.Season(season))
+	}
+	for _, discount := range pricing.StayDiscounts {
+		record.StayDiscounts = append(record.StayDiscounts, hotelsDAO.StayDiscount(discount))
+	}
+	for _, surcharge := range pricing.OccupancySurcharges {
+		record.OccupancySurcharges = append(record.OccupancySurcharges, hotelsDAO.OccupancySurcharge(surcharge))
+	}
+	return record
+}
+
+func pricingToDomain(record *hotelsDAO.Pricing) *hotelsDomain.Pricing {
+	if record == nil {
+		return nil
+	}
+	pricing := &hotelsDomain.Pricing{
+		WeekendRate:    record.WeekendRate,
+		IncludedGuests: record.IncludedGuests,
+		ExtraGuestFee:  record.ExtraGuestFee,
+	}
+	for _, season := range record.Seasons {
+		pricing.Seasons = append(pricing.Seasons, hotelsDomain.Season(season))
+	}
+	for _, discount := range record.StayDiscounts {
+		pricing.StayDiscounts = append(pricing.StayDiscounts, hotelsDomain.StayDiscount(discount))
+	}
+	for _, surcharge := range record.OccupancySurcharges {
+		pricing.OccupancySurcharges = append(pricing.OccupancySurcharges, hotelsDomain.OccupancySurcharge(surcharge))
+	}
+	return pricing
+}
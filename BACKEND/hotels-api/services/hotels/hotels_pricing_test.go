@@ -0,0 +1,80 @@
+package hotels
+
+import (
+	"testing"
+	"time"
+
+	hotelsDomain "hotels-api/domain/hotels"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestCalculateQuote(t *testing.T) {
+	hotel := hotelsDomain.Hotel{
+		PricePerNight: 100,
+		AvaiableRooms: 4,
+		Pricing: &hotelsDomain.Pricing{
+			WeekendRate: 150,
+			Seasons: []hotelsDomain.Season{
+				{Name: "Verano", From: date(2025, 1, 1), To: date(2025, 2, 28), Rate: 200, WeekendRate: 250},
+			},
+			StayDiscounts: []hotelsDomain.StayDiscount{
+				{MinNights: 3, Percent: 5},
+				{MinNights: 7, Percent: 10},
+			},
+			OccupancySurcharges: []hotelsDomain.OccupancySurcharge{
+				{MinOccupancy: 0.5, Percent: 10},
+				{MinOccupancy: 0.75, Percent: 20},
+			},
+			IncludedGuests: 2,
+			ExtraGuestFee:  15,
+		},
+	}
+
+	t.Run("Weekday and weekend rates", func(t *testing.T) {
+		// Jueves 2024-12-12 a domingo 2024-12-15: jueves, viernes y sabado a la noche
+		quote := calculateQuote(hotel, date(2024, 12, 12), date(2024, 12, 15), 2, nil)
+		assert.Len(t, quote.Nights, 3)
+		assert.False(t, quote.Nights[0].Weekend)
+		assert.Equal(t, 100.0, quote.Nights[0].Price)
+		assert.Equal(t, 150.0, quote.Nights[1].Price)
+		assert.Equal(t, 150.0, quote.Nights[2].Price)
+		assert.Equal(t, 400.0, quote.Subtotal)
+		assert.Equal(t, 20.0, quote.Discount)
+		assert.Equal(t, 380.0, quote.Total)
+	})
+
+	t.Run("Season across the boundary", func(t *testing.T) {
+		// Martes 2024-12-31 fuera de temporada, miercoles 2025-01-01 dentro
+		quote := calculateQuote(hotel, date(2024, 12, 31), date(2025, 1, 2), 2, nil)
+		assert.Equal(t, "", quote.Nights[0].Season)
+		assert.Equal(t, 100.0, quote.Nights[0].Rate)
+		assert.Equal(t, "Verano", quote.Nights[1].Season)
+		assert.Equal(t, 200.0, quote.Nights[1].Rate)
+		assert.Equal(t, 300.0, quote.Total)
+	})
+
+	t.Run("Occupancy surcharge and extra guests", func(t *testing.T) {
+		reserved := map[time.Time]int{
+			date(2024, 12, 10): 2, // 50% de ocupacion
+			date(2024, 12, 11): 3, // 75% de ocupacion
+		}
+		quote := calculateQuote(hotel, date(2024, 12, 10), date(2024, 12, 12), 3, reserved)
+		assert.Equal(t, 10.0, quote.Nights[0].OccupancySurcharge)
+		assert.Equal(t, 20.0, quote.Nights[1].OccupancySurcharge)
+		assert.Equal(t, 15.0, quote.Nights[0].ExtraGuestFee)
+		assert.Equal(t, 125.0, quote.Nights[0].Price)
+		assert.Equal(t, 135.0, quote.Nights[1].Price)
+		assert.Equal(t, 260.0, quote.Total)
+	})
+
+	t.Run("Without pricing rules", func(t *testing.T) {
+		quote := calculateQuote(hotelsDomain.Hotel{PricePerNight: 80.5}, date(2024, 12, 13), date(2024, 12, 15), 4, nil)
+		assert.Equal(t, 161.0, quote.Total)
+		assert.Zero(t, quote.Discount)
+	})
+}
@@ -0,0 +1,91 @@
+package hotels
+
+import (
+	hotelsDAO "hotels-api/dao/hotels"
+	hotelsDomain "hotels-api/domain/hotels"
+	"time"
+)
+
+// Funcion que devuelve los tipos de habitacion del hotel
+// Los hoteles sin tipos tienen un unico tipo DefaultRoomType con todas sus habitaciones
+func roomTypesOf(hotel hotelsDomain.Hotel) []hotelsDomain.RoomType {
+	if len(hotel.RoomTypes) > 0 {
+		return hotel.RoomTypes
+	}
+	return []hotelsDomain.RoomType{{
+		Code:  hotelsDAO.DefaultRoomType,
+		Name:  "Standard",
+		Rooms: hotel.AvaiableRooms,
+	}}
+}
+
+// Funcion que devuelve cuantos huespedes entran en una habitacion del tipo: la del tipo, la del hotel o la por defecto
+func roomMaxGuests(hotel hotelsDomain.Hotel, roomType hotelsDomain.RoomType) int {
+	if roomType.MaxGuests > 0 {
+		return roomType.MaxGuests
+	}
+	if hotel.MaxGuestsPerRoom > 0 {
+		return hotel.MaxGuestsPerRoom
+	}
+	return defaultMaxGuestsPerRoom
+}
+
+// Funcion que cotiza una habitacion con la tarifa de su tipo, o la del hotel si el tipo no tiene
+// occupancy tiene las habitaciones reservadas por noche, para los recargos por ocupacion
+func roomPrice(hotel hotelsDomain.Hotel, roomType hotelsDomain.RoomType, guests int, checkIn, checkOut time.Time, occupancy map[time.Time]int) float64 {
+	if roomType.PricePerNight > 0 {
+		hotel.PricePerNight = roomType.PricePerNight
+	}
+	return calculateQuote(hotel, toDate(checkIn), toDate(checkOut), guests, occupancy).Total
+}
+
+// Funcion que arma las habitaciones de una reserva que no las detalla: roomCount habitaciones del tipo roomType
+// con los huespedes repartidos lo mas parejo posible
+func splitRooms(roomType string, roomCount int, guests int) []hotelsDomain.ReservationRoom {
+	rooms := make([]hotelsDomain.ReservationRoom, 0, roomCount)
+	for i := 0; i < roomCount; i++ {
+		// Las primeras habitaciones se llevan los huespedes que sobran de la division
+		roomGuests := guests / roomCount
+		if i < guests%roomCount {
+			roomGuests++
+		}
+		if roomGuests == 0 {
+			roomGuests = 1
+		}
+		rooms = append(rooms, hotelsDomain.ReservationRoom{RoomType: roomType, Guests: roomGuests})
+	}
+	return rooms
+}
+
+// Funciones para pasar los tipos de habitacion y las habitaciones reservadas entre el formato de dominio y el de base de datos
+func roomTypesToDAO(roomTypes []hotelsDomain.RoomType) []hotelsDAO.RoomType {
+	records := make([]hotelsDAO.RoomType, 0, len(roomTypes))
+	for _, roomType := range roomTypes {
+		records = append(records, hotelsDAO.RoomType(roomType))
+	}
+	return records
+}
+
+func roomTypesToDomain(records []hotelsDAO.RoomType) []hotelsDomain.RoomType {
+	roomTypes := make([]hotelsDomain.RoomType, 0, len(records))
+	for _, record := range records {
+		roomTypes = append(roomTypes, hotelsDomain.RoomType(record))
+	}
+	return roomTypes
+}
+
+func reservationRoomsToDAO(rooms []hotelsDomain.ReservationRoom) []hotelsDAO.ReservationRoom {
+	records := make([]hotelsDAO.ReservationRoom, 0, len(rooms))
+	for _, room := range rooms {
+		records = append(records, hotelsDAO.ReservationRoom(room))
+	}
+	return records
+}
+
+func reservationRoomsToDomain(records []hotelsDAO.ReservationRoom) []hotelsDomain.ReservationRoom {
+	rooms := make([]hotelsDomain.ReservationRoom, 0, len(records))
+	for _, record := range records {
+		rooms = append(rooms, hotelsDomain.ReservationRoom(record))
+	}
+	return rooms
+}
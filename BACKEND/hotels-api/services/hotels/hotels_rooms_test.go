@@ -0,0 +1,23 @@
+package hotels
+
+import (
+	"testing"
+
+	hotelsDomain "hotels-api/domain/hotels"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitRooms(t *testing.T) {
+	rooms := splitRooms("double", 3, 7)
+	assert.Equal(t, []hotelsDomain.ReservationRoom{
+		{RoomType: "double", Guests: 3},
+		{RoomType: "double", Guests: 2},
+		{RoomType: "double", Guests: 2},
+	}, rooms)
+
+	// Con menos huespedes que habitaciones cada habitacion tiene al menos uno
+	for _, room := range splitRooms("single", 2, 1) {
+		assert.Equal(t, 1, room.Guests)
+	}
+}
@@ -2,9 +2,18 @@ package hotels
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	hotelsDAO "hotels-api/dao/hotels"
 	hotelsDomain "hotels-api/domain/hotels"
+	"hotels-api/services/availability"
+	"log"
+	"strings"
+	"sync/atomic"
+	"tiered"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 // Estas funciones salen de los repositorios, se encargan de interactuar tanto de la base de datos como de la cache, ambas tienen las mismas funciones pero con diferentes implementaciones para cada cosa
@@ -14,91 +23,296 @@ type Repository interface {
 	Update(ctx context.Context, hotel hotelsDAO.Hotel) error
 	Delete(ctx context.Context, id string) error
 	CreateReservation(ctx context.Context, reservation hotelsDAO.Reservation) (string, error)
-	CancelReservation(ctx context.Context, id string) error
+	CancelReservation(ctx context.Context, id string, cancellation hotelsDAO.Cancellation) error
+	CancelReservationRoom(ctx context.Context, id string, roomID string, penalty float64) (hotelsDAO.Reservation, error)
+	UpdateReservation(ctx context.Context, reservation hotelsDAO.Reservation) error
+	GetReservationByID(ctx context.Context, id string) (hotelsDAO.Reservation, error)
 	GetReservationsByHotelID(ctx context.Context, hotelID string) ([]hotelsDAO.Reservation, error)
 	GetReservationsByUserAndHotelID(ctx context.Context, hotelID string, userID string) ([]hotelsDAO.Reservation, error)
 	GetReservationsByUserID(ctx context.Context, userID string) ([]hotelsDAO.Reservation, error)
 	GetAvailability(ctx context.Context, hotelIDs []string, checkIn, checkOut string) (map[string]bool, error)
 }
 
+// Funciones que solo tiene el repositorio principal (MongoDB)
+type MainRepository interface {
+	Repository
+	DeleteReservation(ctx context.Context, id string) error
+	GetReservationsInRange(ctx context.Context, hotelID string, from, to time.Time) ([]hotelsDAO.Reservation, error)
+	CreateWaitlistEntry(ctx context.Context, entry hotelsDAO.WaitlistEntry) (string, error)
+	GetWaitlist(ctx context.Context, hotelID string, status string) ([]hotelsDAO.WaitlistEntry, error)
+	OfferWaitlistEntry(ctx context.Context, id string, offeredAt, expiresAt time.Time) (bool, error)
+	ExpireWaitlistOffers(ctx context.Context, now time.Time) ([]string, error)
+	CompleteWaitlistOffers(ctx context.Context, hotelID, userID string, checkIn, checkOut time.Time) error
+	CreateHold(ctx context.Context, hold hotelsDAO.Hold) (string, error)
+	GetHoldByID(ctx context.Context, id string) (hotelsDAO.Hold, error)
+	GetActiveHolds(ctx context.Context, hotelID string, now time.Time) ([]hotelsDAO.Hold, error)
+	DeleteHold(ctx context.Context, id string) error
+	ClaimHold(ctx context.Context, id string, now time.Time) (bool, error)
+	DeleteExpiredHolds(ctx context.Context, now time.Time) ([]string, error)
+	CreatePayment(ctx context.Context, payment hotelsDAO.Payment) (string, error)
+	GetPaymentByReservationID(ctx context.Context, reservationID string) (hotelsDAO.Payment, error)
+	GetPaymentByAuthorizationID(ctx context.Context, authorizationID string) (hotelsDAO.Payment, error)
+	UpdatePayment(ctx context.Context, payment hotelsDAO.Payment) error
+	ReserveIdempotencyKey(ctx context.Context, key hotelsDAO.IdempotencyKey) (hotelsDAO.IdempotencyKey, bool, error)
+	CompleteIdempotencyKey(ctx context.Context, id string, result string) error
+	ReleaseIdempotencyKey(ctx context.Context, id string) error
+	AcquireLock(ctx context.Context, name, owner string, now time.Time, lease time.Duration) (bool, error)
+	RenewLock(ctx context.Context, name, owner string, now time.Time, lease time.Duration) (bool, error)
+	ReleaseLock(ctx context.Context, name, owner string) error
+}
+
+// Funciones que solo tiene la cache: las listas de reservas y las retenciones leidas de la base principal, que se
+// invalidan cuando cambian, y los calendarios de disponibilidad ya calculados
+type Cache interface {
+	Repository
+	SetReservationsByHotelID(ctx context.Context, hotelID string, reservations []hotelsDAO.Reservation) error
+	SetReservationsByUserID(ctx context.Context, userID string, reservations []hotelsDAO.Reservation) error
+	SetReservationsByUserAndHotelID(ctx context.Context, hotelID string, userID string, reservations []hotelsDAO.Reservation) error
+	InvalidateReservations(ctx context.Context, hotelID string, userID string) error
+	SetActiveHolds(ctx context.Context, hotelID string, holds []hotelsDAO.Hold) error
+	InvalidateHolds(ctx context.Context, hotelID string) error
+	SetHotel(ctx context.Context, hotel hotelsDAO.Hotel, ttl time.Duration) error
+	SetReservation(ctx context.Context, reservation hotelsDAO.Reservation, ttl time.Duration) error
+	DeleteReservation(ctx context.Context, id string) error
+	SetHotelNotFound(ctx context.Context, id string) error
+	Metrics(ctx context.Context) (hotelsDAO.CacheMetrics, error)
+	GetCalendar(ctx context.Context, hotelID string, from, to time.Time) (hotelsDAO.Calendar, error)
+	SetCalendar(ctx context.Context, calendar hotelsDAO.Calendar) error
+	InvalidateCalendars(ctx context.Context, hotelID string) error
+}
+
+// Cache compartida entre las replicas (Memcached), solo guarda hoteles
+type SharedCache interface {
+	Repository
+	SetHotel(ctx context.Context, hotel hotelsDAO.Hotel, ttl time.Duration) error
+}
+
+// Tiempo que cada nivel de la cache guarda un hotel o una reserva. La cache local es de cada replica, por eso dura
+// menos: lo que se cambia en otra replica se invalida con los eventos, y si alguno se pierde vence pronto
+const (
+	hotelCacheTTL       = 30 * time.Second
+	hotelSharedCacheTTL = 5 * time.Minute
+	reservationCacheTTL = 30 * time.Second
+)
+
 type Queue interface {
 	Publish(hotelNew hotelsDomain.HotelNew) error
 }
 
+// Cola de eventos de reservas, separada de la de hoteles que consume search-api
+type ReservationsQueue interface {
+	PublishReservation(reservationNew hotelsDomain.ReservationNew) error
+}
+
+// Funcion de users-api, para validar los usuarios de las reservas
+type UsersAPI interface {
+	UserExists(ctx context.Context, userID string) (bool, error)
+}
+
+// Funciones para avisarle a los usuarios, por ejemplo que se libero lugar en la lista de espera
+type Notifier interface {
+	NotifyWaitlistOffer(ctx context.Context, entry hotelsDomain.WaitlistEntry) error
+}
+
+// Funciones del procesador de pagos. Un rechazo no es un error, viene con Approved en false
+type PaymentGateway interface {
+	Authorize(ctx context.Context, request hotelsDomain.PaymentRequest) (hotelsDomain.PaymentResult, error)
+	Capture(ctx context.Context, authorizationID string, amount float64, idempotencyKey string) (hotelsDomain.PaymentResult, error)
+	Refund(ctx context.Context, authorizationID string, amount float64, idempotencyKey string) (hotelsDomain.PaymentResult, error)
+	Void(ctx context.Context, authorizationID string, idempotencyKey string) (hotelsDomain.PaymentResult, error)
+	VerifyWebhook(payload []byte, signature string) bool
+}
+
 type Service struct {
-	mainRepository  Repository
-	cacheRepository Repository
-	eventsQueue     Queue
+	mainRepository    MainRepository
+	cacheRepository   Cache
+	eventsQueue       Queue
+	reservationsQueue ReservationsQueue
+	usersAPI          UsersAPI
+	notifier          Notifier
+	paymentGateway    PaymentGateway
+	// Hoteles y reservas por ID: cache local, cache compartida si hay, y base principal
+	hotels       tiered.Cache[string, hotelsDAO.Hotel]
+	reservations tiered.Cache[string, hotelsDAO.Reservation]
+	// Cantidad de escrituras e invalidaciones de la cache que fallaron, la peticion sigue igual porque la base
+	// principal ya se actualizo
+	cacheErrors *atomic.Int64
+}
+
+// Funcion que se encarga de crear un nuevo servicio con los repositorios, la cache compartida (puede ser nil), las colas
+// de eventos de hoteles y de reservas, el cliente de users-api, el notificador y el procesador de pagos
+func NewService(mainRepository MainRepository, cacheRepository Cache, sharedCache SharedCache, eventsQueue Queue, reservationsQueue ReservationsQueue, usersAPI UsersAPI, notifier Notifier, paymentGateway PaymentGateway) Service {
+	service := Service{
+		mainRepository:    mainRepository,
+		cacheRepository:   cacheRepository,
+		eventsQueue:       eventsQueue,
+		reservationsQueue: reservationsQueue,
+		usersAPI:          usersAPI,
+		notifier:          notifier,
+		paymentGateway:    paymentGateway,
+		cacheErrors:       &atomic.Int64{},
+	}
+	service.hotels = tiered.New(tiered.Config[string, hotelsDAO.Hotel]{
+		Levels:     hotelLevels(cacheRepository, sharedCache),
+		Load:       mainRepository.GetHotelByID,
+		IsNotFound: func(err error) bool { return errors.Is(err, hotelsDAO.ErrNotFound) },
+		OnError:    func(err error) { service.cacheFailed("error writing hotel cache", err) },
+	})
+	service.reservations = tiered.New(tiered.Config[string, hotelsDAO.Reservation]{
+		Levels: []tiered.Level[string, hotelsDAO.Reservation]{{
+			Name: "cache",
+			Tier: tiered.Funcs[string, hotelsDAO.Reservation]{
+				GetFunc: cacheRepository.GetReservationByID,
+				SetFunc: func(ctx context.Context, id string, reservation hotelsDAO.Reservation, ttl time.Duration) error {
+					return cacheRepository.SetReservation(ctx, reservation, ttl)
+				},
+				DeleteFunc: cacheRepository.DeleteReservation,
+			},
+			TTL: reservationCacheTTL,
+		}},
+		Load:    mainRepository.GetReservationByID,
+		OnError: func(err error) { service.cacheFailed("error writing reservation cache", err) },
+	})
+	return service
+}
+
+// Funcion que arma los niveles de la cache de hoteles, cada uno con su duracion
+// La cache local tambien recuerda por un rato los hoteles que no existen
+func hotelLevels(cacheRepository Cache, sharedCache SharedCache) []tiered.Level[string, hotelsDAO.Hotel] {
+	levels := []tiered.Level[string, hotelsDAO.Hotel]{{
+		Name: "cache",
+		Tier: tiered.Funcs[string, hotelsDAO.Hotel]{
+			GetFunc:         cacheRepository.GetHotelByID,
+			SetFunc:         hotelSetter(cacheRepository),
+			DeleteFunc:      cacheRepository.Delete,
+			SetNotFoundFunc: cacheRepository.SetHotelNotFound,
+		},
+		TTL: hotelCacheTTL,
+	}}
+	if sharedCache != nil {
+		levels = append(levels, tiered.Level[string, hotelsDAO.Hotel]{
+			Name: "shared cache",
+			Tier: tiered.Funcs[string, hotelsDAO.Hotel]{
+				GetFunc:    sharedCache.GetHotelByID,
+				SetFunc:    hotelSetter(sharedCache),
+				DeleteFunc: sharedCache.Delete,
+			},
+			TTL: hotelSharedCacheTTL,
+		})
+	}
+	return levels
 }
 
-// Funcion que se encarga de crear un nuevo servicio con los repositorios y la cola de eventos
-func NewService(mainRepository Repository, cacheRepository Repository, eventsQueue Queue) Service {
-	return Service{
-		mainRepository:  mainRepository,
-		cacheRepository: cacheRepository,
-		eventsQueue:     eventsQueue,
+// Funcion que adapta SetHotel de un repositorio al SetFunc de un nivel, la clave es el ID del hotel
+func hotelSetter(repository SharedCache) func(ctx context.Context, id string, hotel hotelsDAO.Hotel, ttl time.Duration) error {
+	return func(ctx context.Context, id string, hotel hotelsDAO.Hotel, ttl time.Duration) error {
+		return repository.SetHotel(ctx, hotel, ttl)
 	}
 }
 
 // Funcion que se encarga de obtener un hotel por su ID, primero se intenta obtener de la cache, si no se encuentra se obtiene de la base de datos principal y se guarda en la cache
+// La cache tambien recuerda por un rato los hoteles que no existen
 func (service Service) GetHotelByID(ctx context.Context, id string) (hotelsDomain.Hotel, error) {
-	// Se intenta obtener el hotel de la cache
-	hotelDAO, err := service.cacheRepository.GetHotelByID(ctx, id)
+	// Se busca en las caches y si no esta se lee de la base principal
+	hotelDAO, err := service.hotels.Get(ctx, id)
 	if err != nil {
-		// Si no se encuentra en la cache, se obtiene de la base de datos principal
-		hotelDAO, err = service.mainRepository.GetHotelByID(ctx, id)
-		if err != nil {
-			return hotelsDomain.Hotel{}, fmt.Errorf("error getting hotel from repository: %v", err)
-		}
-		// Se guarda el hotel en la cache
-		if _, err := service.cacheRepository.Create(ctx, hotelDAO); err != nil {
-			return hotelsDomain.Hotel{}, fmt.Errorf("error creating hotel in cache: %w", err)
-		}
+		return hotelsDomain.Hotel{}, fmt.Errorf("error getting hotel: %w", err)
 	}
 
 	// Lo pasa de formato de base de datos a formato de dominio para las respuestas
 	//Lo devuelve en formato de dominio
+	latitude, longitude := hotelDAO.Location.LatLon()
 	return hotelsDomain.Hotel{
-		ID:            hotelDAO.ID,
-		Name:          hotelDAO.Name,
-		Description:   hotelDAO.Description,
-		Address:       hotelDAO.Address,
-		City:          hotelDAO.City,
-		State:         hotelDAO.State,
-		Country:       hotelDAO.Country,
-		Phone:         hotelDAO.Phone,
-		Email:         hotelDAO.Email,
-		PricePerNight: hotelDAO.PricePerNight,
-		Rating:        hotelDAO.Rating,
-		AvaiableRooms: hotelDAO.AvaiableRooms,
-		CheckInTime:   hotelDAO.CheckInTime,
-		CheckOutTime:  hotelDAO.CheckOutTime,
-		Amenities:     hotelDAO.Amenities,
-		Images:        hotelDAO.Images,
+		ID:                 hotelDAO.ID,
+		Name:               hotelDAO.Name,
+		Description:        hotelDAO.Description,
+		Address:            hotelDAO.Address,
+		City:               hotelDAO.City,
+		State:              hotelDAO.State,
+		Country:            hotelDAO.Country,
+		Phone:              hotelDAO.Phone,
+		Email:              hotelDAO.Email,
+		PricePerNight:      hotelDAO.PricePerNight,
+		Rating:             hotelDAO.Rating,
+		AvaiableRooms:      hotelDAO.AvaiableRooms,
+		CheckInTime:        hotelDAO.CheckInTime,
+		CheckOutTime:       hotelDAO.CheckOutTime,
+		Amenities:          hotelDAO.Amenities,
+		Images:             hotelDAO.Images,
+		Version:            hotelDAO.Version,
+		UpdatedAt:          hotelDAO.UpdatedAt,
+		Latitude:           latitude,
+		Longitude:          longitude,
+		Pricing:            pricingToDomain(hotelDAO.Pricing),
+		MaxGuestsPerRoom:   hotelDAO.MaxGuestsPerRoom,
+		RoomTypes:          roomTypesToDomain(hotelDAO.RoomTypes),
+		CancellationPolicy: cancellationPolicyToDomain(hotelDAO.CancellationPolicy),
+		ManagerIDs:         hotelDAO.ManagerIDs,
+	}, nil
+}
+
+// Funcion que registra una falla de la cache. La cache es una ayuda: si no se pudo escribir o invalidar algo, lo peor
+// que pasa es que una lectura va a la base principal o ve un dato viejo hasta que venza, asi que no se corta la peticion
+func (service Service) cacheFailed(message string, err error) {
+	service.cacheErrors.Add(1)
+	log.Printf("%s: %v", message, err)
+}
+
+// Funcion que devuelve los contadores de la cache, con la proporcion de aciertos
+func (service Service) CacheMetrics(ctx context.Context) (hotelsDomain.CacheMetrics, error) {
+	metrics, err := service.cacheRepository.Metrics(ctx)
+	if err != nil {
+		return hotelsDomain.CacheMetrics{}, fmt.Errorf("error getting cache metrics: %w", err)
+	}
+	ratio := 0.0
+	if lookups := metrics.Hits + metrics.Misses; lookups > 0 {
+		ratio = float64(metrics.Hits) / float64(lookups)
+	}
+	return hotelsDomain.CacheMetrics{
+		Hits:         metrics.Hits,
+		Misses:       metrics.Misses,
+		HitRatio:     ratio,
+		NotFoundHits: metrics.NotFoundHits,
+		Evictions:    metrics.Evictions,
+		Items:        metrics.Items,
+		Errors:       service.cacheErrors.Load(),
 	}, nil
 }
 
 // Funcion que se encarga de crear un nuevo hotel, primero se crea en la base de datos principal, luego en la cache y por ultimo se publica un evento para notificar que se creo un nuevo hotel
 func (service Service) Create(ctx context.Context, hotel hotelsDomain.Hotel) (string, error) {
+	if err := validateCancellationPolicy(hotel.CancellationPolicy); err != nil {
+		return "", err
+	}
+	if err := validateLocation(hotel.Latitude, hotel.Longitude); err != nil {
+		return "", err
+	}
 	// Convierte el modelo de dominio a modelo DAO
 	//Modelo de como viene -> modelo base de datos
 	record := hotelsDAO.Hotel{
-		Name:          hotel.Name,
-		Description:   hotel.Description,
-		Address:       hotel.Address,
-		City:          hotel.City,
-		State:         hotel.State,
-		Country:       hotel.Country,
-		Phone:         hotel.Phone,
-		Email:         hotel.Email,
-		PricePerNight: hotel.PricePerNight,
-		Rating:        hotel.Rating,
-		AvaiableRooms: hotel.AvaiableRooms,
-		CheckInTime:   hotel.CheckInTime,
-		CheckOutTime:  hotel.CheckOutTime,
-		Amenities:     hotel.Amenities,
-		Images:        hotel.Images,
+		Name:               hotel.Name,
+		Description:        hotel.Description,
+		Address:            hotel.Address,
+		City:               hotel.City,
+		State:              hotel.State,
+		Country:            hotel.Country,
+		Phone:              hotel.Phone,
+		Email:              hotel.Email,
+		PricePerNight:      hotel.PricePerNight,
+		Rating:             hotel.Rating,
+		AvaiableRooms:      hotel.AvaiableRooms,
+		CheckInTime:        hotel.CheckInTime,
+		CheckOutTime:       hotel.CheckOutTime,
+		Amenities:          hotel.Amenities,
+		Images:             hotel.Images,
+		Location:           hotelsDAO.NewGeoPoint(hotel.Latitude, hotel.Longitude),
+		Pricing:            pricingToDAO(hotel.Pricing),
+		MaxGuestsPerRoom:   hotel.MaxGuestsPerRoom,
+		RoomTypes:          roomTypesToDAO(hotel.RoomTypes),
+		CancellationPolicy: cancellationPolicyToDAO(hotel.CancellationPolicy),
 	}
+	// Asigna la version inicial del hotel, que viaja en los eventos para que search-api pueda descartar los desactualizados
+	record.UpdatedAt, record.Version = newVersion()
 	// Crea el hotel en el repositorio principal (base de datos -> MongoDB)
 	id, err := service.mainRepository.Create(ctx, record)
 	if err != nil {
@@ -107,13 +321,12 @@ func (service Service) Create(ctx context.Context, hotel hotelsDomain.Hotel) (st
 	// Crea el hotel en el repositorio de cache
 	//El id que usan es el ObjectId de MongoDB
 	record.ID = id
-	if _, err := service.cacheRepository.Create(ctx, record); err != nil {
-		return "", fmt.Errorf("error creating hotel in cache: %w", err)
-	}
+	service.hotels.Set(ctx, id, record)
 	// Publica un evento para notificar la creación del hotel (RabbitMQ)
 	if err := service.eventsQueue.Publish(hotelsDomain.HotelNew{
 		Operation: "CREATE",
 		HotelID:   id,
+		Version:   record.Version,
 	}); err != nil {
 		return "", fmt.Errorf("error publishing hotel new: %w", err)
 	}
@@ -123,25 +336,38 @@ func (service Service) Create(ctx context.Context, hotel hotelsDomain.Hotel) (st
 
 // Funcion que se encarga de actualizar un hotel, primero se actualiza en la base de datos principal, luego en la cache y por ultimo se publica un evento para notificar que se actualizo un hotel
 func (service Service) Update(ctx context.Context, hotel hotelsDomain.Hotel) error {
+	if err := validateCancellationPolicy(hotel.CancellationPolicy); err != nil {
+		return err
+	}
+	if err := validateLocation(hotel.Latitude, hotel.Longitude); err != nil {
+		return err
+	}
 	// Convierte el modelo de dominio a modelo DAO
 	record := hotelsDAO.Hotel{
-		ID:            hotel.ID,
-		Name:          hotel.Name,
-		Description:   hotel.Description,
-		Address:       hotel.Address,
-		City:          hotel.City,
-		State:         hotel.State,
-		Country:       hotel.Country,
-		Phone:         hotel.Phone,
-		Email:         hotel.Email,
-		PricePerNight: hotel.PricePerNight,
-		Rating:        hotel.Rating,
-		AvaiableRooms: hotel.AvaiableRooms,
-		CheckInTime:   hotel.CheckInTime,
-		CheckOutTime:  hotel.CheckOutTime,
-		Amenities:     hotel.Amenities,
-		Images:        hotel.Images,
+		ID:                 hotel.ID,
+		Name:               hotel.Name,
+		Description:        hotel.Description,
+		Address:            hotel.Address,
+		City:               hotel.City,
+		State:              hotel.State,
+		Country:            hotel.Country,
+		Phone:              hotel.Phone,
+		Email:              hotel.Email,
+		PricePerNight:      hotel.PricePerNight,
+		Rating:             hotel.Rating,
+		AvaiableRooms:      hotel.AvaiableRooms,
+		CheckInTime:        hotel.CheckInTime,
+		CheckOutTime:       hotel.CheckOutTime,
+		Amenities:          hotel.Amenities,
+		Images:             hotel.Images,
+		Location:           hotelsDAO.NewGeoPoint(hotel.Latitude, hotel.Longitude),
+		Pricing:            pricingToDAO(hotel.Pricing),
+		MaxGuestsPerRoom:   hotel.MaxGuestsPerRoom,
+		RoomTypes:          roomTypesToDAO(hotel.RoomTypes),
+		CancellationPolicy: cancellationPolicyToDAO(hotel.CancellationPolicy),
 	}
+	// Cada actualizacion genera una version mayor a la anterior
+	record.UpdatedAt, record.Version = newVersion()
 
 	// Actualiza el hotel en el repositorio principal (MongoDB)
 	err := service.mainRepository.Update(ctx, record)
@@ -149,15 +375,19 @@ func (service Service) Update(ctx context.Context, hotel hotelsDomain.Hotel) err
 		return fmt.Errorf("error updating hotel in main repository: %w", err)
 	}
 
-	//INTENTA actualizar el hotel en el repositorio de cache
-	if err := service.cacheRepository.Update(ctx, record); err != nil {
-		return fmt.Errorf("error updating hotel in cache: %w", err)
+	// Se saca el hotel de las caches, porque record solo tiene los campos que cambiaron. La proxima lectura lo trae
+	// completo de la base principal, y las demas replicas borran su copia local al recibir el evento
+	service.hotels.Delete(ctx, hotel.ID)
+	// Los precios y la capacidad del calendario pueden haber cambiado
+	if err := service.cacheRepository.InvalidateCalendars(ctx, hotel.ID); err != nil {
+		service.cacheFailed("error invalidating calendars in cache", err)
 	}
 
 	// Publica un evento para notificar la actualización del hotel (RabbitMQ)
 	if err := service.eventsQueue.Publish(hotelsDomain.HotelNew{
 		Operation: "UPDATE",
 		HotelID:   hotel.ID,
+		Version:   record.Version,
 	}); err != nil {
 		return fmt.Errorf("error publishing hotel update: %w", err)
 	}
@@ -173,15 +403,19 @@ func (service Service) Delete(ctx context.Context, id string) error {
 		return fmt.Errorf("error deleting hotel from main repository: %w", err)
 	}
 
-	// Intenta eliminar el hotel del repositorio de cache
-	if err := service.cacheRepository.Delete(ctx, id); err != nil {
-		return fmt.Errorf("error deleting hotel from cache: %w", err)
+	// Elimina el hotel de las caches
+	service.hotels.Delete(ctx, id)
+	if err := service.cacheRepository.InvalidateCalendars(ctx, id); err != nil {
+		service.cacheFailed("error invalidating calendars in cache", err)
 	}
 
 	// Publica un evento para notificar la eliminación del hotel (RabbitMQ)
+	// La version del borrado es mayor a cualquier version previa del hotel
+	_, version := newVersion()
 	if err := service.eventsQueue.Publish(hotelsDomain.HotelNew{
 		Operation: "DELETE",
 		HotelID:   id,
+		Version:   version,
 	}); err != nil {
 		return fmt.Errorf("error publishing hotel delete: %w", err)
 	}
@@ -189,44 +423,346 @@ func (service Service) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// Funcion que valida las coordenadas del hotel, que se guardan en el indice 2dsphere de MongoDB y en Solr
+// Sin coordenadas (0, 0) el hotel queda sin ubicacion
+func validateLocation(latitude, longitude float64) error {
+	if latitude < -90 || latitude > 90 {
+		return hotelsDomain.ValidationError{Field: "latitude", Message: "must be between -90 and 90"}
+	}
+	if longitude < -180 || longitude > 180 {
+		return hotelsDomain.ValidationError{Field: "longitude", Message: "must be between -180 and 180"}
+	}
+	return nil
+}
+
+// Funcion que genera la fecha de actualizacion y la version de un hotel
+// La version son los microsegundos de la fecha de actualizacion, por lo que crece entre cambios sucesivos
+// y entra sin perder precision en un float64 (JSON de Solr y del frontend)
+func newVersion() (time.Time, int64) {
+	updatedAt := time.Now().UTC()
+	return updatedAt, updatedAt.UnixMicro()
+}
+
+const (
+	defaultCurrency         = "USD" // Moneda de los precios de los hoteles
+	defaultMaxGuestsPerRoom = 2     // Capacidad de una habitacion si el hotel no la define
+)
+
+// Funcion que crea una reserva validando las fechas, el hotel, el usuario y la capacidad, y guardando el precio cotizado
+// Los datos mal formados se devuelven como hotelsDomain.ValidationError y los que no se pueden cumplir
+// (hotel o usuario inexistente, sin capacidad) como hotelsDomain.UnprocessableError
+// La reserva solo se confirma si se autoriza el pago con el token del cliente, si no se devuelve hotelsDomain.PaymentError
 func (service Service) CreateReservation(ctx context.Context, reservation hotelsDomain.Reservation) (string, error) {
-	record := hotelsDAO.Reservation{
-		HotelName: reservation.HotelName,
-		HotelID:   reservation.HotelID,
-		UserID:    reservation.UserID,
-		CheckIn:   reservation.CheckIn,
-		CheckOut:  reservation.CheckOut,
+	key := reservation.IdempotencyKey
+	reservation.IdempotencyKey = ""
+	return service.idempotent(ctx, key, reservation.UserID, "reservation", reservation, func(key string) (string, error) {
+		reservation.IdempotencyKey = key
+		return service.createReservation(ctx, reservation, "")
+	})
+}
+
+// Funcion que crea la reserva. holdID es la retencion que se esta confirmando, o vacio si no hay
+// Lo que depende de otros servicios (users-api y el procesador de pagos) se hace antes de bloquear el hotel, asi una
+// respuesta lenta no hace vencer el lock; con el hotel bloqueado solo se vuelve a verificar y se guarda
+func (service Service) createReservation(ctx context.Context, reservation hotelsDomain.Reservation, holdID string) (string, error) {
+	if strings.TrimSpace(reservation.PaymentToken) == "" {
+		return "", hotelsDomain.ValidationError{Field: "payment_token", Message: "is required"}
+	}
+
+	// Se verifica y se cotiza sin bloquear, para no autorizar el pago de una estadia que no se puede reservar
+	hotel, reservations, err := service.checkStay(ctx, &reservation, holdID)
+	if err != nil {
+		return "", err
+	}
+	record := quoteReservation(hotel, &reservation, reservations)
+	// Sin pago autorizado no hay reserva
+	authorization, err := service.authorizePayment(ctx, record, reservation.PaymentToken, reservation.IdempotencyKey)
+	if err != nil {
+		return "", err
+	}
+
+	// Se bloquea el hotel para que nadie tome las habitaciones entre que se verifican y se guarda la reserva
+	err = service.withHotelLock(ctx, reservation.HotelID, func(lock hotelLock) error {
+		var err error
+		record, err = service.placeReservation(ctx, lock, hotel, reservation, authorization, holdID)
+		return err
+	})
+	if err != nil {
+		service.voidAuthorization(ctx, authorization.TransactionID)
+		return "", err
+	}
+
+	// Crea la reserva en el repositorio de cache
+	service.reservations.Set(ctx, record.ID, record)
+	// Las listas del hotel y del usuario ya no tienen todas sus reservas
+	if err := service.cacheRepository.InvalidateReservations(ctx, record.HotelID, record.UserID); err != nil {
+		service.cacheFailed("error invalidating reservations in cache", err)
+	}
+	// Si el usuario reservo con una oferta de la lista de espera, la oferta ya se uso
+	// La reserva ya esta guardada y pagada, si falla la oferta sigue ocupando lugar hasta que vence
+	if err := service.mainRepository.CompleteWaitlistOffers(ctx, record.HotelID, record.UserID, record.CheckIn, record.CheckOut); err != nil {
+		log.Printf("error completing waitlist offers of reservation %s: %v", record.ID, err)
+	}
+	// Las ofertas usadas dejan de ocupar habitaciones aparte de la reserva
+	if err := service.cacheRepository.InvalidateHolds(ctx, record.HotelID); err != nil {
+		service.cacheFailed("error invalidating holds in cache", err)
+	}
+	// La reserva ocupa habitaciones en los calendarios del hotel
+	if err := service.cacheRepository.InvalidateCalendars(ctx, record.HotelID); err != nil {
+		service.cacheFailed("error invalidating calendars in cache", err)
+	}
+	// Las demas replicas tambien tienen que ver la reserva al calcular la disponibilidad
+	service.publishReservationsChanged(record.HotelID, record.UserID, record.ID)
+
+	return record.ID, nil
+}
+
+// Funcion que vuelve a verificar y cotizar la reserva con el hotel ya bloqueado, y la guarda con su pago autorizado
+// Si el precio subio por encima de lo autorizado (se ocupo el hotel mientras tanto) se devuelve un hotelsDomain.ConflictError
+func (service Service) placeReservation(ctx context.Context, lock hotelLock, hotel hotelsDomain.Hotel, reservation hotelsDomain.Reservation, authorization hotelsDomain.PaymentResult, holdID string) (hotelsDAO.Reservation, error) {
+	reservations, err := service.checkRoomsFree(ctx, hotel, reservation, holdID)
+	if err != nil {
+		return hotelsDAO.Reservation{}, err
+	}
+	record := quoteReservation(hotel, &reservation, reservations)
+	if record.TotalPrice > authorization.Amount {
+		return hotelsDAO.Reservation{}, hotelsDomain.ConflictError{Message: "the price of the stay changed while booking, try again"}
+	}
+
+	// Lo verificado solo vale si el hotel sigue bloqueado por esta peticion
+	if err := lock.confirm(ctx); err != nil {
+		return hotelsDAO.Reservation{}, err
+	}
+	// La retencion se reclama con el hotel bloqueado, si vencio o la confirmo otra peticion no se reserva
+	if holdID != "" {
+		claimed, err := service.mainRepository.ClaimHold(ctx, holdID, time.Now().UTC())
+		if err != nil {
+			return hotelsDAO.Reservation{}, fmt.Errorf("error claiming hold in main repository: %w", err)
+		}
+		if !claimed {
+			return hotelsDAO.Reservation{}, hotelsDomain.UnprocessableError{Field: "hold", Message: "is expired"}
+		}
 	}
 	// Crea la reserva en el repositorio principal (base de datos -> MongoDB)
 	id, err := service.mainRepository.CreateReservation(ctx, record)
 	if err != nil {
-		return "", fmt.Errorf("error creating reservation in main repository: %w", err)
+		return hotelsDAO.Reservation{}, fmt.Errorf("error creating reservation in main repository: %w", err)
+	}
+	// Guarda el pago autorizado de la reserva, si no se puede la reserva no queda
+	now := time.Now().UTC()
+	_, err = service.mainRepository.CreatePayment(ctx, hotelsDAO.Payment{
+		ReservationID:    id,
+		AuthorizationID:  authorization.TransactionID,
+		Status:           hotelsDAO.PaymentAuthorized,
+		Currency:         record.Currency,
+		AuthorizedAmount: authorization.Amount,
+		Events: []hotelsDAO.PaymentEvent{{
+			ID:              authorization.TransactionID,
+			Type:            hotelsDAO.PaymentEventAuthorized,
+			AuthorizationID: authorization.TransactionID,
+			Amount:          authorization.Amount,
+			CreatedAt:       now,
+		}},
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		if deleteErr := service.mainRepository.DeleteReservation(ctx, id); deleteErr != nil {
+			log.Printf("error deleting reservation %s without payment: %v", id, deleteErr)
+		}
+		return hotelsDAO.Reservation{}, fmt.Errorf("error creating payment in main repository: %w", err)
 	}
-	// Crea la reserva en el repositorio de cache
 	record.ID = id
-	if _, err := service.cacheRepository.CreateReservation(ctx, record); err != nil {
-		return "", fmt.Errorf("error creating reservation in cache: %w", err)
+	return record, nil
+}
+
+// Funcion que cotiza cada habitacion con la tarifa de su tipo y arma la reserva a guardar, el total es la suma
+// reservations son las reservas del hotel, para los recargos por ocupacion
+func quoteReservation(hotel hotelsDomain.Hotel, reservation *hotelsDomain.Reservation, reservations []hotelsDAO.Reservation) hotelsDAO.Reservation {
+	roomTypes := make(map[string]hotelsDomain.RoomType)
+	for _, roomType := range roomTypesOf(hotel) {
+		roomTypes[roomType.Code] = roomType
 	}
 
-	return id, nil
+	occupancy := availability.ReservedByNight(reservations, toDate(reservation.CheckIn), toDate(reservation.CheckOut))
+	totalPrice := 0.0
+	for i, room := range reservation.Rooms {
+		price := roomPrice(hotel, roomTypes[room.RoomType], room.Guests, reservation.CheckIn, reservation.CheckOut, occupancy)
+		reservation.Rooms[i].ID = uuid.New().String()
+		reservation.Rooms[i].Price = price
+		reservation.Rooms[i].Cancelled = false
+		reservation.Rooms[i].CancelledAt = nil
+		reservation.Rooms[i].Penalty = 0
+		totalPrice += price
+	}
+
+	return hotelsDAO.Reservation{
+		HotelName:       reservation.HotelName,
+		HotelID:         reservation.HotelID,
+		UserID:          reservation.UserID,
+		CheckIn:         reservation.CheckIn,
+		CheckOut:        reservation.CheckOut,
+		Guests:          reservation.Guests,
+		RoomCount:       reservation.RoomCount,
+		Rooms:           reservationRoomsToDAO(reservation.Rooms),
+		TotalPrice:      roundPrice(totalPrice),
+		Currency:        defaultCurrency,
+		SpecialRequests: strings.TrimSpace(reservation.SpecialRequests),
+		ContactName:     strings.TrimSpace(reservation.ContactName),
+		ContactEmail:    strings.TrimSpace(reservation.ContactEmail),
+		ContactPhone:    strings.TrimSpace(reservation.ContactPhone),
+		Status:          hotelsDAO.ReservationConfirmed,
+		CreatedAt:       time.Now().UTC(),
+	}
 }
 
-func (service Service) CancelReservation(ctx context.Context, id string) error {
-	// Intenta eliminar la reserva del repositorio principal (MongoDB)
-	err := service.mainRepository.CancelReservation(ctx, id)
+// Funcion que prepara una estadia para reservarla o retenerla: completa las habitaciones y los huespedes y valida
+// las fechas, el hotel, el usuario y la capacidad de cada tipo de habitacion
+// holdID es la retencion que se esta confirmando, cuyas habitaciones no cuentan como ocupadas
+// Devuelve el hotel y sus reservas para cotizar la estadia
+func (service Service) checkStay(ctx context.Context, reservation *hotelsDomain.Reservation, holdID string) (hotelsDomain.Hotel, []hotelsDAO.Reservation, error) {
+	// Si la reserva detalla las habitaciones, la cantidad y los huespedes salen de ellas
+	// Si no, se asume una persona en una habitacion
+	if len(reservation.Rooms) > 0 {
+		reservation.RoomCount = len(reservation.Rooms)
+		reservation.Guests = 0
+		for i := range reservation.Rooms {
+			if reservation.Rooms[i].Guests < 0 {
+				return hotelsDomain.Hotel{}, nil, hotelsDomain.ValidationError{Field: "rooms", Message: "guests can't be negative"}
+			}
+			if reservation.Rooms[i].Guests == 0 {
+				reservation.Rooms[i].Guests = 1
+			}
+			reservation.Guests += reservation.Rooms[i].Guests
+		}
+	}
+	if reservation.Guests == 0 {
+		reservation.Guests = 1
+	}
+	if reservation.RoomCount == 0 {
+		reservation.RoomCount = 1
+	}
+	if err := validateReservation(*reservation, time.Now()); err != nil {
+		return hotelsDomain.Hotel{}, nil, err
+	}
+
+	// El hotel tiene que existir, y el nombre se toma de el y no de lo que manda el cliente
+	hotel, err := service.GetHotelByID(ctx, reservation.HotelID)
+	if errors.Is(err, hotelsDAO.ErrNotFound) {
+		return hotelsDomain.Hotel{}, nil, hotelsDomain.UnprocessableError{Field: "hotel_id", Message: "not found"}
+	}
+	if err != nil {
+		return hotelsDomain.Hotel{}, nil, fmt.Errorf("error getting hotel: %w", err)
+	}
+	reservation.HotelName = hotel.Name
+
+	// El usuario tiene que existir en users-api
+	exists, err := service.usersAPI.UserExists(ctx, reservation.UserID)
+	if err != nil {
+		return hotelsDomain.Hotel{}, nil, fmt.Errorf("error getting user: %w", err)
+	}
+	if !exists {
+		return hotelsDomain.Hotel{}, nil, hotelsDomain.UnprocessableError{Field: "user_id", Message: "not found"}
+	}
+
+	// Sin detalle, todas las habitaciones son del primer tipo del hotel
+	roomTypes := make(map[string]hotelsDomain.RoomType)
+	for _, roomType := range roomTypesOf(hotel) {
+		roomTypes[roomType.Code] = roomType
+	}
+	if len(reservation.Rooms) == 0 {
+		reservation.Rooms = splitRooms(roomTypesOf(hotel)[0].Code, reservation.RoomCount, reservation.Guests)
+	}
+
+	// Cada habitacion tiene que ser de un tipo del hotel y no superar su capacidad
+	for _, room := range reservation.Rooms {
+		roomType, ok := roomTypes[room.RoomType]
+		if !ok {
+			return hotelsDomain.Hotel{}, nil, hotelsDomain.UnprocessableError{Field: "rooms", Message: fmt.Sprintf("room type %s doesn't exist in the hotel", room.RoomType)}
+		}
+		if maxGuests := roomMaxGuests(hotel, roomType); room.Guests > maxGuests {
+			return hotelsDomain.Hotel{}, nil, hotelsDomain.UnprocessableError{Field: "guests", Message: fmt.Sprintf("exceeds %d guests per %s room", maxGuests, room.RoomType)}
+		}
+	}
+
+	reservations, err := service.checkRoomsFree(ctx, hotel, *reservation, holdID)
 	if err != nil {
-		return fmt.Errorf("error canceling reservation from main repository: %w", err)
+		return hotelsDomain.Hotel{}, nil, err
+	}
+	return hotel, reservations, nil
+}
+
+// Funcion que verifica que las habitaciones de una estadia ya preparada con checkStay esten libres
+// Solo consulta la base principal, asi se puede repetir con el hotel bloqueado justo antes de guardar
+// Devuelve las reservas del hotel para cotizar la estadia
+func (service Service) checkRoomsFree(ctx context.Context, hotel hotelsDomain.Hotel, reservation hotelsDomain.Reservation, holdID string) ([]hotelsDAO.Reservation, error) {
+	roomTypes := make(map[string]hotelsDomain.RoomType)
+	for _, roomType := range roomTypesOf(hotel) {
+		roomTypes[roomType.Code] = roomType
+	}
+	requested := make(map[string]int)
+	for _, room := range reservation.Rooms {
+		requested[room.RoomType]++
 	}
 
-	// Intenta eliminar la reserva del repositorio de cache
-	if err := service.cacheRepository.CancelReservation(ctx, id); err != nil {
-		return fmt.Errorf("error canceling reservation from cache: %w", err)
+	// La reserva sale entera o no sale: todas las habitaciones tienen que estar libres todas las noches
+	// Se usan las reservas de la base principal, la cache puede no tenerlas todas, y solo las de las noches pedidas
+	reservations, err := service.mainRepository.GetReservationsInRange(ctx, hotel.ID, toDate(reservation.CheckIn), toDate(reservation.CheckOut))
+	if err != nil {
+		return nil, fmt.Errorf("error getting reservations from repository: %w", err)
+	}
+	// Las habitaciones retenidas y las que se le guardan a otros usuarios de la lista de espera tampoco estan libres
+	held, err := service.heldRooms(ctx, hotel.ID, reservation.UserID, holdID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	reserved := availability.ReservedRoomsByType(append(held, reservations...), toDate(reservation.CheckIn), toDate(reservation.CheckOut))
+	for roomType, count := range requested {
+		if reserved[roomType]+count > roomTypes[roomType].Rooms {
+			return nil, hotelsDomain.UnprocessableError{Field: "rooms", Message: fmt.Sprintf("not enough %s rooms available", roomType)}
+		}
 	}
+	return reservations, nil
+}
 
+// Funcion que valida los datos de una reserva que no dependen del hotel
+func validateReservation(reservation hotelsDomain.Reservation, now time.Time) error {
+	if strings.TrimSpace(reservation.HotelID) == "" {
+		return hotelsDomain.ValidationError{Field: "hotel_id", Message: "is required"}
+	}
+	if strings.TrimSpace(reservation.UserID) == "" {
+		return hotelsDomain.ValidationError{Field: "user_id", Message: "is required"}
+	}
+	if reservation.CheckIn.IsZero() || reservation.CheckOut.IsZero() {
+		return hotelsDomain.ValidationError{Field: "check_in", Message: "check_in and check_out are required"}
+	}
+	if !toDate(reservation.CheckOut).After(toDate(reservation.CheckIn)) {
+		return hotelsDomain.ValidationError{Field: "check_out", Message: "must be after check_in"}
+	}
+	if toDate(reservation.CheckIn).Before(toDate(now.UTC())) {
+		return hotelsDomain.ValidationError{Field: "check_in", Message: "can't be in the past"}
+	}
+	if reservation.Guests < 0 {
+		return hotelsDomain.ValidationError{Field: "guests", Message: "must be a positive number"}
+	}
+	if reservation.RoomCount < 0 {
+		return hotelsDomain.ValidationError{Field: "room_count", Message: "must be a positive number"}
+	}
+	if reservation.ContactEmail != "" && !strings.Contains(reservation.ContactEmail, "@") {
+		return hotelsDomain.ValidationError{Field: "contact_email", Message: "is not a valid email"}
+	}
 	return nil
 }
 
-func (service Service) GetReservationsByHotelID(ctx context.Context, hotelID string) ([]hotelsDomain.Reservation, error) {
+// Funcion que lista las reservas de un hotel. Los administradores del hotel ven todas, el resto de los usuarios
+// solo las suyas
+func (service Service) GetReservationsByHotelID(ctx context.Context, hotelID string, requesterID string) ([]hotelsDomain.Reservation, error) {
+	hotel, err := service.GetHotelByID(ctx, hotelID)
+	if err != nil && !errors.Is(err, hotelsDAO.ErrNotFound) {
+		return nil, fmt.Errorf("error getting hotel: %w", err)
+	}
+	manager := isManager(hotel, requesterID)
+
 	// Se intenta obtener las reservas del repositorio de cache
 	reservationsDAO, err := service.cacheRepository.GetReservationsByHotelID(ctx, hotelID)
 	if err != nil {
@@ -235,31 +771,30 @@ func (service Service) GetReservationsByHotelID(ctx context.Context, hotelID str
 		if err != nil {
 			return nil, fmt.Errorf("error getting reservations from repository: %v", err)
 		}
-		// Se guardan las reservas en la cache
-		for _, reservationDAO := range reservationsDAO {
-			if _, err := service.cacheRepository.CreateReservation(ctx, reservationDAO); err != nil {
-				return nil, fmt.Errorf("error creating reservation in cache: %w", err)
-			}
+		// Se guarda la lista en la cache, hasta que se cree o cambie alguna de sus reservas
+		if err := service.cacheRepository.SetReservationsByHotelID(ctx, hotelID, reservationsDAO); err != nil {
+			service.cacheFailed("error caching reservations", err)
 		}
 	}
 
 	// Se convierten las reservas de formato de base de datos a formato de dominio
 	reservations := make([]hotelsDomain.Reservation, 0)
 	for _, reservationDAO := range reservationsDAO {
-		reservations = append(reservations, hotelsDomain.Reservation{
-			ID:        reservationDAO.ID,
-			HotelName: reservationDAO.HotelName,
-			HotelID:   reservationDAO.HotelID,
-			UserID:    reservationDAO.UserID,
-			CheckIn:   reservationDAO.CheckIn,
-			CheckOut:  reservationDAO.CheckOut,
-		})
+		if !manager && (requesterID == "" || reservationDAO.UserID != requesterID) {
+			continue
+		}
+		reservations = append(reservations, reservationToDomain(reservationDAO))
 	}
 
 	return reservations, nil
 }
 
-func (service Service) GetReservationsByUserAndHotelID(ctx context.Context, hotelID string, userID string) ([]hotelsDomain.Reservation, error) {
+// Funcion que lista las reservas de un usuario en un hotel, solo para ese usuario o los administradores del hotel
+func (service Service) GetReservationsByUserAndHotelID(ctx context.Context, hotelID string, userID string, requesterID string) ([]hotelsDomain.Reservation, error) {
+	if err := service.authorizeUserReservations(ctx, hotelID, userID, requesterID); err != nil {
+		return nil, err
+	}
+
 	// Se intenta obtener las reservas del repositorio de cache
 	reservationsDAO, err := service.cacheRepository.GetReservationsByUserAndHotelID(ctx, hotelID, userID)
 	if err != nil {
@@ -268,31 +803,27 @@ func (service Service) GetReservationsByUserAndHotelID(ctx context.Context, hote
 		if err != nil {
 			return nil, fmt.Errorf("error getting reservations from repository: %v", err)
 		}
-		// Se guardan las reservas en la cache
-		for _, reservationDAO := range reservationsDAO {
-			if _, err := service.cacheRepository.CreateReservation(ctx, reservationDAO); err != nil {
-				return nil, fmt.Errorf("error creating reservation in cache: %w", err)
-			}
+		// Se guarda la lista en la cache, hasta que se cree o cambie alguna de sus reservas
+		if err := service.cacheRepository.SetReservationsByUserAndHotelID(ctx, hotelID, userID, reservationsDAO); err != nil {
+			service.cacheFailed("error caching reservations", err)
 		}
 	}
 
 	// Se convierten las reservas de formato de base de datos a formato de dominio
 	reservations := make([]hotelsDomain.Reservation, 0)
 	for _, reservationDAO := range reservationsDAO {
-		reservations = append(reservations, hotelsDomain.Reservation{
-			ID:        reservationDAO.ID,
-			HotelName: reservationDAO.HotelName,
-			HotelID:   reservationDAO.HotelID,
-			UserID:    reservationDAO.UserID,
-			CheckIn:   reservationDAO.CheckIn,
-			CheckOut:  reservationDAO.CheckOut,
-		})
+		reservations = append(reservations, reservationToDomain(reservationDAO))
 	}
 
 	return reservations, nil
 }
 
-func (service Service) GetReservationsByUserID(ctx context.Context, userID string) ([]hotelsDomain.Reservation, error) {
+// Funcion que lista las reservas de un usuario, solo para ese mismo usuario
+func (service Service) GetReservationsByUserID(ctx context.Context, userID string, requesterID string) ([]hotelsDomain.Reservation, error) {
+	if err := service.authorizeUserReservations(ctx, "", userID, requesterID); err != nil {
+		return nil, err
+	}
+
 	// Se intenta obtener las reservas del repositorio de cache
 	reservationsDAO, err := service.cacheRepository.GetReservationsByUserID(ctx, userID)
 	if err != nil {
@@ -301,30 +832,45 @@ func (service Service) GetReservationsByUserID(ctx context.Context, userID strin
 		if err != nil {
 			return nil, fmt.Errorf("error getting reservations from repository: %v", err)
 		}
-		// Se guardan las reservas en la cache
-		for _, reservationDAO := range reservationsDAO {
-			if _, err := service.cacheRepository.CreateReservation(ctx, reservationDAO); err != nil {
-				return nil, fmt.Errorf("error creating reservation in cache: %w", err)
-			}
+		// Se guarda la lista en la cache, hasta que se cree o cambie alguna de sus reservas
+		if err := service.cacheRepository.SetReservationsByUserID(ctx, userID, reservationsDAO); err != nil {
+			service.cacheFailed("error caching reservations", err)
 		}
 	}
 
 	// Se convierten las reservas de formato de base de datos a formato de dominio
 	reservations := make([]hotelsDomain.Reservation, 0)
 	for _, reservationDAO := range reservationsDAO {
-		reservations = append(reservations, hotelsDomain.Reservation{
-			ID:        reservationDAO.ID,
-			HotelName: reservationDAO.HotelName,
-			HotelID:   reservationDAO.HotelID,
-			UserID:    reservationDAO.UserID,
-			CheckIn:   reservationDAO.CheckIn,
-			CheckOut:  reservationDAO.CheckOut,
-		})
+		reservations = append(reservations, reservationToDomain(reservationDAO))
 	}
 
 	return reservations, nil
 }
 
+// Funcion que pasa una reserva del formato de base de datos al de dominio
+func reservationToDomain(reservation hotelsDAO.Reservation) hotelsDomain.Reservation {
+	return hotelsDomain.Reservation{
+		ID:              reservation.ID,
+		HotelName:       reservation.HotelName,
+		HotelID:         reservation.HotelID,
+		UserID:          reservation.UserID,
+		CheckIn:         reservation.CheckIn,
+		CheckOut:        reservation.CheckOut,
+		Guests:          reservation.Guests,
+		RoomCount:       reservation.RoomCount,
+		Rooms:           reservationRoomsToDomain(reservation.Rooms),
+		TotalPrice:      reservation.TotalPrice,
+		Currency:        reservation.Currency,
+		SpecialRequests: reservation.SpecialRequests,
+		ContactName:     reservation.ContactName,
+		ContactEmail:    reservation.ContactEmail,
+		ContactPhone:    reservation.ContactPhone,
+		Status:          reservation.Status,
+		Cancellation:    cancellationToDomain(reservation.Cancellation),
+		CreatedAt:       reservation.CreatedAt,
+	}
+}
+
 // Hay que ver lo de hacerlo desde la cache
 func (service Service) GetAvailability(ctx context.Context, hotelIDs []string, checkIn, checkOut string) (map[string]bool, error) {
 	// Se intenta obtener la disponibilidad de los hoteles del repositorio de cache
@@ -1 +1,73 @@
 package hotels
+
+import (
+	"testing"
+	"time"
+
+	hotelsDomain "hotels-api/domain/hotels"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateReservation(t *testing.T) {
+	now := time.Date(2024, 12, 10, 15, 0, 0, 0, time.UTC)
+	valid := hotelsDomain.Reservation{
+		HotelID:   "hotel",
+		UserID:    "user",
+		CheckIn:   date(2024, 12, 10),
+		CheckOut:  date(2024, 12, 12),
+		Guests:    2,
+		RoomCount: 1,
+	}
+	assert.NoError(t, validateReservation(valid, now))
+
+	tests := []struct {
+		name   string
+		modify func(reservation *hotelsDomain.Reservation)
+		field  string
+	}{
+		{"Missing hotel", func(r *hotelsDomain.Reservation) { r.HotelID = "" }, "hotel_id"},
+		{"Missing dates", func(r *hotelsDomain.Reservation) { r.CheckOut = time.Time{} }, "check_in"},
+		{"Check-out before check-in", func(r *hotelsDomain.Reservation) { r.CheckOut = date(2024, 12, 9) }, "check_out"},
+		{"Same day", func(r *hotelsDomain.Reservation) { r.CheckOut = r.CheckIn }, "check_out"},
+		{"Past check-in", func(r *hotelsDomain.Reservation) { r.CheckIn = date(2024, 12, 9) }, "check_in"},
+		{"Negative guests", func(r *hotelsDomain.Reservation) { r.Guests = -1 }, "guests"},
+		{"Invalid email", func(r *hotelsDomain.Reservation) { r.ContactEmail = "nope" }, "contact_email"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reservation := valid
+			test.modify(&reservation)
+			var validationErr hotelsDomain.ValidationError
+			assert.ErrorAs(t, validateReservation(reservation, now), &validationErr)
+			assert.Equal(t, test.field, validationErr.Field)
+		})
+	}
+}
+
+func TestValidateLocation(t *testing.T) {
+	assert.NoError(t, validateLocation(0, 0))
+	assert.NoError(t, validateLocation(-34.6037, -58.3816))
+	assert.NoError(t, validateLocation(90, -180))
+
+	tests := []struct {
+		name      string
+		latitude  float64
+		longitude float64
+		field     string
+	}{
+		{"Latitude too low", -90.5, 0, "latitude"},
+		{"Latitude too high", 91, 10, "latitude"},
+		{"Longitude too low", 10, -180.1, "longitude"},
+		{"Longitude too high", 10, 200, "longitude"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateLocation(test.latitude, test.longitude)
+			var validationErr hotelsDomain.ValidationError
+			if assert.ErrorAs(t, err, &validationErr) {
+				assert.Equal(t, test.field, validationErr.Field)
+			}
+		})
+	}
+}
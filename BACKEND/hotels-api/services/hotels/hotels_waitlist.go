@@ -0,0 +1,261 @@
+package hotels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	hotelsDAO "hotels-api/dao/hotels"
+	hotelsDomain "hotels-api/domain/hotels"
+	"hotels-api/services/availability"
+	"log"
+	"strings"
+	"time"
+)
+
+// Tiempo que se le guarda la habitacion a un usuario de la lista de espera para que reserve
+const waitlistOfferDuration = 2 * time.Hour
+
+// Funcion que anota a un usuario en la lista de espera de un hotel para un rango de fechas
+// Solo se puede anotar si el hotel no tiene lugar para esas fechas, si lo tiene tiene que reservar
+func (service Service) JoinWaitlist(ctx context.Context, entry hotelsDomain.WaitlistEntry) (string, error) {
+	if entry.RoomCount == 0 {
+		entry.RoomCount = 1
+	}
+	if strings.TrimSpace(entry.UserID) == "" {
+		return "", hotelsDomain.ValidationError{Field: "user_id", Message: "is required"}
+	}
+	if entry.CheckIn.IsZero() || entry.CheckOut.IsZero() {
+		return "", hotelsDomain.ValidationError{Field: "check_in", Message: "check_in and check_out are required"}
+	}
+	if !toDate(entry.CheckOut).After(toDate(entry.CheckIn)) {
+		return "", hotelsDomain.ValidationError{Field: "check_out", Message: "must be after check_in"}
+	}
+	if toDate(entry.CheckIn).Before(toDate(time.Now().UTC())) {
+		return "", hotelsDomain.ValidationError{Field: "check_in", Message: "can't be in the past"}
+	}
+	if entry.RoomCount < 0 {
+		return "", hotelsDomain.ValidationError{Field: "room_count", Message: "must be a positive number"}
+	}
+
+	// El hotel y el usuario tienen que existir
+	hotel, err := service.GetHotelByID(ctx, entry.HotelID)
+	if errors.Is(err, hotelsDAO.ErrNotFound) {
+		return "", hotelsDomain.UnprocessableError{Field: "hotel_id", Message: "not found"}
+	}
+	if err != nil {
+		return "", fmt.Errorf("error getting hotel: %w", err)
+	}
+	exists, err := service.usersAPI.UserExists(ctx, entry.UserID)
+	if err != nil {
+		return "", fmt.Errorf("error getting user: %w", err)
+	}
+	if !exists {
+		return "", hotelsDomain.UnprocessableError{Field: "user_id", Message: "not found"}
+	}
+
+	// Sin tipo de habitacion se espera por el primer tipo del hotel
+	roomTypes := roomTypesOf(hotel)
+	if entry.RoomType == "" {
+		entry.RoomType = roomTypes[0].Code
+	}
+	roomType, ok := findRoomType(roomTypes, entry.RoomType)
+	if !ok {
+		return "", hotelsDomain.UnprocessableError{Field: "room_type", Message: fmt.Sprintf("room type %s doesn't exist in the hotel", entry.RoomType)}
+	}
+	if entry.RoomCount > roomType.Rooms {
+		return "", hotelsDomain.UnprocessableError{Field: "room_count", Message: fmt.Sprintf("the hotel has %d %s rooms", roomType.Rooms, entry.RoomType)}
+	}
+
+	// Si hay lugar no tiene sentido esperar
+	reservations, err := service.mainRepository.GetReservationsInRange(ctx, hotel.ID, entry.CheckIn, entry.CheckOut)
+	if err != nil {
+		return "", fmt.Errorf("error getting reservations from repository: %w", err)
+	}
+	held, err := service.heldRooms(ctx, hotel.ID, entry.UserID, "", time.Now())
+	if err != nil {
+		return "", err
+	}
+	record := waitlistEntryToDAO(entry)
+	if fits(roomType, append(held, reservations...), record) {
+		return "", hotelsDomain.UnprocessableError{Field: "check_in", Message: "there are rooms available for these dates"}
+	}
+
+	record.HotelID = hotel.ID
+	record.Status = hotelsDAO.WaitlistWaiting
+	record.CreatedAt = time.Now().UTC()
+	record.OfferedAt = nil
+	record.OfferExpiresAt = nil
+	id, err := service.mainRepository.CreateWaitlistEntry(ctx, record)
+	if err != nil {
+		return "", fmt.Errorf("error creating waitlist entry in main repository: %w", err)
+	}
+	return id, nil
+}
+
+// Funcion que le ofrece el lugar libre de un hotel a la lista de espera, por orden de llegada
+// A cada usuario que entra se le guardan las habitaciones durante waitlistOfferDuration y se le avisa con el notificador
+// Se bloquea el hotel igual que al reservar, para no ofrecer habitaciones que otra peticion esta reservando
+// Los avisos se mandan despues de liberar el hotel, asi el notificador no lo deja bloqueado
+func (service Service) ProcessWaitlist(ctx context.Context, hotelID string) error {
+	var offers []hotelsDAO.WaitlistEntry
+	err := service.withHotelLock(ctx, hotelID, func(lock hotelLock) error {
+		var err error
+		offers, err = service.processWaitlist(ctx, lock, hotelID)
+		return err
+	})
+	// Las ofertas que se hicieron antes de un error igual se avisan, y ocupan habitaciones en todas las replicas
+	if len(offers) > 0 {
+		service.publishReservationsChanged(hotelID, "", "")
+	}
+	for _, entry := range offers {
+		if err := service.notifier.NotifyWaitlistOffer(ctx, waitlistEntryToDomain(entry)); err != nil {
+			log.Printf("error notifying waitlist offer %s: %v", entry.ID, err)
+		}
+	}
+	return err
+}
+
+// Funcion que ofrece el lugar libre con el hotel ya bloqueado, devuelve las entradas a las que se les ofrecio
+func (service Service) processWaitlist(ctx context.Context, lock hotelLock, hotelID string) ([]hotelsDAO.WaitlistEntry, error) {
+	waiting, err := service.mainRepository.GetWaitlist(ctx, hotelID, hotelsDAO.WaitlistWaiting)
+	if err != nil {
+		return nil, fmt.Errorf("error getting waitlist from main repository: %w", err)
+	}
+	if len(waiting) == 0 {
+		return nil, nil
+	}
+
+	hotel, err := service.GetHotelByID(ctx, hotelID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting hotel: %w", err)
+	}
+	// Alcanzan las reservas de las noches que pide alguna de las entradas
+	from, to := waitlistStays(waiting)
+	reservations, err := service.mainRepository.GetReservationsInRange(ctx, hotelID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("error getting reservations from repository: %w", err)
+	}
+	now := time.Now().UTC()
+	held, err := service.heldRooms(ctx, hotelID, "", "", now)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := now.Add(waitlistOfferDuration)
+	offers := make([]hotelsDAO.WaitlistEntry, 0)
+	for _, entry := range nextWaitlistOffers(hotel, append(held, reservations...), waiting) {
+		// Lo verificado solo vale si el hotel sigue bloqueado por esta peticion
+		if err := lock.confirm(ctx); err != nil {
+			return offers, err
+		}
+		// Si otra instancia ya le ofrecio el lugar no se le vuelve a avisar
+		offered, err := service.mainRepository.OfferWaitlistEntry(ctx, entry.ID, now, expiresAt)
+		if err != nil {
+			return offers, fmt.Errorf("error offering waitlist entry %s: %w", entry.ID, err)
+		}
+		if !offered {
+			continue
+		}
+		// La oferta ocupa habitaciones en la disponibilidad y en los calendarios del hotel
+		if err := service.cacheRepository.InvalidateHolds(ctx, hotelID); err != nil {
+			service.cacheFailed("error invalidating holds in cache", err)
+		}
+		if err := service.cacheRepository.InvalidateCalendars(ctx, hotelID); err != nil {
+			service.cacheFailed("error invalidating calendars in cache", err)
+		}
+		entry.Status = hotelsDAO.WaitlistOffered
+		entry.OfferedAt = &now
+		entry.OfferExpiresAt = &expiresAt
+		offers = append(offers, entry)
+	}
+	return offers, nil
+}
+
+// Funcion que vence las ofertas que no se usaron y le ofrece ese lugar al siguiente de cada lista de espera
+func (service Service) ExpireWaitlistOffers(ctx context.Context) error {
+	hotelIDs, err := service.mainRepository.ExpireWaitlistOffers(ctx, time.Now().UTC())
+	if err != nil {
+		return fmt.Errorf("error expiring waitlist offers: %w", err)
+	}
+	for _, hotelID := range hotelIDs {
+		if err := service.ProcessWaitlist(ctx, hotelID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Funcion que devuelve, como retenciones, las ofertas vigentes de la lista de espera de un hotel
+func (service Service) activeWaitlistOffers(ctx context.Context, hotelID string, now time.Time) ([]hotelsDAO.Hold, error) {
+	offered, err := service.mainRepository.GetWaitlist(ctx, hotelID, hotelsDAO.WaitlistOffered)
+	if err != nil {
+		return nil, fmt.Errorf("error getting waitlist from main repository: %w", err)
+	}
+	offers := make([]hotelsDAO.Hold, 0, len(offered))
+	for _, entry := range offered {
+		if hold := entry.AsHold(); hold.ExpiresAt.After(now) {
+			offers = append(offers, hold)
+		}
+	}
+	return offers, nil
+}
+
+// Funcion que elige, por orden de llegada, las entradas que entran en el lugar libre del hotel
+// Una entrada que no entra no frena a las siguientes, que pueden pedir otras fechas u otro tipo de habitacion
+func nextWaitlistOffers(hotel hotelsDomain.Hotel, reservations []hotelsDAO.Reservation, waiting []hotelsDAO.WaitlistEntry) []hotelsDAO.WaitlistEntry {
+	roomTypes := roomTypesOf(hotel)
+	occupied := append([]hotelsDAO.Reservation(nil), reservations...)
+	offers := make([]hotelsDAO.WaitlistEntry, 0)
+	for _, entry := range waiting {
+		roomType, ok := findRoomType(roomTypes, entry.RoomType)
+		if !ok || !fits(roomType, occupied, entry) {
+			continue
+		}
+		offers = append(offers, entry)
+		occupied = append(occupied, waitlistEntryAsReservation(entry))
+	}
+	return offers
+}
+
+// Funcion que devuelve el rango de noches que cubre las estadias de todas las entradas
+func waitlistStays(entries []hotelsDAO.WaitlistEntry) (time.Time, time.Time) {
+	var from, to time.Time
+	for i, entry := range entries {
+		if i == 0 || entry.CheckIn.Before(from) {
+			from = entry.CheckIn
+		}
+		if i == 0 || entry.CheckOut.After(to) {
+			to = entry.CheckOut
+		}
+	}
+	return from, to
+}
+
+// Funcion que indica si las habitaciones de una entrada estan libres todas las noches que pide
+func fits(roomType hotelsDomain.RoomType, reservations []hotelsDAO.Reservation, entry hotelsDAO.WaitlistEntry) bool {
+	reserved := availability.ReservedRoomsByType(reservations, entry.CheckIn, entry.CheckOut)
+	return reserved[roomType.Code]+entry.RoomCount <= roomType.Rooms
+}
+
+func findRoomType(roomTypes []hotelsDomain.RoomType, code string) (hotelsDomain.RoomType, bool) {
+	for _, roomType := range roomTypes {
+		if roomType.Code == code {
+			return roomType, true
+		}
+	}
+	return hotelsDomain.RoomType{}, false
+}
+
+// Una oferta ocupa sus habitaciones igual que una reserva
+func waitlistEntryAsReservation(entry hotelsDAO.WaitlistEntry) hotelsDAO.Reservation {
+	return entry.AsHold().AsReservation()
+}
+
+// Funciones para pasar las entradas de la lista de espera entre el formato de dominio y el de base de datos
+func waitlistEntryToDAO(entry hotelsDomain.WaitlistEntry) hotelsDAO.WaitlistEntry {
+	return hotelsDAO.WaitlistEntry(entry)
+}
+
+func waitlistEntryToDomain(record hotelsDAO.WaitlistEntry) hotelsDomain.WaitlistEntry {
+	return hotelsDomain.WaitlistEntry(record)
+}
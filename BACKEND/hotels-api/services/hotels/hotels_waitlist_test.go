@@ -0,0 +1,49 @@
+package hotels
+
+import (
+	"testing"
+
+	hotelsDAO "hotels-api/dao/hotels"
+	hotelsDomain "hotels-api/domain/hotels"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextWaitlistOffers(t *testing.T) {
+	hotel := hotelsDomain.Hotel{RoomTypes: []hotelsDomain.RoomType{
+		{Code: "double", Rooms: 2},
+		{Code: "suite", Rooms: 1},
+	}}
+	reservations := []hotelsDAO.Reservation{
+		{CheckIn: date(2025, 5, 1), CheckOut: date(2025, 5, 4), Rooms: []hotelsDAO.ReservationRoom{{RoomType: "double"}}},
+	}
+	waiting := []hotelsDAO.WaitlistEntry{
+		{ID: "first", CheckIn: date(2025, 5, 2), CheckOut: date(2025, 5, 3), RoomType: "double", RoomCount: 1},
+		// Ya no entra: la habitacion doble libre se le ofrece al primero
+		{ID: "second", CheckIn: date(2025, 5, 1), CheckOut: date(2025, 5, 3), RoomType: "double", RoomCount: 1},
+		// No frena a los siguientes que piden otro tipo u otras fechas
+		{ID: "third", CheckIn: date(2025, 5, 1), CheckOut: date(2025, 5, 3), RoomType: "suite", RoomCount: 1},
+		{ID: "fourth", CheckIn: date(2025, 5, 4), CheckOut: date(2025, 5, 6), RoomType: "double", RoomCount: 2},
+		{ID: "unknown", CheckIn: date(2025, 5, 1), CheckOut: date(2025, 5, 3), RoomType: "penthouse", RoomCount: 1},
+	}
+
+	offers := nextWaitlistOffers(hotel, reservations, waiting)
+	ids := make([]string, 0, len(offers))
+	for _, offer := range offers {
+		ids = append(ids, offer.ID)
+	}
+	assert.Equal(t, []string{"first", "third", "fourth"}, ids)
+}
+
+func TestWaitlistStays(t *testing.T) {
+	waiting := []hotelsDAO.WaitlistEntry{
+		{CheckIn: date(2025, 5, 3), CheckOut: date(2025, 5, 5)},
+		{CheckIn: date(2025, 5, 1), CheckOut: date(2025, 5, 2)},
+		{CheckIn: date(2025, 5, 4), CheckOut: date(2025, 5, 9)},
+	}
+
+	// Las reservas se buscan desde el primer check-in hasta el ultimo check-out de la lista
+	from, to := waitlistStays(waiting)
+	assert.Equal(t, date(2025, 5, 1), from)
+	assert.Equal(t, date(2025, 5, 9), to)
+}
@@ -1,9 +1,19 @@
 package utils
 
 import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 )
 
+// Clave del contexto de gin donde AuthMiddleware deja el ID del usuario autenticado
+const UserIDKey = "user_id"
+
 func CorsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*") // Más permisivo para desarrollo
@@ -20,3 +30,52 @@ func CorsMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// AuthMiddleware valida el token que genera users-api (header "Authorization: Bearer <token>") con la misma clave,
+// y deja el ID del usuario en el contexto con la clave UserIDKey. Sin un token valido responde 401
+func AuthMiddleware(key string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, err := userIDFromToken(c.GetHeader("Authorization"), key, time.Now().UTC())
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": fmt.Sprintf("unauthorized: %s", err.Error()),
+			})
+			return
+		}
+		c.Set(UserIDKey, userID)
+		c.Next()
+	}
+}
+
+// Funcion que valida el header Authorization y devuelve el ID del usuario del token
+// users-api no usa el claim "exp" estandar sino "expiration_date", asi que el vencimiento se verifica aca
+func userIDFromToken(header string, key string, now time.Time) (string, error) {
+	value, found := strings.CutPrefix(header, "Bearer ")
+	if !found || strings.TrimSpace(value) == "" {
+		return "", fmt.Errorf("missing bearer token")
+	}
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(strings.TrimSpace(value), claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(key), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return "", fmt.Errorf("invalid token: %w", err)
+	}
+
+	if expiration, ok := claims["expiration_date"].(string); ok {
+		expiresAt, err := time.Parse(time.RFC3339, expiration)
+		if err != nil {
+			return "", fmt.Errorf("invalid expiration_date: %w", err)
+		}
+		if !now.Before(expiresAt) {
+			return "", fmt.Errorf("token expired")
+		}
+	}
+
+	// Los numeros de JSON llegan como float64
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		return "", fmt.Errorf("token without user_id")
+	}
+	return strconv.FormatInt(int64(userID), 10), nil
+}
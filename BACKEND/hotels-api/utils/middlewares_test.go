@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+// Arma un token igual a los que genera users-api
+func signToken(t *testing.T, key string, claims jwt.MapClaims) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(key))
+	assert.NoError(t, err)
+	return "Bearer " + token
+}
+
+func TestUserIDFromToken(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	valid := signToken(t, "key", jwt.MapClaims{"username": "ana", "user_id": 42, "expiration_date": now.Add(time.Hour)})
+
+	userID, err := userIDFromToken(valid, "key", now)
+	assert.NoError(t, err)
+	assert.Equal(t, "42", userID)
+
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{"Missing header", ""},
+		{"Not a bearer token", "Basic YW5hOnNlY3JldA=="},
+		{"Another key", signToken(t, "other", jwt.MapClaims{"user_id": 42})},
+		{"Expired", signToken(t, "key", jwt.MapClaims{"user_id": 42, "expiration_date": now.Add(-time.Minute)})},
+		{"Without user", signToken(t, "key", jwt.MapClaims{"username": "ana"})},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := userIDFromToken(test.header, "key", now)
+			assert.Error(t, err)
+		})
+	}
+}
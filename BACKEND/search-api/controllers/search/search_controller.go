@@ -11,7 +11,8 @@ import (
 )
 
 type Service interface {
-	Search(ctx context.Context, query string, offset int, limit int) ([]hotelsDomain.Hotel, error)
+	Search(ctx context.Context, request hotelsDomain.SearchRequest) (hotelsDomain.SearchResponse, error)
+	Suggest(ctx context.Context, prefix string, limit int) ([]hotelsDomain.Suggestion, error)
 }
 
 type Controller struct {
@@ -24,23 +25,38 @@ func NewController(service Service) Controller {
 	}
 }
 
-
 // Funcion para buscar hoteles en Solr
 func (controller Controller) Search(c *gin.Context) {
 	// Saca el query de la URL
 	query := c.Query("q")
 
-	// Saca el offset de la URL
-	offset, err := strconv.Atoi(c.Query("offset"))
+	// Saca el offset y el limit de la URL, si no vienen el servicio usa los valores por defecto
+	offset, err := parseOptionalInt(c, "offset")
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": fmt.Sprintf("invalid request: %s", err),
 		})
 		return
 	}
+	limit, err := parseOptionalInt(c, "limit")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", err),
+		})
+		return
+	}
+
+	// Saca el cursor de la URL, para paginar en profundidad ("*" en la primera pagina)
+	cursor := c.Query("cursor")
+	if cursor != "" && offset > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request: offset can't be used with cursor",
+		})
+		return
+	}
 
-	// Saca el limit de la URL
-	limit, err := strconv.Atoi(c.Query("limit"))
+	// Saca el filtro geografico de la URL (lat, lon y radius_km)
+	near, err := parseGeoFilter(c)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error": fmt.Sprintf("invalid request: %s", err),
@@ -49,7 +65,13 @@ func (controller Controller) Search(c *gin.Context) {
 	}
 
 	// Llama a la funcion de busqueda de hoteles del servicio
-	hotels, err := controller.service.Search(c.Request.Context(), query, offset, limit)
+	response, err := controller.service.Search(c.Request.Context(), hotelsDomain.SearchRequest{
+		Query:  query,
+		Offset: offset,
+		Limit:  limit,
+		Near:   near,
+		Cursor: cursor,
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": fmt.Sprintf("error searching hotels: %s", err.Error()),
@@ -58,5 +80,86 @@ func (controller Controller) Search(c *gin.Context) {
 	}
 
 	// Devuelve los hoteles encontrados
-	c.JSON(http.StatusOK, hotels)
+	c.JSON(http.StatusOK, response)
+}
+
+// Funcion para autocompletar la busqueda con nombres de hotel, ciudades y paises
+func (controller Controller) Suggest(c *gin.Context) {
+	// Saca el prefijo de la URL
+	prefix := c.Query("q")
+
+	// Saca el limit de la URL, si no viene el servicio usa el valor por defecto
+	limit, err := parseOptionalInt(c, "limit")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("invalid request: %s", err),
+		})
+		return
+	}
+
+	// Llama a la funcion de sugerencias del servicio
+	suggestions, err := controller.service.Suggest(c.Request.Context(), prefix, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("error getting suggestions: %s", err.Error()),
+		})
+		return
+	}
+
+	// Devuelve las sugerencias encontradas
+	c.JSON(http.StatusOK, suggestions)
+}
+
+// Funcion que lee un parametro entero opcional de la URL. Devuelve 0 si no viene y error si es negativo
+func parseOptionalInt(c *gin.Context, name string) (int, error) {
+	value := c.Query(name)
+	if value == "" {
+		return 0, nil
+	}
+	number, err := strconv.Atoi(value)
+	if err != nil || number < 0 {
+		return 0, fmt.Errorf("%s must be a non negative number", name)
+	}
+	return number, nil
+}
+
+const (
+	defaultRadiusKm = 10.0
+)
+
+// Funcion que arma el filtro geografico a partir de lat, lon y radius_km
+// Si no vienen lat y lon la busqueda no es geografica, y si no viene el radio se usa defaultRadiusKm
+func parseGeoFilter(c *gin.Context) (*hotelsDomain.GeoFilter, error) {
+	lat, lon, radius := c.Query("lat"), c.Query("lon"), c.Query("radius_km")
+	if lat == "" && lon == "" {
+		if radius != "" {
+			return nil, fmt.Errorf("radius_km requires lat and lon")
+		}
+		return nil, nil
+	}
+	if lat == "" || lon == "" {
+		return nil, fmt.Errorf("lat and lon must be sent together")
+	}
+
+	latitude, err := strconv.ParseFloat(lat, 64)
+	if err != nil || latitude < -90 || latitude > 90 {
+		return nil, fmt.Errorf("lat must be a number between -90 and 90")
+	}
+	longitude, err := strconv.ParseFloat(lon, 64)
+	if err != nil || longitude < -180 || longitude > 180 {
+		return nil, fmt.Errorf("lon must be a number between -180 and 180")
+	}
+	radiusKm := defaultRadiusKm
+	if radius != "" {
+		radiusKm, err = strconv.ParseFloat(radius, 64)
+		if err != nil || radiusKm <= 0 {
+			return nil, fmt.Errorf("radius_km must be a positive number")
+		}
+	}
+
+	return &hotelsDomain.GeoFilter{
+		Latitude:  latitude,
+		Longitude: longitude,
+		RadiusKm:  radiusKm,
+	}, nil
 }
@@ -19,4 +19,48 @@ type Hotel struct {
 	CheckOutTime time.Time `bson:"check_out_time"`
 	Amenities []string `bson:"amenities"`
 	Images    []string `bson:"images"`
-}
\ No newline at end of file
+	Version   int64    `bson:"version"`
+	UpdatedAt time.Time `bson:"updated_at"`
+	Latitude      float64             `bson:"latitude"`
+	Longitude     float64             `bson:"longitude"`
+	DistanceKm    *float64            `bson:"-"` // Distancia al punto de busqueda, solo en las busquedas por cercania
+	Highlights    map[string][]string `bson:"-"` // Fragmentos resaltados por campo (name, description), solo en las busquedas de texto
+}
+
+// Query de busqueda sobre el indice de hoteles
+type SearchQuery struct {
+	Text   string
+	Offset int
+	Limit  int
+	Near   *GeoFilter
+	Cursor string // cursorMark de Solr para paginar en profundidad ("*" para la primera pagina), reemplaza a Offset
+}
+
+// Resultado de una busqueda: una pagina de hoteles y el total de hoteles que matchean
+type SearchResult struct {
+	Hotels     []Hotel
+	Total      int
+	NextCursor string // Solo en las busquedas con Cursor, igual a Cursor cuando no hay mas resultados
+}
+
+// Filtro geografico: hoteles a menos de RadiusKm del punto
+type GeoFilter struct {
+	Latitude  float64
+	Longitude float64
+	RadiusKm  float64
+}
+
+// Operation es una escritura pendiente sobre el indice de hoteles
+type Operation struct {
+	ID      string
+	Delete  bool  // true para bajas, false para altas y actualizaciones
+	Hotel   Hotel // Solo se usa en altas y actualizaciones
+	Version int64 // Version del hotel que genero la operacion
+}
+
+// Sugerencia de autocompletado que devuelve el suggester de Solr
+type Suggestion struct {
+	Text    string
+	Type    string // name, city o country segun el diccionario del que salio
+	HotelID string // Solo en las sugerencias de nombres de hotel
+}
@@ -19,9 +19,48 @@ type Hotel struct {
 	CheckOutTime time.Time `json:"check_out_time"`
 	Amenities []string `json:"amenities"`
 	Images []string `json:"images"`
+	Version int64 `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Latitude      float64             `json:"latitude"`
+	Longitude     float64             `json:"longitude"`
+	DistanceKm    *float64            `json:"distance_km,omitempty"` // Solo en las busquedas por cercania
+	Highlights    map[string][]string `json:"highlights,omitempty"`  // Fragmentos con los terminos que matchearon
 }
 
 type HotelNew struct {
 	Operation string `json:"operation"`
 	HotelID   string `json:"hotel_id"`
+	Version   int64  `json:"version"`
+}
+
+// Parametros de una busqueda de hoteles
+type SearchRequest struct {
+	Query  string
+	Offset int
+	Limit  int
+	Near   *GeoFilter // Si no es nil, solo se devuelven hoteles dentro del radio, ordenados por distancia
+	Cursor string     // Cursor de la pagina anterior ("*" para empezar), para paginar sin offset
+}
+
+// Respuesta de /search con una pagina de resultados y los datos para pedir la siguiente
+type SearchResponse struct {
+	Results    []Hotel `json:"results"`
+	Total      int     `json:"total"`
+	Offset     int     `json:"offset"`
+	Limit      int     `json:"limit"`
+	NextOffset *int    `json:"next_offset,omitempty"` // nil en la ultima pagina
+	PrevOffset *int    `json:"prev_offset,omitempty"` // nil en la primera pagina
+	NextCursor string  `json:"next_cursor,omitempty"` // Solo si se pagina con cursor y quedan resultados
+}
+
+type GeoFilter struct {
+	Latitude  float64
+	Longitude float64
+	RadiusKm  float64
+}
+
+type Suggestion struct {
+	Text    string `json:"text"`
+	Type    string `json:"type"` // name, city o country
+	HotelID string `json:"hotel_id,omitempty"`
 }
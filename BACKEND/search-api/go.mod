@@ -4,6 +4,7 @@ go 1.22.3
 
 require (
 	github.com/gin-gonic/gin v1.10.0
+	github.com/karlseguin/ccache v2.0.3+incompatible
 	github.com/stevenferrer/solr-go v0.3.4
 	github.com/streadway/amqp v1.1.0
 	github.com/stretchr/testify v1.9.0
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"search-api/clients/queues"
 	controllers "search-api/controllers/search"
@@ -8,6 +9,7 @@ import (
 	services "search-api/services/search"
 
 	"search-api/utils"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,9 +17,17 @@ import (
 func main() {
 	// Solr
 	solrRepo := repositories.NewSolr(repositories.SolrConfig{
-		Host:       "solr",   // Solr host
-		Port:       "8983",   // Solr port
-		Collection: "hotels", // Collection name
+		Host:         "solr",      // Solr host
+		Port:         "8983",      // Solr port
+		Collection:   "hotels",    // Collection name
+		CommitWithin: time.Second, // Soft commit en vez de un commit por documento
+	})
+
+	// Cache de sugerencias de autocompletado
+	cacheRepo := repositories.NewCache(repositories.CacheConfig{
+		MaxSize:      10000,
+		ItemsToPrune: 100,
+		Duration:     30 * time.Second,
 	})
 
 	// Rabbit
@@ -36,8 +46,19 @@ func main() {
 		Port: "8081",
 	})
 
+	// Indexer
+	// Agrupa los eventos de la cola en escrituras por lote a Solr
+	indexer := services.NewIndexer(solrRepo, services.IndexerConfig{
+		BatchSize:     100,
+		FlushInterval: time.Second,
+	})
+	indexer.Start(context.Background())
+
 	// Services
-	service := services.NewService(solrRepo, hotelsAPI)
+	service := services.NewService(solrRepo, cacheRepo, hotelsAPI, indexer)
+
+	// Reconstruye los diccionarios de autocompletado, que Solr no reconstruye en cada commit
+	go service.RunSuggestionsBuilder(context.Background(), time.Minute)
 
 	// Controllers
 	controller := controllers.NewController(service)
@@ -54,6 +75,7 @@ func main() {
 	router.Use(utils.CorsMiddleware())
 
 	router.GET("/search", controller.Search)
+	router.GET("/search/suggest", controller.Suggest)
 	if err := router.Run(":8082"); err != nil {
 		log.Fatalf("Error running application: %v", err)
 	}
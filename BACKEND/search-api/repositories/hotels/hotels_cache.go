@@ -0,0 +1,62 @@
+package hotels
+
+import (
+	"context"
+	"fmt"
+	"search-api/dao/hotels"
+	"time"
+
+	"github.com/karlseguin/ccache"
+)
+
+const (
+	suggestKeyFormat = "suggest:%s"
+)
+
+type CacheConfig struct {
+	MaxSize      int64
+	ItemsToPrune uint32
+	Duration     time.Duration
+}
+
+// Cache en memoria para las sugerencias de autocompletado. Las sugerencias se guardan poco tiempo,
+// asi que un hotel nuevo puede tardar hasta Duration en aparecer para un prefijo ya consultado
+type Cache struct {
+	client   *ccache.Cache
+	duration time.Duration
+}
+
+// Crea una nueva instancia de Cache
+func NewCache(config CacheConfig) Cache {
+	client := ccache.New(ccache.Configure().
+		MaxSize(config.MaxSize).
+		ItemsToPrune(config.ItemsToPrune))
+	return Cache{
+		client:   client,
+		duration: config.Duration,
+	}
+}
+
+// Obtiene las sugerencias de un prefijo de la cache
+func (repository Cache) GetSuggestions(ctx context.Context, prefix string) ([]hotels.Suggestion, error) {
+	key := fmt.Sprintf(suggestKeyFormat, prefix)
+	item := repository.client.Get(key)
+	if item == nil {
+		return nil, fmt.Errorf("not found item with key %s", key)
+	}
+	if item.Expired() {
+		return nil, fmt.Errorf("item with key %s is expired", key)
+	}
+	suggestions, ok := item.Value().([]hotels.Suggestion)
+	if !ok {
+		return nil, fmt.Errorf("error converting item with key %s", key)
+	}
+	return suggestions, nil
+}
+
+// Guarda las sugerencias de un prefijo en la cache
+func (repository Cache) SetSuggestions(ctx context.Context, prefix string, suggestions []hotels.Suggestion) error {
+	key := fmt.Sprintf(suggestKeyFormat, prefix)
+	repository.client.Set(key, suggestions, repository.duration)
+	return nil
+}
@@ -5,23 +5,36 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/url"
 	"search-api/dao/hotels"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/stevenferrer/solr-go"
 )
 
 type SolrConfig struct {
-	Host       string // Solr host
-	Port       string // Solr port
-	Collection string // Solr collection name
+	Host         string        // Solr host
+	Port         string        // Solr port
+	Collection   string        // Solr collection name
+	CommitWithin time.Duration // Tiempo maximo hasta que una escritura es visible en las busquedas
 }
 
 type Solr struct {
-	Client     *solr.JSONClient
-	Collection string
+	Client       *solr.JSONClient
+	Collection   string
+	baseURL      string
+	sender       solr.RequestSender // Para los endpoints que el cliente JSON no expone (ej: /get)
+	commitWithin time.Duration
 }
 
+const (
+	defaultCommitWithin = time.Second
+	solrDateFormat      = "2006-01-02T15:04:05.999Z" // Formato de los campos pdate, siempre en UTC
+)
+
 // Funcion para crear una nueva conexion a Solr
 func NewSolr(config SolrConfig) Solr {
 	// Construimos la URL base para la conexion a Solr
@@ -29,67 +42,98 @@ func NewSolr(config SolrConfig) Solr {
 	// Creamos un nuevo cliente JSON para Solr
 	client := solr.NewJSONClient(baseURL)
 
+	// Si no se configura, las escrituras se hacen visibles en un segundo
+	commitWithin := config.CommitWithin
+	if commitWithin <= 0 {
+		commitWithin = defaultCommitWithin
+	}
+
 	// Devuelve una nueva instancia de Solr
 	return Solr{
-		Client:     client,
-		Collection: config.Collection,
+		Client:       client,
+		Collection:   config.Collection,
+		baseURL:      baseURL,
+		sender:       solr.NewDefaultRequestSender(),
+		commitWithin: commitWithin,
 	}
 }
 
-// Index crea un nuevo documento de hotel en la coleccion de Solr
-func (searchEngine Solr) Index(ctx context.Context, hotel hotels.Hotel) (string, error) {
-	// Prepara el documento para Solr
-	doc := map[string]interface{}{
-		"id":        hotel.ID,
-		"name":      hotel.Name,
-		"description": hotel.Description,
-		"address":   hotel.Address,
-		"city":      hotel.City,
-		"state":     hotel.State,
-		"country":   hotel.Country,
-		"phone":     hotel.Phone,
-		"email":     hotel.Email,
-		"price_per_night": hotel.PricePerNight,
-		"avaiable_rooms": hotel.AvaiableRooms,
-		"check_in_time": hotel.CheckInTime,
-		"check_out_time": hotel.CheckOutTime,
-		"rating":    hotel.Rating,
-		"amenities": hotel.Amenities,
-		"images":    hotel.Images,
+// Bulk aplica un lote de operaciones en un solo request a Solr. En vez de hacer un commit por documento,
+// le pide a Solr que las haga visibles dentro de commitWithin (soft commit).
+// Devuelve el resultado de cada documento: si el lote falla, reintenta las operaciones de a una para saber cuales fallaron
+func (searchEngine Solr) Bulk(ctx context.Context, operations []hotels.Operation) map[string]error {
+	results := make(map[string]error, len(operations))
+	if len(operations) == 0 {
+		return results
 	}
 
-	// Prepara el request de indexacion
-	indexRequest := map[string]interface{}{
-		"add": []interface{}{doc}, // Usa "add" con una lista de documentos para indexar varios a la vez 
+	// Caso normal: todo el lote entra en un request
+	err := searchEngine.update(ctx, operations)
+	if err == nil || len(operations) == 1 {
+		for _, operation := range operations {
+			results[operation.ID] = err
+		}
+		return results
 	}
 
-	// Indexa el documento en Solr (Lo pasa a JSON)
-	body, err := json.Marshal(indexRequest)
-	if err != nil {
-		return "", fmt.Errorf("error marshaling hotel document: %w", err)
+	// Si el lote fallo, un documento invalido no tiene que hacer fallar a los demas
+	for _, operation := range operations {
+		results[operation.ID] = searchEngine.update(ctx, []hotels.Operation{operation})
 	}
+	return results
+}
 
-	// Manda el request de indexacion usando el metodo Update
-	resp, err := searchEngine.Client.Update(ctx, searchEngine.Collection, solr.JSON, bytes.NewReader(body))
-	if err != nil {
-		return "", fmt.Errorf("error indexing hotel: %w", err)
+// Funcion que manda un lote de altas y bajas al handler /update de Solr
+func (searchEngine Solr) update(ctx context.Context, operations []hotels.Operation) error {
+	// El formato JSON de Solr admite repetir los comandos "add" y "delete" en un mismo objeto,
+	// como un map de Go no lo permite el body se arma a mano
+	var body bytes.Buffer
+	body.WriteByte('{')
+	for i, operation := range operations {
+		if i > 0 {
+			body.WriteByte(',')
+		}
+		command, payload := "add", map[string]interface{}{"doc": hotelToDocument(operation.Hotel)}
+		if operation.Delete {
+			command, payload = "delete", map[string]interface{}{"id": operation.ID}
+		}
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("error marshaling hotel document (%s): %w", operation.ID, err)
+		}
+		fmt.Fprintf(&body, "%q:%s", command, encoded)
 	}
-	if resp.Error != nil {
-		return "", fmt.Errorf("failed to index hotel: %v", resp.Error)
+	body.WriteByte('}')
+
+	// commitWithin reemplaza al commit explicito despues de cada escritura
+	params := url.Values{}
+	params.Set("commitWithin", strconv.FormatInt(searchEngine.commitWithin.Milliseconds(), 10))
+	params.Set("wt", "json")
+	urlStr := fmt.Sprintf("%s/solr/%s/update?%s", searchEngine.baseURL, searchEngine.Collection, params.Encode())
+
+	resp, err := searchEngine.sender.SendRequest(ctx, http.MethodPost, urlStr, solr.JSON.String(), &body)
+	if err != nil {
+		return fmt.Errorf("error sending update to Solr: %w", err)
 	}
+	defer resp.Body.Close()
 
-	// Commitea los cambios
-	if err := searchEngine.Client.Commit(ctx, searchEngine.Collection); err != nil {
-		return "", fmt.Errorf("error committing changes to Solr: %w", err)
+	var result solr.UpdateResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("error decoding Solr update response (status %d): %w", resp.StatusCode, err)
+	}
+	if result.BaseResponse != nil && result.Error != nil {
+		return fmt.Errorf("failed to update Solr: %v", result.Error)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to update Solr: received status code %d", resp.StatusCode)
 	}
 
-	// Si todo sale bien, devuelve el ID del hotel
-	return hotel.ID, nil
+	return nil
 }
 
-// Funcion para actualizar un documento de hotel en la coleccion de Solr
-func (searchEngine Solr) Update(ctx context.Context, hotel hotels.Hotel) error {
-	// Prepara el documento para Solr
+// Funcion que convierte un hotel en el documento que se guarda en Solr
+// Los nombres y tipos de los campos tienen que coincidir con solr-config/conf/schema.xml
+func hotelToDocument(hotel hotels.Hotel) map[string]interface{} {
 	doc := map[string]interface{}{
 		"id":        hotel.ID,
 		"name":      hotel.Name,
@@ -101,146 +145,345 @@ func (searchEngine Solr) Update(ctx context.Context, hotel hotels.Hotel) error {
 		"phone":     hotel.Phone,
 		"email":     hotel.Email,
 		"price_per_night": hotel.PricePerNight,
-		"rating":    hotel.Rating,
 		"avaiable_rooms": hotel.AvaiableRooms,
-		"check_in_time": hotel.CheckInTime,
-		"check_out_time": hotel.CheckOutTime,
+		"rating":    hotel.Rating,
 		"amenities": hotel.Amenities,
 		"images":    hotel.Images,
+		"version":   hotel.Version,
 	}
 
-	// Prepara el request de actualizacion
-	updateRequest := map[string]interface{}{
-		"add": []interface{}{doc}, // Use "add" with a list of documents
+	// Los hoteles sin coordenadas no se indexan en el campo geografico
+	if hotel.Latitude != 0 || hotel.Longitude != 0 {
+		doc["location"] = fmt.Sprintf("%s,%s", formatCoordinate(hotel.Latitude), formatCoordinate(hotel.Longitude))
 	}
 
-	// Hace el update del documento en Solr (Lo pasa a JSON)
-	body, err := json.Marshal(updateRequest)
-	if err != nil {
-		return fmt.Errorf("error marshaling hotel document: %w", err)
+	// Solr solo acepta fechas en UTC, y las fechas sin valor no se indexan
+	for field, value := range map[string]time.Time{
+		"check_in_time":  hotel.CheckInTime,
+		"check_out_time": hotel.CheckOutTime,
+		"updated_at":     hotel.UpdatedAt,
+	} {
+		if !value.IsZero() {
+			doc[field] = value.UTC().Format(solrDateFormat)
+		}
 	}
 
-	// Ejecuta el request de actualizacion usando el metodo Update
-	resp, err := searchEngine.Client.Update(ctx, searchEngine.Collection, solr.JSON, bytes.NewReader(body))
+	return doc
+}
+
+// Funcion que convierte un documento de Solr en un hotel
+func documentToHotel(doc map[string]interface{}) hotels.Hotel {
+	latitude, longitude := getLocationField(doc, "location")
+	return hotels.Hotel{
+		ID:        getStringField(doc, "id"),
+		Name:      getStringField(doc, "name"),
+		Description: getStringField(doc, "description"),
+		Address:   getStringField(doc, "address"),
+		City:      getStringField(doc, "city"),
+		State:     getStringField(doc, "state"),
+		Country:   getStringField(doc, "country"),
+		Phone:     getStringField(doc, "phone"),
+		Email:     getStringField(doc, "email"),
+		PricePerNight: getFloatField(doc, "price_per_night"),
+		AvaiableRooms: int(getInt64Field(doc, "avaiable_rooms")),
+		CheckInTime: getTimeField(doc, "check_in_time"),
+		CheckOutTime: getTimeField(doc, "check_out_time"),
+		Rating:    getFloatField(doc, "rating"),
+		Amenities: getStringsField(doc, "amenities"),
+		Images:    getStringsField(doc, "images"),
+		Version:   getInt64Field(doc, "version"),
+		UpdatedAt: getTimeField(doc, "updated_at"),
+		Latitude:      latitude,
+		Longitude:     longitude,
+	}
+}
+
+// Funcion auxiliar para escribir coordenadas y distancias sin notacion cientifica
+func formatCoordinate(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// Campos y pesos de la busqueda de texto: nombre pesa mas que descripcion, y el match exacto mas que el
+// que viene por stemming o sinonimos (campos _es y _en)
+const (
+	searchFields    = "name^4 name_es^2 name_en^2 description description_es^0.5 description_en^0.5"
+	phraseFields    = "name^8 description^2"
+	highlightFields = "name,name_es,name_en,description,description_es,description_en"
+
+	fuzzyMinLength = 4 // Terminos mas cortos matchean demasiadas palabras con un error de distancia
+)
+
+// Respuesta de /query con el resaltado de terminos y el cursor siguiente, que solr.QueryResponse no decodifica
+type searchResponse struct {
+	solr.QueryResponse
+	Highlighting   map[string]map[string][]string `json:"highlighting,omitempty"`
+	NextCursorMark string                         `json:"nextCursorMark,omitempty"`
+}
+
+// Funcion para buscar hoteles en Solr
+// La busqueda de texto usa edismax sobre nombre y descripcion, tolera un error de tipeo por palabra y devuelve
+// los fragmentos resaltados de cada hotel. Si la query tiene un filtro geografico, solo devuelve los hoteles
+// dentro del radio ordenados por distancia
+func (searchEngine Solr) Search(ctx context.Context, query hotels.SearchQuery) (hotels.SearchResult, error) {
+	// Construye la query de busqueda
+	text := buildTextQuery(query.Text)
+	solrQuery := solr.NewQuery(text).Limit(query.Limit)
+
+	// Orden de los resultados: por cercania o por relevancia. El id desempata, y es obligatorio para usar cursorMark
+	sort := "id asc"
+	if text != "*:*" {
+		sort = "score desc, " + sort
+	}
+
+	if query.Near != nil {
+		// geofilt filtra por radio y geodist calcula la distancia (en km) al punto de busqueda
+		point := fmt.Sprintf("%s,%s", formatCoordinate(query.Near.Latitude), formatCoordinate(query.Near.Longitude))
+		distance := fmt.Sprintf("geodist(location,%s)", point)
+		solrQuery = solrQuery.
+			Filters(fmt.Sprintf("{!geofilt sfield=location pt=%s d=%s}", point, formatCoordinate(query.Near.RadiusKm))).
+			Fields("*", "distance:"+distance)
+		sort = distance + " asc, id asc"
+	}
+	solrQuery = solrQuery.Sort(sort)
+
+	// Con cursorMark Solr no admite offset: la posicion la da el cursor
+	params := solr.M{}
+	if query.Cursor != "" {
+		params["cursorMark"] = query.Cursor
+	} else {
+		solrQuery = solrQuery.Offset(query.Offset)
+	}
+
+	// El cliente JSON no permite mandar parametros sueltos (parser, resaltado), asi que se agregan al cuerpo a mano
+	body := solrQuery.BuildQuery()
+	if text != "*:*" {
+		params["defType"] = "edismax"
+		params["qf"] = searchFields
+		params["pf"] = phraseFields
+		params["hl"] = true
+		params["hl.method"] = "unified"
+		params["hl.fl"] = highlightFields
+		params["hl.snippets"] = 2
+		params["hl.fragsize"] = 120
+		params["hl.tag.pre"] = "<em>"
+		params["hl.tag.post"] = "</em>"
+		// Escapa el texto indexado, asi los fragmentos se pueden mostrar como HTML y las unicas etiquetas son las <em>
+		params["hl.encoder"] = "html"
+		params["hl.defaultSummary"] = false
+	}
+	if len(params) > 0 {
+		body["params"] = params
+	}
+	buf := &bytes.Buffer{}
+	if err := json.NewEncoder(buf).Encode(body); err != nil {
+		return hotels.SearchResult{}, fmt.Errorf("error encoding search query: %w", err)
+	}
+
+	// Ejecuta la query en Solr
+	urlStr := fmt.Sprintf("%s/solr/%s/query", searchEngine.baseURL, searchEngine.Collection)
+	httpResp, err := searchEngine.sender.SendRequest(ctx, http.MethodPost, urlStr, solr.JSON.String(), buf)
 	if err != nil {
-		return fmt.Errorf("error updating hotel: %w", err)
+		return hotels.SearchResult{}, fmt.Errorf("error executing search query: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	var resp searchResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return hotels.SearchResult{}, fmt.Errorf("error decoding search response: %w", err)
 	}
-	if resp.Error != nil {
-		return fmt.Errorf("failed to update hotel: %v", resp.Error)
+	if resp.BaseResponse != nil && resp.Error != nil {
+		return hotels.SearchResult{}, fmt.Errorf("failed to execute search query: %v", resp.Error)
 	}
 
-	// Hace commit de los cambios 
-	if err := searchEngine.Client.Commit(ctx, searchEngine.Collection); err != nil {
-		return fmt.Errorf("error committing changes to Solr: %w", err)
+	// Itera sobre los documentos de la respuesta y los convierte en hoteles
+	hotelsList := make([]hotels.Hotel, 0, len(resp.Response.Documents))
+	for _, doc := range resp.Response.Documents {
+		// Lo convierte en un objeto de tipo Hotel
+		hotel := documentToHotel(doc)
+		if _, ok := doc["distance"]; ok {
+			distance := getFloatField(doc, "distance")
+			hotel.DistanceKm = &distance
+		}
+		hotel.Highlights = mergeHighlights(resp.Highlighting[hotel.ID])
+		// Agrega el hotel a la lista
+		hotelsList = append(hotelsList, hotel)
 	}
 
-	return nil
+	// Devuelve la pagina de hoteles con el total
+	return hotels.SearchResult{
+		Hotels:     hotelsList,
+		Total:      resp.Response.NumFound,
+		NextCursor: resp.NextCursorMark,
+	}, nil
 }
 
-func (searchEngine Solr) Delete(ctx context.Context, id string) error {
-	// Papara el documento a borrar, con el ID del hotel a borrar
-	docToDelete := map[string]interface{}{
-		"delete": map[string]interface{}{
-			"id": id,
-		},
+// Funcion que arma la query de edismax a partir del texto del usuario
+// Cada palabra se escapa y se agrupa con su version fuzzy (~1), asi "hiltn" encuentra "hilton" pero un match
+// exacto (o por sinonimo y stemming, que no aplican a los terminos fuzzy) suma mas puntaje
+func buildTextQuery(text string) string {
+	terms := strings.Fields(text)
+	if len(terms) == 0 {
+		return "*:*"
 	}
 
-	// Convierte el documento a JSON
-	body, err := json.Marshal(docToDelete)
-	if err != nil {
-		return fmt.Errorf("error marshaling hotel document: %w", err)
+	clauses := make([]string, 0, len(terms))
+	for _, term := range terms {
+		// En minusculas para que AND, OR y NOT no se interpreten como operadores
+		escaped := escapeQueryTerm(strings.ToLower(term))
+		if len([]rune(term)) < fuzzyMinLength {
+			clauses = append(clauses, escaped)
+			continue
+		}
+		clauses = append(clauses, fmt.Sprintf("(%s OR %s~1)", escaped, escaped))
+	}
+	return strings.Join(clauses, " ")
+}
+
+// Funcion que escapa los caracteres especiales de la sintaxis de Solr
+func escapeQueryTerm(term string) string {
+	var builder strings.Builder
+	for _, char := range term {
+		if strings.ContainsRune(`\+-!():^[]"{}~*?|&/`, char) {
+			builder.WriteRune('\\')
+		}
+		builder.WriteRune(char)
+	}
+	return builder.String()
+}
+
+// Funcion que junta los fragmentos resaltados de las copias por idioma en los campos originales
+// (name_es y name_en en name, etc.), quedandose con los del primer campo que tenga resultados
+func mergeHighlights(highlighting map[string][]string) map[string][]string {
+	merged := make(map[string][]string)
+	for _, field := range []string{"name", "description"} {
+		for _, source := range []string{field, field + "_es", field + "_en"} {
+			if snippets := highlighting[source]; len(snippets) > 0 {
+				merged[field] = snippets
+				break
+			}
+		}
+	}
+	if len(merged) == 0 {
+		return nil
 	}
+	return merged
+}
+
+// Diccionarios del suggester configurados en solr-config/conf/solrconfig.xml, en el orden en que se devuelven
+var suggestDictionaries = []struct {
+	name string
+	kind string
+}{
+	{name: "hotelNames", kind: "name"},
+	{name: "hotelCities", kind: "city"},
+	{name: "hotelCountries", kind: "country"},
+}
 
-	// Ejecuta el request de borrado usando el metodo Update
-	//La diferencia entre el metodo Update y Delete es que el metodo Update permite borrar varios documentos a la vez
-	resp, err := searchEngine.Client.Update(ctx, searchEngine.Collection, solr.JSON, bytes.NewReader(body))
+// Funcion para obtener sugerencias de autocompletado a partir de un prefijo
+// Devuelve hasta limit sugerencias por diccionario, sin deduplicar (varias ciudades o paises se repiten entre hoteles)
+func (searchEngine Solr) Suggest(ctx context.Context, prefix string, limit int) ([]hotels.Suggestion, error) {
+	dictionaries := make([]string, 0, len(suggestDictionaries))
+	for _, dictionary := range suggestDictionaries {
+		dictionaries = append(dictionaries, dictionary.name)
+	}
+	params := solr.NewSuggesterParams("suggest").
+		Dictionaries(dictionaries...).
+		Query(prefix).
+		Count(limit)
+
+	resp, err := searchEngine.Client.Suggest(ctx, searchEngine.Collection, params)
 	if err != nil {
-		return fmt.Errorf("error deleting hotel: %w", err)
+		return nil, fmt.Errorf("error executing suggest query: %w", err)
 	}
-	if resp.Error != nil {
-		return fmt.Errorf("failed to index hotel: %v", resp.Error)
+	if resp.BaseResponse != nil && resp.Error != nil {
+		return nil, fmt.Errorf("failed to execute suggest query: %v", resp.Error)
 	}
 
-	// Hace commit de los cambios
-	if err := searchEngine.Client.Commit(ctx, searchEngine.Collection); err != nil {
-		return fmt.Errorf("error committing changes to Solr: %w", err)
+	suggestions := make([]hotels.Suggestion, 0)
+	if resp.Suggest == nil {
+		return suggestions, nil
+	}
+	for _, dictionary := range suggestDictionaries {
+		// Solr agrupa las sugerencias por diccionario y por la query que se mando
+		for _, body := range (*resp.Suggest)[dictionary.name] {
+			for _, suggestion := range body.Suggestions {
+				suggestions = append(suggestions, hotels.Suggestion{
+					Text:    suggestion.Term,
+					Type:    dictionary.kind,
+					HotelID: suggestion.Payload,
+				})
+			}
+		}
 	}
 
+	return suggestions, nil
+}
+
+// Funcion que reconstruye los diccionarios de autocompletado con los documentos commiteados
+func (searchEngine Solr) BuildSuggestions(ctx context.Context) error {
+	params := solr.NewSuggesterParams("suggest").BuildAll()
+	resp, err := searchEngine.Client.Suggest(ctx, searchEngine.Collection, params)
+	if err != nil {
+		return fmt.Errorf("error building suggesters: %w", err)
+	}
+	if resp.BaseResponse != nil && resp.Error != nil {
+		return fmt.Errorf("failed to build suggesters: %v", resp.Error)
+	}
 	return nil
 }
 
+// GetVersions devuelve la version indexada de cada hotel usando el real-time get de Solr,
+// que ve los documentos aunque todavia no se hayan commiteado. Los hoteles que no estan indexados no aparecen en el mapa
+func (searchEngine Solr) GetVersions(ctx context.Context, ids []string) (map[string]int64, error) {
+	versions := make(map[string]int64)
+	if len(ids) == 0 {
+		return versions, nil
+	}
 
-// Funcion para buscar hoteles en Solr
-func (searchEngine Solr) Search(ctx context.Context, query string, limit int, offset int) ([]hotels.Hotel, error) {
-	// Construye la query de busqueda
-	solrQuery := fmt.Sprintf("q=(name:%s OR description:%s)&rows=%d&start=%d", query, query, limit, offset)
+	// Arma la URL del real-time get pidiendo solo los campos necesarios
+	params := url.Values{}
+	params.Set("ids", strings.Join(ids, ","))
+	params.Set("fl", "id,version")
+	params.Set("wt", "json")
+	urlStr := fmt.Sprintf("%s/solr/%s/get?%s", searchEngine.baseURL, searchEngine.Collection, params.Encode())
 
-	// Ejecuta la query en Solr
-	resp, err := searchEngine.Client.Query(ctx, searchEngine.Collection, solr.NewQuery(solrQuery))
+	resp, err := searchEngine.sender.SendRequest(ctx, http.MethodGet, urlStr, solr.JSON.String(), nil)
 	if err != nil {
-		return nil, fmt.Errorf("error executing search query: %w", err)
-	}
-	if resp.Error != nil {
-		return nil, fmt.Errorf("failed to execute search query: %v", resp.Error)
+		return nil, fmt.Errorf("error getting hotel versions: %w", err)
 	}
+	defer resp.Body.Close()
 
-	// Itera sobre los documentos de la respuesta y los convierte en hoteles
-	var hotelsList []hotels.Hotel
-	for _, doc := range resp.Response.Documents {
-		// Crea un slice de strings para los amenities
-		var amenities []string
-		var images []string
-
-		// Extrae los amenities del documento y los agrega al slice
-		if amenitiesData, ok := doc["amenities"].([]interface{}); ok {
-			for _, amenity := range amenitiesData {
-				if amenityStr, ok := amenity.(string); ok {
-					amenities = append(amenities, amenityStr)
-				}
-			}
-		}
-		if imagesData, ok := doc["images"].([]interface{}); ok {
-			for _, image := range imagesData {
-				if imageStr, ok := image.(string); ok {
-					images = append(images, imageStr)
-				}
-			}
-		}
+	// Decodifica la respuesta
+	var result solr.QueryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error decoding hotel versions: %w", err)
+	}
+	if result.BaseResponse != nil && result.Error != nil {
+		return nil, fmt.Errorf("failed to get hotel versions: %v", result.Error)
+	}
 
-		// Lo convierte en un objeto de tipo Hotel y lo agrega a la lista
-		hotel := hotels.Hotel{
-			ID:        getStringField(doc, "id"),
-			Name:      getStringField(doc, "name"),
-			Description: getStringField(doc, "description"),
-			Address:   getStringField(doc, "address"),
-			City:      getStringField(doc, "city"),
-			State:     getStringField(doc, "state"),
-			Country:   getStringField(doc, "country"),
-			Phone:     getStringField(doc, "phone"),
-			Email:     getStringField(doc, "email"),
-			PricePerNight: getFloatField(doc, "price_per_night"),
-			AvaiableRooms: int(getFloatField(doc, "avaiable_rooms")),
-			CheckInTime: getTimeField(doc, "check_in_time"),
-			CheckOutTime: getTimeField(doc, "check_out_time"),
-			Rating:    getFloatField(doc, "rating"),
-			Amenities: amenities,
-			Images: images,
-		}
-		// Agrega el hotel a la lista
-		hotelsList = append(hotelsList, hotel)
+	for _, doc := range result.Response.Documents {
+		versions[getStringField(doc, "id")] = getInt64Field(doc, "version")
 	}
 
-	// Devuelve la lista de hoteles
-	return hotelsList, nil
+	return versions, nil
 }
 
-
 // Funcion auxiliar para obtener campos de tipo time de un documento
+// Solr devuelve las fechas como strings en formato ISO-8601 (UTC)
 func getTimeField(doc map[string]interface{}, field string) time.Time {
 	if val, ok := doc[field].(time.Time); ok {
 		return val
 	}
-	return time.Time{}
+	value := getStringField(doc, field)
+	if value == "" {
+		return time.Time{}
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return parsed.UTC()
 }
 
 
@@ -257,16 +500,80 @@ func getStringField(doc map[string]interface{}, field string) string {
 	return ""
 }
 
+// Funcion auxiliar para obtener campos multivaluados de tipo string de un documento
+func getStringsField(doc map[string]interface{}, field string) []string {
+	var values []string
+	switch val := doc[field].(type) {
+	case string:
+		values = append(values, val)
+	case []interface{}:
+		for _, item := range val {
+			if strVal, ok := item.(string); ok {
+				values = append(values, strVal)
+			}
+		}
+	case []string:
+		values = append(values, val...)
+	}
+	return values
+}
+
+// Funcion auxiliar para obtener campos de tipo location ("lat,lon") de un documento
+func getLocationField(doc map[string]interface{}, field string) (float64, float64) {
+	parts := strings.Split(getStringField(doc, field), ",")
+	if len(parts) != 2 {
+		return 0, 0
+	}
+	latitude, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return 0, 0
+	}
+	longitude, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return 0, 0
+	}
+	return latitude, longitude
+}
+
 // Funcion auxiliar para obtener campos de tipo float de un documento
 func getFloatField(doc map[string]interface{}, field string) float64 {
-	if val, ok := doc[field].(float64); ok {
-		return val
+	value := doc[field]
+	if val, ok := value.([]interface{}); ok && len(val) > 0 {
+		value = val[0]
 	}
-	if val, ok := doc[field].([]interface{}); ok && len(val) > 0 {
-		if floatVal, ok := val[0].(float64); ok {
-			return floatVal
-		}
+	switch val := value.(type) {
+	case float64:
+		return val
+	case int:
+		return float64(val)
+	case int64:
+		return float64(val)
+	case json.Number:
+		floatVal, _ := val.Float64()
+		return floatVal
 	}
 	// Devuelve 0.0 si no se encuentra el campo
 	return 0.0
 }
+
+// Funcion auxiliar para obtener campos enteros (pint, plong) de un documento
+func getInt64Field(doc map[string]interface{}, field string) int64 {
+	value := doc[field]
+	if val, ok := value.([]interface{}); ok && len(val) > 0 {
+		value = val[0]
+	}
+	switch val := value.(type) {
+	case json.Number:
+		intVal, err := val.Int64()
+		if err != nil {
+			floatVal, _ := val.Float64()
+			return int64(floatVal)
+		}
+		return intVal
+	case int:
+		return int64(val)
+	case int64:
+		return val
+	}
+	return int64(getFloatField(doc, field))
+}
@@ -0,0 +1,112 @@
+package hotels
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"search-api/dao/hotels"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocumentRoundTrip(t *testing.T) {
+	hotel := hotels.Hotel{
+		ID:            "6720f5a9c1e4b2a1d3f4e5a6",
+		Name:          "Hotel Playa",
+		Description:   "Frente al mar",
+		Address:       "Av. Costanera 123",
+		City:          "Mar del Plata",
+		State:         "Buenos Aires",
+		Country:       "Argentina",
+		Phone:         "+54 223 555 0000",
+		Email:         "info@playa.com",
+		PricePerNight: 120.5,
+		Rating:        4.5,
+		AvaiableRooms: 12,
+		CheckInTime:   time.Date(2024, 11, 1, 14, 0, 0, 0, time.UTC),
+		CheckOutTime:  time.Date(2024, 11, 2, 10, 30, 0, 0, time.UTC),
+		Amenities:     []string{"wifi", "pool"},
+		Images:        []string{"https://example.com/1.jpg"},
+		Latitude:      -38.0055,
+		Longitude:     -57.5426,
+		Version:       1730000000123456,
+		UpdatedAt:     time.Date(2024, 10, 27, 3, 33, 20, 123000000, time.UTC),
+	}
+
+	// El documento pasa por JSON igual que cuando se manda y se lee de Solr
+	body, err := json.Marshal(hotelToDocument(hotel))
+	assert.NoError(t, err)
+	var doc map[string]interface{}
+	assert.NoError(t, json.Unmarshal(body, &doc))
+
+	assert.Equal(t, "2024-11-01T14:00:00Z", doc["check_in_time"])
+	assert.Equal(t, "-38.0055,-57.5426", doc["location"])
+	assert.Equal(t, hotel, documentToHotel(doc))
+}
+
+func TestDocumentDatesInUTC(t *testing.T) {
+	buenosAires := time.FixedZone("ART", -3*60*60)
+	doc := hotelToDocument(hotels.Hotel{
+		ID:          "1",
+		CheckInTime: time.Date(2024, 11, 1, 11, 0, 0, 0, buenosAires),
+	})
+
+	assert.Equal(t, "2024-11-01T14:00:00Z", doc["check_in_time"])
+	// Las fechas sin valor no se mandan, Solr las rechazaria o las guardaria como el año 1
+	assert.NotContains(t, doc, "check_out_time")
+	assert.NotContains(t, doc, "updated_at")
+	// Sin coordenadas el hotel no entra en las busquedas geograficas
+	assert.NotContains(t, doc, "location")
+}
+
+func TestDocumentToHotelFromSolrResponse(t *testing.T) {
+	// Respuesta con campos multivaluados, como los que genera el modo schemaless
+	response := `{
+		"id": "1",
+		"name": ["Hotel Centro"],
+		"city": ["Córdoba"],
+		"price_per_night": [80.0],
+		"avaiable_rooms": [3],
+		"check_in_time": ["2024-11-01T14:00:00Z"],
+		"amenities": ["wifi", "gym"],
+		"version": 1730000000123456,
+		"_version_": 1815000000000000000
+	}`
+	var doc map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(response), &doc))
+
+	hotel := documentToHotel(doc)
+	assert.Equal(t, "Hotel Centro", hotel.Name)
+	assert.Equal(t, "Córdoba", hotel.City)
+	assert.Equal(t, 80.0, hotel.PricePerNight)
+	assert.Equal(t, 3, hotel.AvaiableRooms)
+	assert.Equal(t, time.Date(2024, 11, 1, 14, 0, 0, 0, time.UTC), hotel.CheckInTime)
+	assert.Equal(t, []string{"wifi", "gym"}, hotel.Amenities)
+	assert.Equal(t, int64(1730000000123456), hotel.Version)
+	assert.True(t, hotel.CheckOutTime.IsZero())
+}
+
+func TestBuildTextQuery(t *testing.T) {
+	assert.Equal(t, "*:*", buildTextQuery("   "))
+	// Las palabras cortas no son fuzzy
+	assert.Equal(t, "(hiltn OR hiltn~1) spa", buildTextQuery("Hiltn spa"))
+	// Los operadores y caracteres especiales se toman como texto
+	assert.Equal(t, `(playa OR playa~1) or (\(mar\) OR \(mar\)~1)`, buildTextQuery("playa OR (mar)"))
+	assert.Equal(t, `(all\-inclusive OR all\-inclusive~1)`, buildTextQuery("all-inclusive"))
+}
+
+func TestMergeHighlights(t *testing.T) {
+	highlights := mergeHighlights(map[string][]string{
+		"name":           {},
+		"name_en":        {"Sunset <em>Beach</em> Hotel"},
+		"description":    {"A pasos de la <em>playa</em>"},
+		"description_es": {"A pasos de la <em>playa</em>"},
+	})
+	assert.Equal(t, map[string][]string{
+		"name":        {"Sunset <em>Beach</em> Hotel"},
+		"description": {"A pasos de la <em>playa</em>"},
+	}, highlights)
+
+	assert.Nil(t, mergeHighlights(nil))
+}
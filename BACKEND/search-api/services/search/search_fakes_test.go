@@ -0,0 +1,95 @@
+package search_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	hotelsDAO "search-api/dao/hotels"
+)
+
+// Repositorio en memoria que registra los lotes que recibe
+type fakeRepository struct {
+	mu       sync.Mutex
+	versions map[string]int64
+	failing  map[string]error
+	batches  [][]hotelsDAO.Operation
+
+	suggestions  []hotelsDAO.Suggestion
+	suggestCalls int
+
+	searchResult hotelsDAO.SearchResult
+	lastQuery    hotelsDAO.SearchQuery
+
+	builds int
+}
+
+func (repository *fakeRepository) Bulk(ctx context.Context, operations []hotelsDAO.Operation) map[string]error {
+	repository.mu.Lock()
+	defer repository.mu.Unlock()
+	repository.batches = append(repository.batches, operations)
+	results := make(map[string]error)
+	for _, operation := range operations {
+		results[operation.ID] = repository.failing[operation.ID]
+	}
+	return results
+}
+
+func (repository *fakeRepository) Search(ctx context.Context, query hotelsDAO.SearchQuery) (hotelsDAO.SearchResult, error) {
+	repository.mu.Lock()
+	defer repository.mu.Unlock()
+	repository.lastQuery = query
+	return repository.searchResult, nil
+}
+
+func (repository *fakeRepository) GetVersions(ctx context.Context, ids []string) (map[string]int64, error) {
+	return repository.versions, nil
+}
+
+func (repository *fakeRepository) Suggest(ctx context.Context, prefix string, limit int) ([]hotelsDAO.Suggestion, error) {
+	repository.mu.Lock()
+	defer repository.mu.Unlock()
+	repository.suggestCalls++
+	return repository.suggestions, nil
+}
+
+func (repository *fakeRepository) BuildSuggestions(ctx context.Context) error {
+	repository.mu.Lock()
+	defer repository.mu.Unlock()
+	repository.builds++
+	return nil
+}
+
+func (repository *fakeRepository) buildCount() int {
+	repository.mu.Lock()
+	defer repository.mu.Unlock()
+	return repository.builds
+}
+
+func (repository *fakeRepository) batchCount() int {
+	repository.mu.Lock()
+	defer repository.mu.Unlock()
+	return len(repository.batches)
+}
+
+func upsert(id string, version int64) hotelsDAO.Operation {
+	return hotelsDAO.Operation{ID: id, Hotel: hotelsDAO.Hotel{ID: id, Version: version}, Version: version}
+}
+
+// Cache en memoria sin vencimiento
+type fakeCache struct {
+	suggestions map[string][]hotelsDAO.Suggestion
+}
+
+func (cache *fakeCache) GetSuggestions(ctx context.Context, prefix string) ([]hotelsDAO.Suggestion, error) {
+	suggestions, ok := cache.suggestions[prefix]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return suggestions, nil
+}
+
+func (cache *fakeCache) SetSuggestions(ctx context.Context, prefix string, suggestions []hotelsDAO.Suggestion) error {
+	cache.suggestions[prefix] = suggestions
+	return nil
+}
@@ -0,0 +1,161 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	hotelsDAO "search-api/dao/hotels"
+	"sync/atomic"
+	"time"
+)
+
+// ErrOutdated indica que la operacion se descarto porque el indice ya tiene una version igual o mas nueva del hotel
+var ErrOutdated = errors.New("outdated operation")
+
+type IndexerConfig struct {
+	BatchSize     int           // Cantidad de operaciones que dispara un flush
+	FlushInterval time.Duration // Tiempo maximo que una operacion espera en el buffer
+}
+
+// Indexer agrupa las operaciones que llegan de la cola y las manda a Solr en lotes,
+// cuando se juntan BatchSize operaciones o cuando pasa FlushInterval, lo que ocurra primero
+type Indexer struct {
+	repository Repository
+	config     IndexerConfig
+	requests   chan indexRequest
+	// Si se escribio algo en Solr desde la ultima vez que se reconstruyeron las sugerencias
+	changed *atomic.Bool
+}
+
+type indexRequest struct {
+	operation hotelsDAO.Operation
+	result    chan error
+}
+
+const (
+	defaultBatchSize     = 100
+	defaultFlushInterval = time.Second
+)
+
+// Funcion para crear un nuevo indexador
+func NewIndexer(repository Repository, config IndexerConfig) *Indexer {
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultBatchSize
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = defaultFlushInterval
+	}
+	return &Indexer{
+		repository: repository,
+		config:     config,
+		requests:   make(chan indexRequest, config.BatchSize),
+		changed:    &atomic.Bool{},
+	}
+}
+
+// Inicia la goroutine que junta las operaciones y hace los flush. Cuando el contexto se cancela
+// se hace un ultimo flush con lo que quedo en el buffer
+func (indexer *Indexer) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(indexer.config.FlushInterval)
+		defer ticker.Stop()
+
+		batch := make([]indexRequest, 0, indexer.config.BatchSize)
+		for {
+			select {
+			case request := <-indexer.requests:
+				batch = append(batch, request)
+				if len(batch) < indexer.config.BatchSize {
+					continue
+				}
+			case <-ticker.C:
+				if len(batch) == 0 {
+					continue
+				}
+			case <-ctx.Done():
+				indexer.flush(context.Background(), batch)
+				return
+			}
+			indexer.flush(ctx, batch)
+			batch = make([]indexRequest, 0, indexer.config.BatchSize)
+		}
+	}()
+}
+
+// Encola una operacion. El canal devuelto recibe el resultado de esa operacion cuando se hace el flush del lote
+func (indexer *Indexer) Submit(operation hotelsDAO.Operation) <-chan error {
+	result := make(chan error, 1)
+	indexer.requests <- indexRequest{operation: operation, result: result}
+	return result
+}
+
+// Funcion que escribe un lote en Solr y le devuelve a cada operacion su resultado
+func (indexer *Indexer) flush(ctx context.Context, batch []indexRequest) {
+	if len(batch) == 0 {
+		return
+	}
+
+	// Si el mismo hotel aparece varias veces en el lote, solo se escribe la operacion mas nueva
+	latest := make(map[string]indexRequest)
+	ids := make([]string, 0)
+	for _, request := range batch {
+		current, ok := latest[request.operation.ID]
+		if !ok {
+			ids = append(ids, request.operation.ID)
+		} else if request.operation.Version < current.operation.Version {
+			request.result <- ErrOutdated
+			continue
+		} else {
+			current.result <- ErrOutdated
+		}
+		latest[request.operation.ID] = request
+	}
+
+	// Una sola consulta trae las versiones indexadas de todo el lote
+	versions, err := indexer.repository.GetVersions(ctx, ids)
+	if err != nil {
+		for _, request := range latest {
+			request.result <- fmt.Errorf("error getting indexed versions: %w", err)
+		}
+		return
+	}
+
+	// Descarta las operaciones que son mas viejas que lo que ya esta indexado
+	operations := make([]hotelsDAO.Operation, 0, len(latest))
+	for _, id := range ids {
+		request := latest[id]
+		if isOutdated(request.operation, versions[id]) {
+			request.result <- ErrOutdated
+			delete(latest, id)
+			continue
+		}
+		operations = append(operations, request.operation)
+	}
+
+	// Escribe el lote y reparte los errores por documento
+	results := indexer.repository.Bulk(ctx, operations)
+	if len(operations) > 0 {
+		indexer.changed.Store(true)
+	}
+	for id, request := range latest {
+		request.result <- results[id]
+	}
+}
+
+// Funcion que devuelve si se escribio algo en Solr desde la ultima llamada
+func (indexer *Indexer) TakeChanges() bool {
+	return indexer.changed.Swap(false)
+}
+
+// Funcion que decide si una operacion es vieja respecto de la version indexada (0 si el hotel no esta indexado)
+// Las operaciones sin version (publicadas antes de versionar los hoteles) nunca se consideran viejas
+func isOutdated(operation hotelsDAO.Operation, indexed int64) bool {
+	if indexed == 0 || operation.Version == 0 {
+		return false
+	}
+	if operation.Delete {
+		// Un borrado con la misma version que lo indexado es valido: el hotel se borro despues de esa version
+		return indexed > operation.Version
+	}
+	return operation.Version <= indexed
+}
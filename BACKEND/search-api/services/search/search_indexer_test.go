@@ -0,0 +1,78 @@
+package search_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	hotelsDAO "search-api/dao/hotels"
+	service "search-api/services/search"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndexer(t *testing.T) {
+	t.Run("Flush on batch size", func(t *testing.T) {
+		repository := &fakeRepository{versions: map[string]int64{}}
+		indexer := service.NewIndexer(repository, service.IndexerConfig{BatchSize: 3, FlushInterval: time.Hour})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		indexer.Start(ctx)
+
+		results := []<-chan error{
+			indexer.Submit(upsert("1", 1)),
+			indexer.Submit(upsert("2", 1)),
+			indexer.Submit(upsert("3", 1)),
+		}
+		for _, result := range results {
+			assert.NoError(t, <-result)
+		}
+		assert.Equal(t, 1, repository.batchCount())
+		assert.Len(t, repository.batches[0], 3)
+	})
+
+	t.Run("Flush on interval", func(t *testing.T) {
+		repository := &fakeRepository{versions: map[string]int64{}}
+		indexer := service.NewIndexer(repository, service.IndexerConfig{BatchSize: 100, FlushInterval: 10 * time.Millisecond})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		indexer.Start(ctx)
+
+		assert.NoError(t, <-indexer.Submit(upsert("1", 1)))
+		assert.Equal(t, 1, repository.batchCount())
+	})
+
+	t.Run("Per document errors and outdated operations", func(t *testing.T) {
+		repository := &fakeRepository{
+			versions: map[string]int64{"old": 5, "deleted": 3},
+			failing:  map[string]error{"broken": errors.New("invalid document")},
+		}
+		indexer := service.NewIndexer(repository, service.IndexerConfig{BatchSize: 6, FlushInterval: time.Hour})
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		indexer.Start(ctx)
+
+		ok := indexer.Submit(upsert("ok", 1))
+		broken := indexer.Submit(upsert("broken", 1))
+		old := indexer.Submit(upsert("old", 4))
+		duplicated := indexer.Submit(upsert("old", 5))
+		fresh := indexer.Submit(upsert("new", 1))
+		deleted := indexer.Submit(hotelsDAO.Operation{ID: "deleted", Delete: true, Version: 3})
+
+		assert.NoError(t, <-ok)
+		assert.EqualError(t, <-broken, "invalid document")
+		assert.ErrorIs(t, <-old, service.ErrOutdated)
+		assert.ErrorIs(t, <-duplicated, service.ErrOutdated)
+		assert.NoError(t, <-fresh)
+		assert.NoError(t, <-deleted)
+
+		// Solo se escriben las operaciones que no estaban desactualizadas
+		assert.Equal(t, 1, repository.batchCount())
+		ids := make([]string, 0)
+		for _, operation := range repository.batches[0] {
+			ids = append(ids, operation.ID)
+		}
+		assert.ElementsMatch(t, []string{"ok", "broken", "new", "deleted"}, ids)
+	})
+}
@@ -2,17 +2,27 @@ package search
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	hotelsDAO "search-api/dao/hotels"
 	hotelsDomain "search-api/domain/hotels"
+	"strings"
+	"time"
 )
 
 //Funciones de solr
 type Repository interface {
-	Index(ctx context.Context, hotel hotelsDAO.Hotel) (string, error)
-	Update(ctx context.Context, hotel hotelsDAO.Hotel) error
-	Delete(ctx context.Context, id string) error
-	Search(ctx context.Context, query string, limit int, offset int) ([]hotelsDAO.Hotel, error) // Updated signature
+	Bulk(ctx context.Context, operations []hotelsDAO.Operation) map[string]error
+	Search(ctx context.Context, query hotelsDAO.SearchQuery) (hotelsDAO.SearchResult, error)
+	GetVersions(ctx context.Context, ids []string) (map[string]int64, error)
+	Suggest(ctx context.Context, prefix string, limit int) ([]hotelsDAO.Suggestion, error)
+	BuildSuggestions(ctx context.Context) error
+}
+
+// Funciones de la cache de sugerencias
+type Cache interface {
+	GetSuggestions(ctx context.Context, prefix string) ([]hotelsDAO.Suggestion, error)
+	SetSuggestions(ctx context.Context, prefix string, suggestions []hotelsDAO.Suggestion) error
 }
 
 // Funcion de la API de hoteles
@@ -22,29 +32,66 @@ type ExternalRepository interface {
 
 type Service struct {
 	repository Repository // Este seria nuestro repositorio de solr
+	cache      Cache              // Cache en memoria de las sugerencias de autocompletado
 	hotelsAPI  ExternalRepository // Este seria nuestro repositorio de la API de hoteles
+	indexer    *Indexer // Agrupa las escrituras a Solr en lotes
 }
 
 // Funcion para crear un nuevo servicio 
-func NewService(repository Repository, hotelsAPI ExternalRepository) Service {
+func NewService(repository Repository, cache Cache, hotelsAPI ExternalRepository, indexer *Indexer) Service {
 	return Service{
 		repository: repository,
+		cache:      cache,
 		hotelsAPI:  hotelsAPI,
+		indexer:    indexer,
 	}
 }
 
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 100
+)
 
 // Funcion para buscar hoteles en Solr
-func (service Service) Search(ctx context.Context, query string, offset int, limit int) ([]hotelsDomain.Hotel, error) {
+// Devuelve una pagina de resultados con el total y los offsets (o el cursor) de las paginas vecinas
+func (service Service) Search(ctx context.Context, request hotelsDomain.SearchRequest) (hotelsDomain.SearchResponse, error) {
+	if request.Offset < 0 {
+		request.Offset = 0
+	}
+	if request.Limit <= 0 {
+		request.Limit = defaultSearchLimit
+	}
+	if request.Limit > maxSearchLimit {
+		request.Limit = maxSearchLimit
+	}
+	// Con cursor la posicion la marca el cursor, no el offset
+	if request.Cursor != "" {
+		request.Offset = 0
+	}
+
+	query := hotelsDAO.SearchQuery{
+		Text:   request.Query,
+		Offset: request.Offset,
+		Limit:  request.Limit,
+		Cursor: request.Cursor,
+	}
+	if request.Near != nil {
+		query.Near = &hotelsDAO.GeoFilter{
+			Latitude:  request.Near.Latitude,
+			Longitude: request.Near.Longitude,
+			RadiusKm:  request.Near.RadiusKm,
+		}
+	}
+
 	// Llama al metodo Search del repositorio
-	hotelsDAOList, err := service.repository.Search(ctx, query, limit, offset)
+	result, err := service.repository.Search(ctx, query)
 	if err != nil {
-		return nil, fmt.Errorf("error searching hotels: %w", err)
+		return hotelsDomain.SearchResponse{}, fmt.Errorf("error searching hotels: %w", err)
 	}
 
 	// Hace un mapeo de los hoteles de la lista de hoteles de Solr a la lista de hoteles de dominio
 	hotelsDomainList := make([]hotelsDomain.Hotel, 0)
-	for _, hotel := range hotelsDAOList {
+	for _, hotel := range result.Hotels {
 		hotelsDomainList = append(hotelsDomainList, hotelsDomain.Hotel{
 			ID:        hotel.ID,
 			Name:      hotel.Name,
@@ -62,21 +109,156 @@ func (service Service) Search(ctx context.Context, query string, offset int, lim
 			CheckOutTime: hotel.CheckOutTime,
 			Amenities: hotel.Amenities,
 			Images:    hotel.Images,
+			Version:   hotel.Version,
+			UpdatedAt: hotel.UpdatedAt,
+			Latitude:      hotel.Latitude,
+			Longitude:     hotel.Longitude,
+			DistanceKm:    hotel.DistanceKm,
+			Highlights:    hotel.Highlights,
+		})
+	}
+
+	response := hotelsDomain.SearchResponse{
+		Results: hotelsDomainList,
+		Total:   result.Total,
+		Offset:  request.Offset,
+		Limit:   request.Limit,
+	}
+	if request.Cursor != "" {
+		// Solr devuelve el mismo cursor cuando ya no quedan resultados
+		if result.NextCursor != "" && result.NextCursor != request.Cursor {
+			response.NextCursor = result.NextCursor
+		}
+		return response, nil
+	}
+	if next := request.Offset + request.Limit; next < result.Total {
+		response.NextOffset = &next
+	}
+	if request.Offset > 0 {
+		prev := request.Offset - request.Limit
+		if prev < 0 {
+			prev = 0
+		}
+		response.PrevOffset = &prev
+	}
+
+	// Devuelve la pagina de hoteles
+	return response, nil
+}
+
+const (
+	defaultSuggestLimit = 5
+	maxSuggestLimit     = 10
+)
+
+// Funcion para obtener sugerencias de autocompletado (nombres de hotel, ciudades y paises) a partir de un prefijo
+// Las sugerencias se deduplican y se intercalan por tipo, para que los nombres de hotel no tapen a las ciudades y paises
+func (service Service) Suggest(ctx context.Context, prefix string, limit int) ([]hotelsDomain.Suggestion, error) {
+	if limit <= 0 {
+		limit = defaultSuggestLimit
+	}
+	if limit > maxSuggestLimit {
+		limit = maxSuggestLimit
+	}
+
+	// El suggester no distingue mayusculas, asi que el prefijo normalizado sirve como llave de la cache
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	if prefix == "" {
+		return []hotelsDomain.Suggestion{}, nil
+	}
+
+	// Busca primero en la cache, que guarda siempre el maximo de sugerencias para servir cualquier limit
+	suggestions, err := service.cache.GetSuggestions(ctx, prefix)
+	if err != nil {
+		suggestions, err = service.repository.Suggest(ctx, prefix, maxSuggestLimit)
+		if err != nil {
+			return nil, fmt.Errorf("error getting suggestions: %w", err)
+		}
+		suggestions = mergeSuggestions(suggestions)
+		if err := service.cache.SetSuggestions(ctx, prefix, suggestions); err != nil {
+			fmt.Printf("Error caching suggestions for prefix (%s): %v\n", prefix, err)
+		}
+	}
+
+	result := make([]hotelsDomain.Suggestion, 0, limit)
+	for _, suggestion := range suggestions {
+		if len(result) == limit {
+			break
+		}
+		result = append(result, hotelsDomain.Suggestion{
+			Text:    suggestion.Text,
+			Type:    suggestion.Type,
+			HotelID: suggestion.HotelID,
 		})
 	}
+	return result, nil
+}
 
-	// Devuelve la lista de hoteles 
-	return hotelsDomainList, nil
+// Funcion que saca las sugerencias repetidas (la misma ciudad aparece una vez por hotel) e intercala los tipos
+// manteniendo el orden de Solr dentro de cada tipo
+func mergeSuggestions(suggestions []hotelsDAO.Suggestion) []hotelsDAO.Suggestion {
+	types := make([]string, 0)
+	byType := make(map[string][]hotelsDAO.Suggestion)
+	seen := make(map[string]bool)
+	for _, suggestion := range suggestions {
+		key := suggestion.Type + ":" + strings.ToLower(suggestion.Text)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if _, ok := byType[suggestion.Type]; !ok {
+			types = append(types, suggestion.Type)
+		}
+		byType[suggestion.Type] = append(byType[suggestion.Type], suggestion)
+	}
+
+	merged := make([]hotelsDAO.Suggestion, 0, len(seen))
+	for i := 0; len(merged) < len(seen); i++ {
+		for _, kind := range types {
+			if i < len(byType[kind]) {
+				merged = append(merged, byType[kind][i])
+			}
+		}
+	}
+	return merged
 }
 
+// Funcion que reconstruye los diccionarios de autocompletado de Solr cada interval, si el indexador escribio algo
+// desde la ultima vez. Corre hasta que se cancela el contexto
+func (service Service) RunSuggestionsBuilder(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			service.buildSuggestions(ctx)
+		}
+	}
+}
+
+func (service Service) buildSuggestions(ctx context.Context) {
+	if !service.indexer.TakeChanges() {
+		return
+	}
+	if err := service.repository.BuildSuggestions(ctx); err != nil {
+		fmt.Printf("Error building suggestions: %v\n", err)
+		// Se vuelve a intentar en la proxima vuelta
+		service.indexer.changed.Store(true)
+	}
+}
 
 // Funcion para manejar la creacion y eliminacion de hoteles
+// Las operaciones se encolan en el indexador, que las escribe en Solr por lotes. Los eventos pueden llegar repetidos
+// o desordenados, el indexador compara la version de cada operacion con la indexada y descarta las mas viejas
 func (service Service) HandleHotelNew(hotelNew hotelsDomain.HotelNew) {
 	// Hacemos un switch para manejar las operaciones de creacion, actualizacion y eliminacion
 	switch hotelNew.Operation {
 	// Caso en el que se crea o actualiza un hotel
 	case "CREATE", "UPDATE":
 		// Obtenemos el hotel de la API de hoteles
+		// Si el hotel fue borrado despues de este evento la API devuelve error y el evento se descarta
 		hotel, err := service.hotelsAPI.GetHotelByID(context.Background(), hotelNew.HotelID)
 		if err != nil {
 			fmt.Printf("Error getting hotel (%s) from API: %v\n", hotelNew.HotelID, err)
@@ -100,35 +282,47 @@ func (service Service) HandleHotelNew(hotelNew hotelsDomain.HotelNew) {
 			CheckOutTime: hotel.CheckOutTime,
 			Amenities: hotel.Amenities,
 			Images:    hotel.Images,
+			Version:   hotel.Version,
+			UpdatedAt: hotel.UpdatedAt,
+			Latitude:      hotel.Latitude,
+			Longitude:     hotel.Longitude,
 		}
 
-		// Caso en el que se crea un hotel
-		if hotelNew.Operation == "CREATE" {
-			// Llama al metodo Index del repositorio para indexar el hotel en Solr
-			if _, err := service.repository.Index(context.Background(), hotelDAO); err != nil {
-				fmt.Printf("Error indexing hotel (%s): %v\n", hotelNew.HotelID, err)
-			} else {
-				fmt.Println("Hotel indexed successfully:", hotelNew.HotelID)
-			}
-		} else { // Caso en el que se actualiza un hotel
-			// Llama al metodo Update del repositorio para actualizar el hotel en Solr
-			if err := service.repository.Update(context.Background(), hotelDAO); err != nil {
-				fmt.Printf("Error updating hotel (%s): %v\n", hotelNew.HotelID, err)
-			} else {
-				fmt.Println("Hotel updated successfully:", hotelNew.HotelID)
-			}
-		}
-	
+		// En Solr crear y actualizar son la misma operacion (el documento se reemplaza)
+		result := service.indexer.Submit(hotelsDAO.Operation{
+			ID:      hotel.ID,
+			Hotel:   hotelDAO,
+			Version: hotel.Version,
+		})
+		go logIndexResult(hotelNew, result)
+
 	// Caso en el que se elimina un hotel
 	case "DELETE":
-		// Llama al metodo Delete del repositorio para eliminar el hotel de Solr
-		if err := service.repository.Delete(context.Background(), hotelNew.HotelID); err != nil {
-			fmt.Printf("Error deleting hotel (%s): %v\n", hotelNew.HotelID, err)
-		} else {
-			fmt.Println("Hotel deleted successfully:", hotelNew.HotelID)
-		}
+		// Borrar un hotel que ya no esta indexado no tiene efecto, asi que los duplicados son inofensivos
+		result := service.indexer.Submit(hotelsDAO.Operation{
+			ID:      hotelNew.HotelID,
+			Delete:  true,
+			Version: hotelNew.Version,
+		})
+		go logIndexResult(hotelNew, result)
+
+	// Cambios de reservas que hotels-api publica para la cache de sus replicas, no cambian el indice
+	case "RESERVATIONS":
 
 	default:
 		fmt.Printf("Unknown operation: %s\n", hotelNew.Operation)
 	}
 }
+
+// Funcion que espera el resultado de una operacion encolada en el indexador y lo loguea
+func logIndexResult(hotelNew hotelsDomain.HotelNew, result <-chan error) {
+	err := <-result
+	switch {
+	case err == nil:
+		fmt.Printf("Hotel %s applied successfully: %s\n", hotelNew.Operation, hotelNew.HotelID)
+	case errors.Is(err, ErrOutdated):
+		fmt.Printf("Ignoring %s of hotel (%s): version %d is outdated\n", hotelNew.Operation, hotelNew.HotelID, hotelNew.Version)
+	default:
+		fmt.Printf("Error applying %s of hotel (%s): %v\n", hotelNew.Operation, hotelNew.HotelID, err)
+	}
+}
@@ -0,0 +1,133 @@
+package search_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	hotelsDAO "search-api/dao/hotels"
+	hotelsDomain "search-api/domain/hotels"
+	service "search-api/services/search"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSuggest(t *testing.T) {
+	// Cada caso arma su propio repositorio y cache para no depender del orden
+	setup := func() (*fakeRepository, service.Service) {
+		repository := &fakeRepository{
+			suggestions: []hotelsDAO.Suggestion{
+				{Text: "Hotel Mar Azul", Type: "name", HotelID: "1"},
+				{Text: "Marina Suites", Type: "name", HotelID: "2"},
+				{Text: "Maral Plaza", Type: "name", HotelID: "3"},
+				{Text: "Mar del Plata", Type: "city"},
+				{Text: "Mar del Plata", Type: "city"},
+				{Text: "mar del plata", Type: "city"},
+				{Text: "Marruecos", Type: "country"},
+			},
+		}
+		cache := &fakeCache{suggestions: map[string][]hotelsDAO.Suggestion{}}
+		return repository, service.NewService(repository, cache, nil, nil)
+	}
+
+	t.Run("Dedupe and interleave types", func(t *testing.T) {
+		_, searchService := setup()
+		suggestions, err := searchService.Suggest(context.Background(), " MAR ", 4)
+		assert.NoError(t, err)
+		assert.Equal(t, []hotelsDomain.Suggestion{
+			{Text: "Hotel Mar Azul", Type: "name", HotelID: "1"},
+			{Text: "Mar del Plata", Type: "city"},
+			{Text: "Marruecos", Type: "country"},
+			{Text: "Marina Suites", Type: "name", HotelID: "2"},
+		}, suggestions)
+	})
+
+	t.Run("Cached by normalized prefix", func(t *testing.T) {
+		repository, searchService := setup()
+		_, err := searchService.Suggest(context.Background(), " MAR ", 4)
+		assert.NoError(t, err)
+		suggestions, err := searchService.Suggest(context.Background(), "mar", 0)
+		assert.NoError(t, err)
+		assert.Len(t, suggestions, 5)
+		assert.Equal(t, 1, repository.suggestCalls)
+	})
+
+	t.Run("Empty prefix", func(t *testing.T) {
+		repository, searchService := setup()
+		suggestions, err := searchService.Suggest(context.Background(), "  ", 5)
+		assert.NoError(t, err)
+		assert.Empty(t, suggestions)
+		assert.Equal(t, 0, repository.suggestCalls)
+	})
+}
+
+func TestSearchPagination(t *testing.T) {
+	repository := &fakeRepository{
+		searchResult: hotelsDAO.SearchResult{
+			Hotels: []hotelsDAO.Hotel{{ID: "1"}, {ID: "2"}},
+			Total:  25,
+		},
+	}
+	searchService := service.NewService(repository, nil, nil, nil)
+
+	t.Run("Defaults", func(t *testing.T) {
+		response, err := searchService.Search(context.Background(), hotelsDomain.SearchRequest{Query: "playa"})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, repository.lastQuery.Offset)
+		assert.Equal(t, 10, repository.lastQuery.Limit)
+		assert.Equal(t, 25, response.Total)
+		assert.Len(t, response.Results, 2)
+		assert.Equal(t, 10, *response.NextOffset)
+		assert.Nil(t, response.PrevOffset)
+	})
+
+	t.Run("Limit is capped", func(t *testing.T) {
+		response, err := searchService.Search(context.Background(), hotelsDomain.SearchRequest{Limit: 1000})
+		assert.NoError(t, err)
+		assert.Equal(t, 100, repository.lastQuery.Limit)
+		assert.Equal(t, 100, response.Limit)
+		assert.Nil(t, response.NextOffset)
+	})
+
+	t.Run("Last page", func(t *testing.T) {
+		response, err := searchService.Search(context.Background(), hotelsDomain.SearchRequest{Offset: 20, Limit: 10})
+		assert.NoError(t, err)
+		assert.Nil(t, response.NextOffset)
+		assert.Equal(t, 10, *response.PrevOffset)
+	})
+
+	t.Run("Cursor", func(t *testing.T) {
+		repository.searchResult.NextCursor = "AoE/ATI="
+		response, err := searchService.Search(context.Background(), hotelsDomain.SearchRequest{Offset: 10, Cursor: "*"})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, repository.lastQuery.Offset)
+		assert.Equal(t, "*", repository.lastQuery.Cursor)
+		assert.Equal(t, "AoE/ATI=", response.NextCursor)
+		assert.Nil(t, response.NextOffset)
+
+		// Cuando Solr devuelve el mismo cursor no quedan mas resultados
+		response, err = searchService.Search(context.Background(), hotelsDomain.SearchRequest{Cursor: "AoE/ATI="})
+		assert.NoError(t, err)
+		assert.Empty(t, response.NextCursor)
+	})
+}
+
+func TestSuggestionsBuilder(t *testing.T) {
+	repository := &fakeRepository{versions: map[string]int64{}}
+	indexer := service.NewIndexer(repository, service.IndexerConfig{BatchSize: 1, FlushInterval: time.Hour})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	indexer.Start(ctx)
+	searchService := service.NewService(repository, nil, nil, indexer)
+	go searchService.RunSuggestionsBuilder(ctx, 5*time.Millisecond)
+
+	// Sin escrituras no se reconstruyen los diccionarios
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, 0, repository.buildCount())
+
+	// Despues de indexar un hotel se reconstruyen una sola vez
+	assert.NoError(t, <-indexer.Submit(upsert("1", 1)))
+	assert.Eventually(t, func() bool { return repository.buildCount() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, 1, repository.buildCount())
+}
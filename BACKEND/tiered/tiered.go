@@ -0,0 +1,243 @@
+// Package tiered es una cache de lectura en varios niveles, por ejemplo una cache en memoria delante de Memcached
+// delante de la base de datos. Es un modulo aparte que usan users-api y hotels-api
+package tiered
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Nivel de la cache. Get devuelve un error si no tiene la clave
+type Tier[K comparable, V any] interface {
+	Get(ctx context.Context, key K) (V, error)
+	Set(ctx context.Context, key K, value V, ttl time.Duration) error
+	Delete(ctx context.Context, key K) error
+}
+
+// Nivel que ademas puede recordar que una clave no existe en la fuente
+type NotFoundTier[K comparable] interface {
+	SetNotFound(ctx context.Context, key K) error
+}
+
+// Politica que decide que hace Set en un nivel
+type WritePolicy int
+
+const (
+	// WriteThrough guarda el valor nuevo en el nivel
+	WriteThrough WritePolicy = iota
+	// WriteInvalidate borra la clave del nivel, asi la proxima lectura carga el valor nuevo
+	WriteInvalidate
+)
+
+// Nivel con su configuracion, van del mas rapido al mas lento
+type Level[K comparable, V any] struct {
+	Name  string // Se usa en los errores que se le pasan a OnError
+	Tier  Tier[K, V]
+	TTL   time.Duration // Se le pasa al nivel en cada escritura, cero deja que el nivel use su duracion por defecto
+	Write WritePolicy
+}
+
+// Funcion que lee un valor de la fuente (la base principal) cuando ningun nivel lo tiene
+type Loader[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+type Config[K comparable, V any] struct {
+	Levels []Level[K, V]
+	Load   Loader[K, V]
+	// IsNotFound indica si un error significa que la clave no existe. Si un nivel devuelve ese error es porque
+	// recuerda que no existe, y la busqueda termina ahi; si lo devuelve Load, lo recuerdan los niveles que
+	// implementan NotFoundTier. Si es nil ningun error es un no encontrado
+	IsNotFound func(err error) bool
+	// OnError recibe cada escritura o borrado de un nivel que fallo. Las fallas de los niveles nunca hacen fallar una llamada
+	OnError func(err error)
+}
+
+type Cache[K comparable, V any] struct {
+	config Config[K, V]
+	// Agrupa las cargas simultaneas de una clave, asi solo una llega a la fuente
+	loads *singleflight.Group
+	// Cargas en curso por clave. Set y Delete las marcan como viejas, para que no vuelvan a escribir en los niveles
+	// un valor leido antes del cambio
+	mu      *sync.Mutex
+	pending map[string]*pendingLoad
+}
+
+// Carga en curso de una clave, stale indica que la clave cambio mientras se cargaba
+type pendingLoad struct {
+	stale bool
+}
+
+func New[K comparable, V any](config Config[K, V]) Cache[K, V] {
+	return Cache[K, V]{
+		config:  config,
+		loads:   &singleflight.Group{},
+		mu:      &sync.Mutex{},
+		pending: make(map[string]*pendingLoad),
+	}
+}
+
+// Funcion que devuelve el valor del primer nivel que lo tiene y lo guarda en los niveles mas rapidos
+// Si ningun nivel lo tiene se carga de la fuente y se guarda en todos
+func (cache Cache[K, V]) Get(ctx context.Context, key K) (V, error) {
+	for i, level := range cache.config.Levels {
+		value, err := level.Tier.Get(ctx, key)
+		if err == nil {
+			cache.fill(ctx, cache.config.Levels[:i], key, value)
+			return value, nil
+		}
+		if cache.notFound(err) {
+			var zero V
+			return zero, err
+		}
+	}
+	return cache.load(ctx, key)
+}
+
+// Funcion que guarda un valor que se acaba de escribir en la fuente, segun la politica de escritura de cada nivel
+func (cache Cache[K, V]) Set(ctx context.Context, key K, value V) {
+	cache.invalidate(key)
+	for _, level := range cache.config.Levels {
+		switch level.Write {
+		case WriteThrough:
+			cache.report(level, "set", level.Tier.Set(ctx, key, value, level.TTL))
+		case WriteInvalidate:
+			cache.report(level, "delete", level.Tier.Delete(ctx, key))
+		}
+	}
+}
+
+// Funcion que borra una clave de todos los niveles, despues de borrarla o cambiarla en la fuente
+func (cache Cache[K, V]) Delete(ctx context.Context, key K) {
+	cache.invalidate(key)
+	for _, level := range cache.config.Levels {
+		cache.report(level, "delete", level.Tier.Delete(ctx, key))
+	}
+}
+
+// La carga no se cancela con la peticion que la empezo, porque otras pueden estar esperandola
+// Si la clave se escribe o se borra mientras se carga, lo cargado no se guarda en los niveles
+func (cache Cache[K, V]) load(ctx context.Context, key K) (V, error) {
+	ctx = context.WithoutCancel(ctx)
+	id := fmt.Sprint(key)
+	value, err, _ := cache.loads.Do(id, func() (interface{}, error) {
+		pending := cache.begin(id)
+		value, err := cache.config.Load(ctx, key)
+		stored := false
+		if err == nil && !cache.isStale(pending) {
+			cache.fill(ctx, cache.config.Levels, key, value)
+			stored = true
+		}
+		if err != nil && cache.notFound(err) && !cache.isStale(pending) {
+			for _, level := range cache.config.Levels {
+				if tier, ok := level.Tier.(NotFoundTier[K]); ok {
+					cache.report(level, "set not found", tier.SetNotFound(ctx, key))
+				}
+			}
+			stored = true
+		}
+		// Si cambio mientras se guardaba, lo guardado puede pisar el valor nuevo: se borra y se vuelve a cargar
+		// en la proxima lectura
+		if cache.end(id, pending) && stored {
+			for _, level := range cache.config.Levels {
+				cache.report(level, "delete", level.Tier.Delete(ctx, key))
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		return value, nil
+	})
+	if err != nil {
+		var zero V
+		return zero, err
+	}
+	return value.(V), nil
+}
+
+// Funcion que registra una carga en curso de la clave id
+func (cache Cache[K, V]) begin(id string) *pendingLoad {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	pending := &pendingLoad{}
+	cache.pending[id] = pending
+	return pending
+}
+
+func (cache Cache[K, V]) isStale(pending *pendingLoad) bool {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	return pending.stale
+}
+
+// Funcion que termina una carga en curso y devuelve si la clave cambio mientras tanto
+func (cache Cache[K, V]) end(id string, pending *pendingLoad) bool {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if cache.pending[id] == pending {
+		delete(cache.pending, id)
+	}
+	return pending.stale
+}
+
+// Funcion que marca como vieja la carga en curso de una clave que cambio, y hace que las lecturas siguientes no
+// esperen esa carga sino que empiecen otra
+func (cache Cache[K, V]) invalidate(key K) {
+	id := fmt.Sprint(key)
+	cache.mu.Lock()
+	if pending, ok := cache.pending[id]; ok {
+		pending.stale = true
+		delete(cache.pending, id)
+	}
+	cache.mu.Unlock()
+	cache.loads.Forget(id)
+}
+
+func (cache Cache[K, V]) fill(ctx context.Context, levels []Level[K, V], key K, value V) {
+	for _, level := range levels {
+		cache.report(level, "set", level.Tier.Set(ctx, key, value, level.TTL))
+	}
+}
+
+func (cache Cache[K, V]) notFound(err error) bool {
+	return cache.config.IsNotFound != nil && cache.config.IsNotFound(err)
+}
+
+func (cache Cache[K, V]) report(level Level[K, V], operation string, err error) {
+	if err != nil && cache.config.OnError != nil {
+		cache.config.OnError(fmt.Errorf("%s in %s: %w", operation, level.Name, err))
+	}
+}
+
+// Adapta funciones a un Tier, para los repositorios cuyos metodos no coinciden. Si DeleteFunc o SetNotFoundFunc
+// son nil no hacen nada
+type Funcs[K comparable, V any] struct {
+	GetFunc         func(ctx context.Context, key K) (V, error)
+	SetFunc         func(ctx context.Context, key K, value V, ttl time.Duration) error
+	DeleteFunc      func(ctx context.Context, key K) error
+	SetNotFoundFunc func(ctx context.Context, key K) error
+}
+
+func (funcs Funcs[K, V]) Get(ctx context.Context, key K) (V, error) {
+	return funcs.GetFunc(ctx, key)
+}
+
+func (funcs Funcs[K, V]) Set(ctx context.Context, key K, value V, ttl time.Duration) error {
+	return funcs.SetFunc(ctx, key, value, ttl)
+}
+
+func (funcs Funcs[K, V]) Delete(ctx context.Context, key K) error {
+	if funcs.DeleteFunc == nil {
+		return nil
+	}
+	return funcs.DeleteFunc(ctx, key)
+}
+
+func (funcs Funcs[K, V]) SetNotFound(ctx context.Context, key K) error {
+	if funcs.SetNotFoundFunc == nil {
+		return nil
+	}
+	return funcs.SetNotFoundFunc(ctx, key)
+}
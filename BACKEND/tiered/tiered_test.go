@@ -0,0 +1,270 @@
+package tiered
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var errNotFound = errors.New("not found")
+
+// Nivel en memoria que registra el TTL de cada escritura, y puede fallar como un servidor caido
+type mapTier struct {
+	mu      sync.Mutex
+	values  map[string]string
+	ttls    map[string]time.Duration
+	missing map[string]bool
+	down    bool
+}
+
+func newMapTier() *mapTier {
+	return &mapTier{values: map[string]string{}, ttls: map[string]time.Duration{}, missing: map[string]bool{}}
+}
+
+func (tier *mapTier) Get(ctx context.Context, key string) (string, error) {
+	tier.mu.Lock()
+	defer tier.mu.Unlock()
+	if tier.down {
+		return "", errors.New("connection refused")
+	}
+	if tier.missing[key] {
+		return "", errNotFound
+	}
+	value, ok := tier.values[key]
+	if !ok {
+		return "", errors.New("cache miss")
+	}
+	return value, nil
+}
+
+func (tier *mapTier) Set(ctx context.Context, key string, value string, ttl time.Duration) error {
+	tier.mu.Lock()
+	defer tier.mu.Unlock()
+	if tier.down {
+		return errors.New("connection refused")
+	}
+	tier.values[key], tier.ttls[key] = value, ttl
+	delete(tier.missing, key)
+	return nil
+}
+
+func (tier *mapTier) Delete(ctx context.Context, key string) error {
+	tier.mu.Lock()
+	defer tier.mu.Unlock()
+	if tier.down {
+		return errors.New("connection refused")
+	}
+	delete(tier.values, key)
+	delete(tier.missing, key)
+	return nil
+}
+
+func (tier *mapTier) SetNotFound(ctx context.Context, key string) error {
+	tier.mu.Lock()
+	defer tier.mu.Unlock()
+	tier.missing[key] = true
+	return nil
+}
+
+// Fuente de los valores, cuenta las cargas
+type source struct {
+	values  map[string]string
+	loads   atomic.Int64
+	release chan struct{}
+}
+
+func (source *source) load(ctx context.Context, key string) (string, error) {
+	source.loads.Add(1)
+	if source.release != nil {
+		<-source.release
+	}
+	value, ok := source.values[key]
+	if !ok {
+		return "", errNotFound
+	}
+	return value, nil
+}
+
+func newCache(source *source, local, shared *mapTier, errs *[]error) Cache[string, string] {
+	return New(Config[string, string]{
+		Levels: []Level[string, string]{
+			{Name: "local", Tier: local, TTL: time.Minute},
+			{Name: "shared", Tier: shared, TTL: time.Hour},
+		},
+		Load:       source.load,
+		IsNotFound: func(err error) bool { return errors.Is(err, errNotFound) },
+		OnError:    func(err error) { *errs = append(*errs, err) },
+	})
+}
+
+func TestGetReadsThroughTiers(t *testing.T) {
+	ctx := context.Background()
+	src := &source{values: map[string]string{"a": "A"}}
+	local, shared := newMapTier(), newMapTier()
+	var errs []error
+	cache := newCache(src, local, shared, &errs)
+
+	// La primera lectura carga de la fuente y guarda el valor en todos los niveles con su TTL
+	value, err := cache.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, "A", value)
+	assert.Equal(t, int64(1), src.loads.Load())
+	assert.Equal(t, time.Minute, local.ttls["a"])
+	assert.Equal(t, time.Hour, shared.ttls["a"])
+
+	// Si solo lo tiene el nivel compartido, se guarda en el local sin cargar
+	assert.NoError(t, local.Delete(ctx, "a"))
+	value, err = cache.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, "A", value)
+	assert.Equal(t, "A", local.values["a"])
+	assert.Equal(t, int64(1), src.loads.Load())
+	assert.Empty(t, errs)
+}
+
+func TestLevelsUseTheirOwnTTL(t *testing.T) {
+	ctx := context.Background()
+	src := &source{values: map[string]string{"a": "A"}}
+	local, shared := newMapTier(), newMapTier()
+	var errs []error
+	cache := newCache(src, local, shared, &errs)
+
+	// La misma clave se guarda en cada nivel con la duracion de ese nivel, al llenarlo y al escribirla
+	cache.Set(ctx, "a", "B")
+	assert.Equal(t, time.Minute, local.ttls["a"])
+	assert.Equal(t, time.Hour, shared.ttls["a"])
+
+	assert.NoError(t, local.Delete(ctx, "a"))
+	shared.ttls["a"] = 0
+	value, err := cache.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, "B", value)
+	assert.Equal(t, time.Minute, local.ttls["a"])
+	assert.Equal(t, time.Duration(0), shared.ttls["a"])
+	assert.Empty(t, errs)
+}
+
+func TestGetCachesNotFound(t *testing.T) {
+	ctx := context.Background()
+	src := &source{values: map[string]string{}}
+	local, shared := newMapTier(), newMapTier()
+	var errs []error
+	cache := newCache(src, local, shared, &errs)
+
+	for i := 0; i < 2; i++ {
+		_, err := cache.Get(ctx, "missing")
+		assert.ErrorIs(t, err, errNotFound)
+	}
+	assert.Equal(t, int64(1), src.loads.Load())
+}
+
+func TestGetCoalescesLoads(t *testing.T) {
+	src := &source{values: map[string]string{"a": "A"}, release: make(chan struct{})}
+	var errs []error
+	cache := newCache(src, newMapTier(), newMapTier(), &errs)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := cache.Get(context.Background(), "a")
+			assert.NoError(t, err)
+			assert.Equal(t, "A", value)
+		}()
+	}
+	assert.Eventually(t, func() bool { return src.loads.Load() == 1 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	close(src.release)
+	wg.Wait()
+	assert.Equal(t, int64(1), src.loads.Load())
+}
+
+func TestWriteDuringLoadIsNotOverwritten(t *testing.T) {
+	for _, write := range []string{"set", "delete"} {
+		t.Run(write, func(t *testing.T) {
+			ctx := context.Background()
+			src := &source{values: map[string]string{"a": "old"}, release: make(chan struct{})}
+			local, shared := newMapTier(), newMapTier()
+			var errs []error
+			cache := newCache(src, local, shared, &errs)
+
+			// Una lectura empieza a cargar el valor viejo y la clave cambia antes de que termine
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				value, err := cache.Get(ctx, "a")
+				assert.NoError(t, err)
+				assert.Equal(t, "old", value)
+			}()
+			assert.Eventually(t, func() bool { return src.loads.Load() == 1 }, time.Second, time.Millisecond)
+			if write == "set" {
+				cache.Set(ctx, "a", "new")
+			} else {
+				cache.Delete(ctx, "a")
+			}
+			close(src.release)
+			<-done
+			src.values["a"] = "new"
+
+			// La carga vieja no pisa lo que dejo la escritura
+			if write == "set" {
+				assert.Equal(t, "new", local.values["a"])
+				assert.Equal(t, "new", shared.values["a"])
+			} else {
+				assert.NotContains(t, local.values, "a")
+				assert.NotContains(t, shared.values, "a")
+			}
+			value, err := cache.Get(ctx, "a")
+			assert.NoError(t, err)
+			assert.Equal(t, "new", value)
+			assert.Empty(t, errs)
+		})
+	}
+}
+
+func TestSetFollowsWritePolicies(t *testing.T) {
+	ctx := context.Background()
+	local, shared := newMapTier(), newMapTier()
+	shared.values["a"] = "old"
+	cache := New(Config[string, string]{
+		Levels: []Level[string, string]{
+			{Name: "local", Tier: local, Write: WriteThrough},
+			{Name: "shared", Tier: shared, Write: WriteInvalidate},
+		},
+		Load: (&source{}).load,
+	})
+
+	cache.Set(ctx, "a", "new")
+	assert.Equal(t, "new", local.values["a"])
+	assert.NotContains(t, shared.values, "a")
+
+	cache.Delete(ctx, "a")
+	assert.NotContains(t, local.values, "a")
+}
+
+func TestTierOutageIsReported(t *testing.T) {
+	ctx := context.Background()
+	src := &source{values: map[string]string{"a": "A"}}
+	local, shared := newMapTier(), newMapTier()
+	shared.down = true
+	var errs []error
+	cache := newCache(src, local, shared, &errs)
+
+	// Con el nivel compartido caido se sigue leyendo de la fuente
+	value, err := cache.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, "A", value)
+	cache.Set(ctx, "a", "B")
+	cache.Delete(ctx, "a")
+
+	// y cada escritura que falla llega a OnError
+	assert.Len(t, errs, 3)
+	assert.ErrorContains(t, errs[0], "set in shared")
+	assert.ErrorContains(t, errs[2], "delete in shared")
+}
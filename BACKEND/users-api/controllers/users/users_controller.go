@@ -1,10 +1,12 @@
 package users
 
 import (
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
+	dao "users-api/dao/users"
 	domain "users-api/domain/users"
 )
 
@@ -15,6 +17,7 @@ type Service interface {
 	Update(user domain.User) error
 	Delete(id int64) error
 	Login(username string, password string) (domain.LoginResponse, error)
+	CacheMetrics() domain.CacheMetrics
 }
 
 type Controller struct {
@@ -54,17 +57,28 @@ func (controller Controller) GetByID(c *gin.Context) {
 
 	// Invoke service
 	user, err := controller.service.GetByID(id)
-	if err != nil {
+	if errors.Is(err, dao.ErrNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": fmt.Sprintf("user not found: %s", err.Error()),
 		})
 		return
 	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("error getting user: %s", err.Error()),
+		})
+		return
+	}
 
 	// Send user
 	c.JSON(http.StatusOK, user)
 }
 
+func (controller Controller) CacheMetrics(c *gin.Context) {
+	// Send cache counters
+	c.JSON(http.StatusOK, controller.service.CacheMetrics())
+}
+
 func (controller Controller) Create(c *gin.Context) {
 	// Parse user from HTTP Request
 	var user domain.User
@@ -1,5 +1,10 @@
 package users
 
+import "errors"
+
+// ErrNotFound is returned by the main repository when the user doesn't exist
+var ErrNotFound = errors.New("user not found")
+
 type User struct {
 	ID       int64  `gorm:"primaryKey;autoIncrement"`                    // Auto-increment primary key
 	Username string `gorm:"size:100;not null;unique" binding:"required"` // Unique username, required
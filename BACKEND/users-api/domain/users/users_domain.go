@@ -11,3 +11,7 @@ type LoginResponse struct {
 	Username string `json:"username"`
 	Token    string `json:"token"`
 }
+
+type CacheMetrics struct {
+	Errors int64 `json:"errors"` // Cache writes and deletes that failed
+}
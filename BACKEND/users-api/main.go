@@ -58,6 +58,7 @@ func main() {
 	// URL mappings
 	router.GET("/users", controller.GetAll)
 	router.GET("/users/:id", controller.GetByID)
+	router.GET("/users/cache/metrics", controller.CacheMetrics)
 	router.POST("/users", controller.Create)
 	router.PUT("/users/:id", controller.Update)
 	router.POST("/login", controller.Login)
@@ -70,16 +70,27 @@ func (repository Cache) GetByUsername(username string) (users.User, error) {
 }
 
 func (repository Cache) Create(user users.User) (int64, error) {
-	// Cache user by ID and by username after creation
+	// Cache user by ID and by username after creation, with the configured TTL
+	if err := repository.Set(user, 0); err != nil {
+		return 0, err
+	}
+
+	// Return the user ID as if it was created successfully
+	return user.ID, nil
+}
+
+// Set caches the user by ID and by username for ttl, zero uses the configured TTL
+func (repository Cache) Set(user users.User, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = repository.ttl
+	}
 	idKey := fmt.Sprintf("user:id:%d", user.ID)
 	userKey := fmt.Sprintf("user:username:%s", user.Username)
 
 	// Set user in cache
-	repository.client.Set(idKey, user, repository.ttl)
-	repository.client.Set(userKey, user, repository.ttl)
-
-	// Return the user ID as if it was created successfully
-	return user.ID, nil
+	repository.client.Set(idKey, user, ttl)
+	repository.client.Set(userKey, user, ttl)
+	return nil
 }
 
 func (repository Cache) Update(user users.User) error {
@@ -99,9 +110,10 @@ func (repository Cache) Delete(id int64) error {
 	idKey := fmt.Sprintf("user:id:%d", id)
 
 	// Try to get user by ID to retrieve username
+	// A user that isn't cached is already deleted from the cache
 	user, err := repository.GetByID(id)
 	if err != nil {
-		return fmt.Errorf("error retrieving user by ID for deletion: %w", err)
+		return nil
 	}
 
 	// Delete by ID
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/bradfitz/gomemcache/memcache"
+	"time"
 	"users-api/dao/users"
 )
 
@@ -79,25 +80,36 @@ func (repository Memcached) GetByUsername(username string) (users.User, error) {
 }
 
 func (repository Memcached) Create(user users.User) (int64, error) {
+	// Store the user without expiration
+	if err := repository.Set(user, 0); err != nil {
+		return 0, err
+	}
+
+	return user.ID, nil
+}
+
+// Set stores the user by ID and by username for ttl, zero stores it without expiration
+func (repository Memcached) Set(user users.User, ttl time.Duration) error {
 	// Serialize user data
 	data, err := json.Marshal(user)
 	if err != nil {
-		return 0, fmt.Errorf("error marshaling user: %w", err)
+		return fmt.Errorf("error marshaling user: %w", err)
 	}
+	expiration := int32(ttl / time.Second)
 
 	// Store user with ID as key and username as an alternate key
 	idKey := idKey(user.ID)
-	if err := repository.client.Set(&memcache.Item{Key: idKey, Value: data}); err != nil {
-		return 0, fmt.Errorf("error storing user in memcached: %w", err)
+	if err := repository.client.Set(&memcache.Item{Key: idKey, Value: data, Expiration: expiration}); err != nil {
+		return fmt.Errorf("error storing user in memcached: %w", err)
 	}
 
 	// Set key for username as well for easier lookup by username
 	usernameKey := usernameKey(user.Username)
-	if err := repository.client.Set(&memcache.Item{Key: usernameKey, Value: data}); err != nil {
-		return 0, fmt.Errorf("error storing username in memcached: %w", err)
+	if err := repository.client.Set(&memcache.Item{Key: usernameKey, Value: data, Expiration: expiration}); err != nil {
+		return fmt.Errorf("error storing username in memcached: %w", err)
 	}
 
-	return user.ID, nil
+	return nil
 }
 
 func (repository Memcached) Update(user users.User) error {
@@ -128,8 +140,9 @@ func (repository Memcached) Delete(id int64) error {
 	idKey := idKey(id)
 	item, err := repository.client.Get(idKey)
 	if err != nil {
+		// A user that isn't cached has nothing to delete
 		if errors.Is(err, memcache.ErrCacheMiss) {
-			return fmt.Errorf("user not found")
+			return nil
 		}
 		return fmt.Errorf("error fetching user from memcached: %w", err)
 	}
@@ -140,20 +153,14 @@ func (repository Memcached) Delete(id int64) error {
 		return fmt.Errorf("error unmarshaling user: %w", err)
 	}
 
-	// Delete the user by username
+	// Delete the user by username, it may have expired already
 	usernameKey := usernameKey(user.Username)
-	if err := repository.client.Delete(usernameKey); err != nil {
-		if !errors.Is(err, memcache.ErrCacheMiss) {
-			return fmt.Errorf("error deleting username from memcached: %w", err)
-		}
-		return fmt.Errorf("error deleting user from memcached: %w", err)
+	if err := repository.client.Delete(usernameKey); err != nil && !errors.Is(err, memcache.ErrCacheMiss) {
+		return fmt.Errorf("error deleting username from memcached: %w", err)
 	}
 
 	// Delete the user by ID
-	if err := repository.client.Delete(idKey); err != nil {
-		if errors.Is(err, memcache.ErrCacheMiss) {
-			return fmt.Errorf("user not found when trying to delete by ID")
-		}
+	if err := repository.client.Delete(idKey); err != nil && !errors.Is(err, memcache.ErrCacheMiss) {
 		return fmt.Errorf("error deleting user from memcached: %w", err)
 	}
 
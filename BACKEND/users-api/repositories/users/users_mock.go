@@ -2,6 +2,7 @@ package users
 
 import (
 	"github.com/stretchr/testify/mock"
+	"time"
 	"users-api/dao/users"
 )
 
@@ -45,6 +46,11 @@ func (m *Mock) Create(user users.User) (int64, error) {
 	return args.Get(0).(int64), nil
 }
 
+func (m *Mock) Set(user users.User, ttl time.Duration) error {
+	args := m.Called(user, ttl)
+	return args.Error(0)
+}
+
 func (m *Mock) Update(user users.User) error {
 	args := m.Called(user)
 	return args.Error(0) // No change needed here as it returns an error directly
@@ -63,7 +63,7 @@ func (repository MySQL) GetByID(id int64) (users.User, error) {
 	var user users.User
 	if err := repository.db.First(&user, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return user, fmt.Errorf("user not found")
+			return user, fmt.Errorf("error fetching user: %w", users.ErrNotFound)
 		}
 		return user, fmt.Errorf("error fetching user by id: %w", err)
 	}
@@ -74,7 +74,7 @@ func (repository MySQL) GetByUsername(username string) (users.User, error) {
 	var user users.User
 	if err := repository.db.Where("username = ?", username).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return user, fmt.Errorf("user not found")
+			return user, fmt.Errorf("error fetching user: %w", users.ErrNotFound)
 		}
 		return user, fmt.Errorf("error fetching user by username: %w", err)
 	}
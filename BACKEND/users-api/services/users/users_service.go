@@ -1,10 +1,14 @@
 package users
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
 	"log"
+	"sync/atomic"
+	"tiered"
+	"time"
 	dao "users-api/dao/users"
 	domain "users-api/domain/users"
 )
@@ -18,24 +22,100 @@ type Repository interface {
 	Delete(id int64) error
 }
 
+// CacheRepository is a Repository that can also store a user for a given TTL, so each cache level keeps users
+// for its own time
+type CacheRepository interface {
+	Repository
+	Set(user dao.User, ttl time.Duration) error
+}
+
+// How long each cache level keeps a user. The in-process cache is per instance, so it is kept short for updates
+// made by another instance to show up soon
+const (
+	cacheTTL     = time.Minute
+	memcachedTTL = 10 * time.Minute
+)
+
 type Tokenizer interface {
 	GenerateToken(username string, userID int64) (string, error)
 }
 
 type Service struct {
 	mainRepository      Repository
-	cacheRepository     Repository
-	memcachedRepository Repository
+	cacheRepository     CacheRepository
+	memcachedRepository CacheRepository
 	tokenizer           Tokenizer
+	// Number of cache writes that failed. The cache is best-effort, so these don't fail the request
+	cacheErrors *atomic.Int64
+	// Users by ID and by username: in-process cache, then Memcached, then the main repository
+	usersByID       tiered.Cache[int64, dao.User]
+	usersByUsername tiered.Cache[string, dao.User]
 }
 
-func NewService(mainRepository, cacheRepository, memcachedRepository Repository, tokenizer Tokenizer) Service {
-	return Service{
+func NewService(mainRepository Repository, cacheRepository, memcachedRepository CacheRepository, tokenizer Tokenizer) Service {
+	service := Service{
 		mainRepository:      mainRepository,
 		cacheRepository:     cacheRepository,
 		memcachedRepository: memcachedRepository,
 		tokenizer:           tokenizer,
+		cacheErrors:         &atomic.Int64{},
+	}
+	onError := func(err error) { service.cacheFailed("error writing user cache", err) }
+	service.usersByID = tiered.New(tiered.Config[int64, dao.User]{
+		Levels: []tiered.Level[int64, dao.User]{
+			{Name: "cache", Tier: userTier(cacheRepository, cacheRepository.GetByID, cacheRepository.Delete), TTL: cacheTTL},
+			{Name: "memcached", Tier: userTier(memcachedRepository, memcachedRepository.GetByID, memcachedRepository.Delete), TTL: memcachedTTL},
+		},
+		Load:    func(ctx context.Context, id int64) (dao.User, error) { return mainRepository.GetByID(id) },
+		OnError: onError,
+	})
+	service.usersByUsername = tiered.New(tiered.Config[string, dao.User]{
+		Levels: []tiered.Level[string, dao.User]{
+			{Name: "cache", Tier: userTier[string](cacheRepository, cacheRepository.GetByUsername, nil), TTL: cacheTTL},
+			{Name: "memcached", Tier: userTier[string](memcachedRepository, memcachedRepository.GetByUsername, nil), TTL: memcachedTTL},
+		},
+		Load: func(ctx context.Context, username string) (dao.User, error) {
+			return mainRepository.GetByUsername(username)
+		},
+		OnError: onError,
+	})
+	return service
+}
+
+// userTier adapts a cache repository to a tier keyed by ID or by username. Set stores the user under both
+// keys with the level's TTL. Users are only deleted by ID, so remove is nil for usernames
+func userTier[K comparable](repository CacheRepository, get func(key K) (dao.User, error), remove func(key K) error) tiered.Tier[K, dao.User] {
+	tier := tiered.Funcs[K, dao.User]{
+		GetFunc: func(ctx context.Context, key K) (dao.User, error) {
+			return get(key)
+		},
+		SetFunc: func(ctx context.Context, key K, user dao.User, ttl time.Duration) error {
+			return repository.Set(user, ttl)
+		},
 	}
+	if remove != nil {
+		tier.DeleteFunc = func(ctx context.Context, key K) error {
+			return remove(key)
+		}
+	}
+	return tier
+}
+
+// CacheErrors returns how many cache writes have failed since the service started
+func (service Service) CacheErrors() int64 {
+	return service.cacheErrors.Load()
+}
+
+// CacheMetrics returns the cache counters exposed by the metrics endpoint
+func (service Service) CacheMetrics() domain.CacheMetrics {
+	return domain.CacheMetrics{Errors: service.CacheErrors()}
+}
+
+// cacheFailed logs a failed cache write and counts it. The main repository is the source of truth, so a
+// cache that is down or missing the item only costs a slower read later
+func (service Service) cacheFailed(message string, err error) {
+	service.cacheErrors.Add(1)
+	log.Printf("%s: %v", message, err)
 }
 
 func (service Service) GetAll() ([]domain.User, error) {
@@ -57,68 +137,22 @@ func (service Service) GetAll() ([]domain.User, error) {
 }
 
 func (service Service) GetByID(id int64) (domain.User, error) {
-	// Check in cache first
-	user, err := service.cacheRepository.GetByID(id)
-	if err == nil {
-		return service.convertUser(user), nil
-	}
-
-	// Check in memcached
-	user, err = service.memcachedRepository.GetByID(id)
-	if err == nil {
-		if _, err := service.cacheRepository.Create(user); err != nil {
-			return domain.User{}, fmt.Errorf("error caching user after memcached retrieval: %w", err)
-		}
-		return service.convertUser(user), nil
-	}
-
-	// Check in main repository
-	user, err = service.mainRepository.GetByID(id)
+	// Check the caches, then the main repository
+	user, err := service.usersByID.Get(context.Background(), id)
 	if err != nil {
 		return domain.User{}, fmt.Errorf("error getting user by ID: %w", err)
 	}
 
-	// Save in cache and memcached
-	if _, err := service.cacheRepository.Create(user); err != nil {
-		return domain.User{}, fmt.Errorf("error caching user after main retrieval: %w", err)
-	}
-	if _, err := service.memcachedRepository.Create(user); err != nil {
-		return domain.User{}, fmt.Errorf("error saving user in memcached: %w", err)
-	}
-
 	return service.convertUser(user), nil
 }
 
 func (service Service) GetByUsername(username string) (domain.User, error) {
-	// Check in cache first
-	user, err := service.cacheRepository.GetByUsername(username)
-	if err == nil {
-		return service.convertUser(user), nil
-	}
-
-	// Check memcached
-	user, err = service.memcachedRepository.GetByUsername(username)
-	if err == nil {
-		if _, err := service.cacheRepository.Create(user); err != nil {
-			return domain.User{}, fmt.Errorf("error caching user after memcached retrieval: %w", err)
-		}
-		return service.convertUser(user), nil
-	}
-
-	// Check main repository
-	user, err = service.mainRepository.GetByUsername(username)
+	// Check the caches, then the main repository
+	user, err := service.usersByUsername.Get(context.Background(), username)
 	if err != nil {
 		return domain.User{}, fmt.Errorf("error getting user by username: %w", err)
 	}
 
-	// Save in cache and memcached
-	if _, err := service.cacheRepository.Create(user); err != nil {
-		return domain.User{}, fmt.Errorf("error caching user after main retrieval: %w", err)
-	}
-	if _, err := service.memcachedRepository.Create(user); err != nil {
-		return domain.User{}, fmt.Errorf("error saving user in memcached: %w", err)
-	}
-
 	return service.convertUser(user), nil
 }
 
@@ -139,12 +173,7 @@ func (service Service) Create(user domain.User) (int64, error) {
 
 	// Add to cache and memcached
 	newUser.ID = id
-	if _, err := service.cacheRepository.Create(newUser); err != nil {
-		return 0, fmt.Errorf("error caching new user: %w", err)
-	}
-	if _, err := service.memcachedRepository.Create(newUser); err != nil {
-		return 0, fmt.Errorf("error saving new user in memcached: %w", err)
-	}
+	service.usersByID.Set(context.Background(), id, newUser)
 
 	return id, nil
 }
@@ -173,17 +202,11 @@ func (service Service) Update(user domain.User) error {
 	}
 
 	// Update in cache and memcached
-	updatedUser := dao.User{
+	service.usersByID.Set(context.Background(), user.ID, dao.User{
 		ID:       user.ID,
 		Username: user.Username,
 		Password: passwordHash,
-	}
-	if err := service.cacheRepository.Update(updatedUser); err != nil {
-		return fmt.Errorf("error updating user in cache: %w", err)
-	}
-	if err := service.memcachedRepository.Update(updatedUser); err != nil {
-		return fmt.Errorf("error updating user in memcached: %w", err)
-	}
+	})
 
 	return nil
 }
@@ -196,12 +219,7 @@ func (service Service) Delete(id int64) error {
 	}
 
 	// Delete from cache and memcached
-	if err := service.cacheRepository.Delete(id); err != nil {
-		return fmt.Errorf("error deleting user from cache: %w", err)
-	}
-	if err := service.memcachedRepository.Delete(id); err != nil {
-		return fmt.Errorf("error deleting user from memcached: %w", err)
-	}
+	service.usersByID.Delete(context.Background(), id)
 
 	return nil
 }
@@ -210,39 +228,12 @@ func (service Service) Login(username string, password string) (domain.LoginResp
 	// Hash the password
 	passwordHash := Hash(password)
 
-	// Try to get user from cache repository first
-	user, err := service.cacheRepository.GetByUsername(username)
+	// Try the caches first, then the main repository (database)
+	user, err := service.usersByUsername.Get(context.Background(), username)
 	if err != nil {
-		log.Printf("error getting user by username from cache repository: %v", err) // Log the error
-		// If not found in cache, try to get user from memcached repository
-		user, err = service.memcachedRepository.GetByUsername(username)
-		if err != nil {
-			log.Printf("error getting user by username from cache repository: %v", err) // Log the error
-			// If not found in memcached, try to get user from the main repository (database)
-			user, err = service.mainRepository.GetByUsername(username)
-			if err != nil {
-				return domain.LoginResponse{}, fmt.Errorf("error getting user by username from main repository: %w", err)
-			}
-
-			// Save the found user in both cache and memcached repositories
-			if _, err := service.cacheRepository.Create(user); err != nil {
-				log.Printf("error getting user by username from cache repository: %v", err) // Log the error
-				return domain.LoginResponse{}, fmt.Errorf("error caching user in cache repository: %w", err)
-			}
-			if _, err := service.memcachedRepository.Create(user); err != nil {
-				log.Printf("error getting user by username from cache repository: %v", err) // Log the error
-				return domain.LoginResponse{}, fmt.Errorf("error caching user in memcached repository: %w", err)
-			}
-		} else {
-			// Save the found user in the cache repository for future access
-			if _, err := service.cacheRepository.Create(user); err != nil {
-				log.Printf("error getting user by username from cache repository: %v", err) // Log the error
-				return domain.LoginResponse{}, fmt.Errorf("error caching user in cache repository: %w", err)
-			}
-		}
-	} else {
-		log.Printf("Se encontro el usuario en la cache")
+		return domain.LoginResponse{}, fmt.Errorf("error getting user by username from main repository: %w", err)
 	}
+
 	// Compare passwords
 	if user.Password != passwordHash {
 		return domain.LoginResponse{}, fmt.Errorf("invalid credentials")
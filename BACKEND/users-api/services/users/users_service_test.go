@@ -2,8 +2,11 @@ package users_test
 
 import (
 	"errors"
+	"fmt"
 	"github.com/stretchr/testify/assert"
+	"net"
 	"testing"
+	"time"
 	dao "users-api/dao/users"
 	domain "users-api/domain/users"
 	"users-api/internal/tokenizers"
@@ -71,7 +74,7 @@ func TestService(t *testing.T) {
 		mockUser := dao.User{ID: 1, Username: "user1", Password: "password1"}
 		cacheRepo.On("GetByID", int64(1)).Return(dao.User{}, errors.New("not found")).Once()
 		memcachedRepo.On("GetByID", int64(1)).Return(mockUser, nil).Once()
-		cacheRepo.On("Create", mockUser).Return(int64(1), nil).Once()
+		cacheRepo.On("Set", mockUser, time.Minute).Return(nil).Once()
 
 		result, err := usersService.GetByID(1)
 
@@ -88,8 +91,8 @@ func TestService(t *testing.T) {
 		cacheRepo.On("GetByID", int64(1)).Return(dao.User{}, errors.New("not found")).Once()
 		memcachedRepo.On("GetByID", int64(1)).Return(dao.User{}, errors.New("not found")).Once()
 		mainRepo.On("GetByID", int64(1)).Return(mockUser, nil).Once()
-		cacheRepo.On("Create", mockUser).Return(int64(1), nil).Once()
-		memcachedRepo.On("Create", mockUser).Return(int64(1), nil).Once()
+		cacheRepo.On("Set", mockUser, time.Minute).Return(nil).Once()
+		memcachedRepo.On("Set", mockUser, 10*time.Minute).Return(nil).Once()
 
 		result, err := usersService.GetByID(1)
 
@@ -117,12 +120,26 @@ func TestService(t *testing.T) {
 		memcachedRepo.AssertExpectations(t)
 	})
 
+	t.Run("GetByID - Not Found in Main Repo", func(t *testing.T) {
+		cacheRepo.On("GetByID", int64(2)).Return(dao.User{}, errors.New("not found")).Once()
+		memcachedRepo.On("GetByID", int64(2)).Return(dao.User{}, errors.New("not found")).Once()
+		mainRepo.On("GetByID", int64(2)).Return(dao.User{}, fmt.Errorf("error fetching user: %w", dao.ErrNotFound)).Once()
+
+		_, err := usersService.GetByID(2)
+
+		assert.ErrorIs(t, err, dao.ErrNotFound)
+
+		mainRepo.AssertExpectations(t)
+		cacheRepo.AssertExpectations(t)
+		memcachedRepo.AssertExpectations(t)
+	})
+
 	t.Run("Create - Success", func(t *testing.T) {
 		newUser := dao.User{Username: "newuser", Password: service.Hash("password")}
 		mainRepo.On("Create", newUser).Return(int64(1), nil).Once()
 		newUser.ID = 1
-		cacheRepo.On("Create", newUser).Return(int64(1), nil).Once()
-		memcachedRepo.On("Create", newUser).Return(int64(1), nil).Once()
+		cacheRepo.On("Set", newUser, time.Minute).Return(nil).Once()
+		memcachedRepo.On("Set", newUser, 10*time.Minute).Return(nil).Once()
 
 		id, err := usersService.Create(domain.User{Username: "newuser", Password: "password"})
 
@@ -152,8 +169,8 @@ func TestService(t *testing.T) {
 	t.Run("Update - Success", func(t *testing.T) {
 		updateUser := dao.User{ID: 1, Username: "updateduser", Password: service.Hash("newpassword")}
 		mainRepo.On("Update", updateUser).Return(nil).Once()
-		cacheRepo.On("Update", updateUser).Return(nil).Once()
-		memcachedRepo.On("Update", updateUser).Return(nil).Once()
+		cacheRepo.On("Set", updateUser, time.Minute).Return(nil).Once()
+		memcachedRepo.On("Set", updateUser, 10*time.Minute).Return(nil).Once()
 
 		userToUpdate := domain.User{ID: 1, Username: "updateduser", Password: "newpassword"}
 		err := usersService.Update(userToUpdate)
@@ -194,6 +211,22 @@ func TestService(t *testing.T) {
 		memcachedRepo.AssertExpectations(t)
 	})
 
+	t.Run("Delete - Cache Error", func(t *testing.T) {
+		mainRepo.On("Delete", int64(1)).Return(nil).Once()
+		cacheRepo.On("Delete", int64(1)).Return(nil).Once()
+		memcachedRepo.On("Delete", int64(1)).Return(errors.New("memcached down")).Once()
+		cacheErrors := usersService.CacheErrors()
+
+		err := usersService.Delete(1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, cacheErrors+1, usersService.CacheErrors())
+
+		mainRepo.AssertExpectations(t)
+		cacheRepo.AssertExpectations(t)
+		memcachedRepo.AssertExpectations(t)
+	})
+
 	t.Run("Delete - Error", func(t *testing.T) {
 		mainRepo.On("Delete", int64(1)).Return(errors.New("db error")).Once()
 
@@ -285,3 +318,54 @@ func TestService(t *testing.T) {
 		memcachedRepo.AssertExpectations(t)
 	})
 }
+
+func TestServiceMemcachedOutage(t *testing.T) {
+	// Nothing listens on this local port, so every Memcached call fails as if the server were down
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	assert.NoError(t, listener.Close())
+
+	mainRepo := repositories.NewMock()
+	usersService := service.NewService(
+		mainRepo,
+		repositories.NewCache(repositories.CacheConfig{TTL: time.Minute}),
+		repositories.NewMemcached(repositories.MemcachedConfig{Host: "127.0.0.1", Port: port}),
+		tokenizers.NewMock(),
+	)
+
+	t.Run("Create succeeds when Memcached is down", func(t *testing.T) {
+		newUser := dao.User{Username: "user1", Password: service.Hash("password")}
+		mainRepo.On("Create", newUser).Return(int64(1), nil).Once()
+
+		id, err := usersService.Create(domain.User{Username: "user1", Password: "password"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), id)
+		assert.Equal(t, int64(1), usersService.CacheErrors())
+		mainRepo.AssertExpectations(t)
+	})
+
+	t.Run("GetByID falls back to the main repository", func(t *testing.T) {
+		mockUser := dao.User{ID: 2, Username: "user2", Password: "hashed"}
+		mainRepo.On("GetByID", int64(2)).Return(mockUser, nil).Once()
+
+		user, err := usersService.GetByID(2)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "user2", user.Username)
+		assert.Equal(t, int64(2), usersService.CacheErrors())
+		mainRepo.AssertExpectations(t)
+	})
+
+	t.Run("Delete succeeds when Memcached is down", func(t *testing.T) {
+		mainRepo.On("Delete", int64(3)).Return(nil).Once()
+
+		err := usersService.Delete(3)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), usersService.CacheErrors())
+		assert.Equal(t, int64(3), usersService.CacheMetrics().Errors)
+		mainRepo.AssertExpectations(t)
+	})
+}
@@ -41,10 +41,16 @@ const Bookings = ({ token }) => {
     if (!confirmCancel) return;
 
     try {
-      await axios.delete(`http://localhost:8081/hotels/reservations/${reservationId}`, {
+      const response = await axios.delete(`http://localhost:8081/hotels/reservations/${reservationId}`, {
         headers: { Authorization: `Bearer ${token}` }
       });
-      setReservationStatus("Reserva cancelada con éxito!");
+      // La respuesta trae la penalidad y el reembolso segun la politica del hotel
+      const cancellation = response.data.cancellation;
+      setReservationStatus(
+        cancellation
+          ? `Reserva cancelada con éxito! Reembolso: $${cancellation.refund}, penalidad: $${cancellation.penalty}`
+          : "Reserva cancelada con éxito!"
+      );
       fetchReservations(); // Refresca las reservas después de cancelar
     } catch (err) {
       console.error("Error cancelando reserva:", err);
@@ -79,7 +85,11 @@ const Bookings = ({ token }) => {
               <h3>{reservation.hotel_name || "Hotel desconocido"}</h3> 
               <p>Check-in: {new Date(reservation.check_in).toLocaleDateString()}</p>
               <p>Check-out: {new Date(reservation.check_out).toLocaleDateString()}</p>
-              <button onClick={() => handleCancelReservation(reservation.id)}>Cancelar Reserva</button>
+              {reservation.status === "cancelled" ? (
+                <p>Cancelada</p>
+              ) : (
+                <button onClick={() => handleCancelReservation(reservation.id)}>Cancelar Reserva</button>
+              )}
             </li>
           ))
         )}